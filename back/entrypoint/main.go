@@ -38,27 +38,64 @@ func main() {
 }
 
 func buildServerContext(cfg *config.Config, db *gorm.DB) *server.ServerContext {
+	jwtService, err := services.NewJwtService(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create jwt service: %v", err)
+	}
+
 	services := server.ServerServices{
-		AuthService:      services.NewAuthService(cfg, db),
-		UserService:      services.NewUserService(db),
-		OfferService:     services.NewOfferService(db),
-		PurchaseService:  services.NewPurchaseService(db),
-		UserTypeService:  services.NewUserTypeService(db),
-		ContractService:  services.NewContractService(db),
-		AnalyticsService: services.NewAnalyticsService(db),
-		CceeService:      services.NewCceeService(),
-		BrasilApiService: services.NewBrasilApiService(),
+		AuthService:        services.NewAuthService(cfg, db, jwtService),
+		UserService:        services.NewUserService(db),
+		OfferService:       services.NewOfferService(db),
+		PurchaseService:    services.NewPurchaseService(db),
+		UserTypeService:    services.NewUserTypeService(db),
+		ContractService:    services.NewContractService(db),
+		AnalyticsService:   services.NewAnalyticsService(db),
+		CceeService:        services.NewCceeService(),
+		BrasilApiService:   services.NewBrasilApiService(),
+		JwtService:         jwtService,
+		AccountService:     services.NewAccountService(cfg, db),
+		TwoFactorService:   services.NewTwoFactorService(cfg, db),
+		AgentService:       services.NewAgentService(cfg, db),
+		AdminService:       services.NewAdminService(db),
+		ProfileService:     services.NewProfileService(cfg, db),
+		PrivacyService:     services.NewPrivacyService(cfg, db),
+		ApiKeyService:      services.NewApiKeyService(db),
+		AuditService:       services.NewAuditService(db),
+		SessionService:     services.NewSessionService(db),
+		RfqService:         services.NewRfqService(db),
+		OrderBookService:   services.NewOrderBookService(db),
+		AuctionService:     services.NewAuctionService(db),
+		NegotiationService: services.NewNegotiationService(db),
+
+		OfferTemplateService: services.NewOfferTemplateService(db),
 	}
 
 	handlers := server.ServerHandlers{
-		AuthHandlers:      handlers.NewAuthHandler(services.AuthService),
-		CnpjHandlers:      handlers.NewCnpjHandler(),
-		OfferHandlers:     handlers.NewOfferHandler(services.OfferService),
-		PurchaseHandlers:  handlers.NewPurchaseHandlers(services.PurchaseService),
-		ContractHandlers:  handlers.NewContractHandlers(services.ContractService),
-		AnalyticsHandlers: handlers.NewAnalyticsHandler(services.AnalyticsService),
-		CceeHandlers:      handlers.NewCceeHandler(services.CceeService),
-		BrasilApiHandlers: handlers.NewBrasilApiHandler(services.BrasilApiService),
+		AuthHandlers:        handlers.NewAuthHandler(services.AuthService),
+		CnpjHandlers:        handlers.NewCnpjHandler(),
+		OfferHandlers:       handlers.NewOfferHandler(services.OfferService),
+		PurchaseHandlers:    handlers.NewPurchaseHandlers(services.PurchaseService),
+		ContractHandlers:    handlers.NewContractHandlers(services.ContractService),
+		AnalyticsHandlers:   handlers.NewAnalyticsHandler(services.AnalyticsService),
+		CceeHandlers:        handlers.NewCceeHandler(services.CceeService),
+		BrasilApiHandlers:   handlers.NewBrasilApiHandler(services.BrasilApiService),
+		JwksHandlers:        handlers.NewJwksHandler(services.JwtService),
+		AccountHandlers:     handlers.NewAccountHandler(services.AccountService),
+		TwoFactorHandlers:   handlers.NewTwoFactorHandler(services.TwoFactorService),
+		AgentHandlers:       handlers.NewAgentHandler(services.AgentService),
+		AdminHandlers:       handlers.NewAdminHandler(services.AdminService),
+		ProfileHandlers:     handlers.NewProfileHandler(services.ProfileService),
+		PrivacyHandlers:     handlers.NewPrivacyHandler(services.PrivacyService),
+		ApiKeyHandlers:      handlers.NewApiKeyHandler(services.ApiKeyService),
+		AuditHandlers:       handlers.NewAuditHandler(services.AuditService),
+		SessionHandlers:     handlers.NewSessionHandler(services.SessionService),
+		RfqHandlers:         handlers.NewRfqHandler(services.RfqService),
+		OrderBookHandlers:   handlers.NewOrderBookHandler(services.OrderBookService),
+		AuctionHandlers:     handlers.NewAuctionHandler(services.AuctionService),
+		NegotiationHandlers: handlers.NewNegotiationHandler(services.NegotiationService),
+
+		OfferTemplateHandlers: handlers.NewOfferTemplateHandler(services.OfferTemplateService),
 	}
 
 	return &server.ServerContext{
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 	"github.com/joho/godotenv"
@@ -11,11 +12,16 @@ type Config struct {
 	AppName        string `env:"APP_NAME" envDefault:"Ecoply"`
 	AppEnvironment string `env:"APP_ENV" envDefault:"dev"`
 	AppDebug       bool   `env:"APP_DEBUG" envDefault:"false"`
+	AppFrontendUrl string `env:"APP_FRONTEND_URL" envDefault:"http://localhost:5173"`
 
 	ServerHost  string `env:"SERVER_HOST" envDefault:"localhost"`
 	ServerPort  uint16 `env:"SERVER_PORT" envDefault:"8080"`
 	ServerDelay int64  `env:"SERVER_DELAY" envDefault:"500"`
 
+	// Proxies allowed to set X-Forwarded-For, the client IP is used to
+	// throttle logins. Empty means the connection address is always used.
+	ServerTrustedProxies []string `env:"SERVER_TRUSTED_PROXIES" envSeparator:","`
+
 	DBConnection string `env:"DB_CONNECTION" envDefault:"postgres"`
 	DBHost       string `env:"DB_HOST" envDefault:"localhost"`
 	DBPort       uint16 `env:"DB_PORT" envDefault:"5432"`
@@ -26,7 +32,30 @@ type Config struct {
 
 	MigrationsPath string `env:"MIGRATIONS_PATH" envDefault:"internal/database/migrations"`
 
-	JWTSigningKey string `env:"JWT_SIGNING_KEY" envDefault:"your-secret-key"`
+	JWTSigningKey     string        `env:"JWT_SIGNING_KEY" envDefault:"your-secret-key"`
+	JWTKeysDir        string        `env:"JWT_KEYS_DIR"`
+	JWTActiveKeyId    string        `env:"JWT_ACTIVE_KEY_ID"`
+	JWTAccessTokenTTL time.Duration `env:"JWT_ACCESS_TOKEN_TTL" envDefault:"15m"`
+	RefreshTokenTTL   time.Duration `env:"REFRESH_TOKEN_TTL" envDefault:"720h"`
+
+	MailDriver   string `env:"MAIL_DRIVER" envDefault:"log"`
+	MailFrom     string `env:"MAIL_FROM" envDefault:"Ecoply <no-reply@ecoply.local>"`
+	SmtpHost     string `env:"SMTP_HOST" envDefault:"localhost"`
+	SmtpPort     uint16 `env:"SMTP_PORT" envDefault:"587"`
+	SmtpUsername string `env:"SMTP_USERNAME"`
+	SmtpPassword string `env:"SMTP_PASSWORD"`
+
+	PasswordHasher    string `env:"PASSWORD_HASHER" envDefault:"argon2id"`
+	Argon2Memory      uint32 `env:"ARGON2_MEMORY" envDefault:"65536"`
+	Argon2Iterations  uint32 `env:"ARGON2_ITERATIONS" envDefault:"3"`
+	Argon2Parallelism uint8  `env:"ARGON2_PARALLELISM" envDefault:"2"`
+	BcryptCost        int    `env:"BCRYPT_COST" envDefault:"12"`
+
+	TwoFactorRequiredFor []string `env:"TWO_FACTOR_REQUIRED_FOR" envSeparator:","`
+
+	AdminEmails []string `env:"ADMIN_EMAILS" envSeparator:","`
+
+	AuditHashKey string `env:"AUDIT_HASH_KEY" envDefault:"your-audit-key"`
 }
 
 var (
@@ -39,6 +39,7 @@ func mountPostgresDsn(cfg *DatabaseConfig) string {
 func New(cfg *config.Config) *gorm.DB {
 	con := Open(cfg)
 	Migrate(con)
+	PromoteAdmins(con, cfg.AdminEmails)
 	return con
 }
 
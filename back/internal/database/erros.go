@@ -4,6 +4,8 @@ import "errors"
 
 var (
 	ErrFailedToOpenConnectionPostgres = makeError("Failed to open connection with postgres")
+	ErrFailedToRunMigration           = makeError("Failed to run migration")
+	ErrFailedToPromoteAdmins          = makeError("Failed to promote admins")
 )
 
 func makeError(message string) error {
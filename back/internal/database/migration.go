@@ -2,6 +2,8 @@ package database
 
 import (
 	"ecoply/internal/domain/models"
+	"log"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -13,10 +15,20 @@ type Migration struct {
 
 func Migrate(con *gorm.DB) {
 	con.Migrator().AutoMigrate(
+		&Migration{},
+
 		&models.UserType{},
 		&models.Submarket{},
 		&models.User{},
 		&models.Agent{},
+		&models.AgentInvitation{},
+		&models.RefreshToken{},
+		&models.Session{},
+		&models.UserToken{},
+		&models.TwoFactor{},
+		&models.TwoFactorRecoveryCode{},
+		&models.ApiKey{},
+		&models.LoginThrottle{},
 
 		&models.Address{},
 		&models.AddressStreet{},
@@ -26,12 +38,127 @@ func Migrate(con *gorm.DB) {
 
 		&models.EnergyType{},
 		&models.Offer{},
+		&models.OfferPriceTier{},
+		&models.OfferDeliveryBlock{},
+		&models.OfferTemplate{},
 		&models.Purchase{},
+		&models.PurchaseDelivery{},
+		&models.AuctionBid{},
+		&models.Negotiation{},
+		&models.NegotiationProposal{},
+		&models.Rfq{},
+		&models.RfqQuote{},
+		&models.Bid{},
+
+		&models.AdminAction{},
+		&models.AuditEvent{},
 	)
 
 	insertUserTypes(con)
 	insertSubmarkets(con)
 	insertEnergyTypes(con)
+
+	runOnce(con, "verify_emails_of_existing_users", verifyEmailsOfExistingUsers)
+	runOnce(con, "insert_admin_user_type", insertAdminUserType)
+	runOnce(con, "make_audit_events_append_only", makeAuditEventsAppendOnly)
+	runOnce(con, "allow_audit_events_anonymization", allowAuditEventsAnonymization)
+}
+
+// PromoteAdmins turns the users with the given emails into administrators.
+// Admins are only provisioned this way, they can't sign up as such.
+func PromoteAdmins(con *gorm.DB, emails []string) {
+	if len(emails) == 0 {
+		return
+	}
+
+	var adminType models.UserType
+	if err := con.Where("type = ?", models.UserTypeAdmin).First(&adminType).Error; err != nil {
+		log.Fatalf("%v: %v\n", ErrFailedToPromoteAdmins, err)
+	}
+
+	err := con.Model(&models.User{}).
+		Where("email IN ? AND user_type_id <> ?", emails, adminType.ID).
+		Update("user_type_id", adminType.ID).Error
+	if err != nil {
+		log.Fatalf("%v: %v\n", ErrFailedToPromoteAdmins, err)
+	}
+}
+
+// runOnce applies a data migration a single time, recording it by name in
+// the migrations table.
+func runOnce(con *gorm.DB, name string, fn func(tx *gorm.DB) error) {
+	var count int64
+	con.Model(&Migration{}).Where("name = ?", name).Count(&count)
+
+	if count > 0 {
+		return
+	}
+
+	err := con.Transaction(func(tx *gorm.DB) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return tx.Create(&Migration{Name: name}).Error
+	})
+	if err != nil {
+		log.Fatalf("%v %s: %v\n", ErrFailedToRunMigration, name, err)
+	}
+}
+
+// The application never changes audit events, the trigger makes sure
+// nobody else does either.
+func makeAuditEventsAppendOnly(tx *gorm.DB) error {
+	err := tx.Exec(`
+		CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit_events is append-only';
+		END;
+		$$ LANGUAGE plpgsql
+	`).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Exec(`
+		CREATE TRIGGER audit_events_append_only
+		BEFORE UPDATE OR DELETE ON audit_events
+		FOR EACH ROW EXECUTE FUNCTION audit_events_append_only()
+	`).Error
+}
+
+// allowAuditEventsAnonymization lets through the single update the account
+// deletion needs: blanking the IP and user agent of an event, and the
+// snapshot of a failed login. Anything else is still refused.
+func allowAuditEventsAnonymization(tx *gorm.DB) error {
+	return tx.Exec(`
+		CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+		BEGIN
+			IF TG_OP = 'UPDATE'
+				AND NEW.ip = '' AND NEW.user_agent = ''
+				AND (NEW.after = OLD.after OR (NEW.after = '' AND OLD.event = 'auth.login_failed'))
+				AND NEW.id = OLD.id AND NEW.uuid = OLD.uuid AND NEW.event = OLD.event
+				AND NEW.actor_id IS NOT DISTINCT FROM OLD.actor_id
+				AND NEW.target_type = OLD.target_type AND NEW.target_uuid = OLD.target_uuid
+				AND NEW.before = OLD.before AND NEW.created_at = OLD.created_at THEN
+				RETURN NEW;
+			END IF;
+
+			RAISE EXCEPTION 'audit_events is append-only';
+		END;
+		$$ LANGUAGE plpgsql
+	`).Error
+}
+
+// Users created before email verification existed are trusted as verified.
+func verifyEmailsOfExistingUsers(tx *gorm.DB) error {
+	return tx.Model(&models.User{}).
+		Where("email_verified_at IS NULL").
+		Update("email_verified_at", time.Now()).Error
+}
+
+func insertAdminUserType(tx *gorm.DB) error {
+	return tx.Where(models.UserType{Type: models.UserTypeAdmin}).
+		FirstOrCreate(&models.UserType{}).Error
 }
 
 func insertUserTypes(con *gorm.DB) {
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccountHandlers interface {
+	ForgotPassword(c *gin.Context)
+	ResetPassword(c *gin.Context)
+	VerifyEmail(c *gin.Context)
+	ResendEmailVerification(c *gin.Context)
+}
+
+type accountHandlers struct {
+	accountService services.AccountService
+}
+
+func NewAccountHandler(accountService services.AccountService) AccountHandlers {
+	return &accountHandlers{
+		accountService: accountService,
+	}
+}
+
+func (h *accountHandlers) ForgotPassword(c *gin.Context) {
+	var payload requests.ForgotPassword
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	if err := h.accountService.ForgotPassword(&payload); err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+func (h *accountHandlers) ResetPassword(c *gin.Context) {
+	var payload requests.ResetPassword
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	if err := h.accountService.ResetPassword(&payload); err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+func (h *accountHandlers) VerifyEmail(c *gin.Context) {
+	var payload requests.VerifyEmail
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	if err := h.accountService.VerifyEmail(&payload); err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+func (h *accountHandlers) ResendEmailVerification(c *gin.Context) {
+	var user *models.User = GetUserFromContext(c)
+
+	if err := h.accountService.SendEmailVerification(user); err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusNoContent)
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandlers interface {
+	ListUsers(c *gin.Context)
+	ListAgents(c *gin.Context)
+	SuspendUser(c *gin.Context)
+	UnsuspendUser(c *gin.Context)
+	UnlockUser(c *gin.Context)
+	ExpireOffer(c *gin.Context)
+	TakeDownOffer(c *gin.Context)
+	GetPurchase(c *gin.Context)
+	GetContract(c *gin.Context)
+	CompletePurchase(c *gin.Context)
+	CancelPurchase(c *gin.Context)
+	ListActions(c *gin.Context)
+}
+
+type adminHandlers struct {
+	adminService services.AdminService
+}
+
+func NewAdminHandler(adminService services.AdminService) AdminHandlers {
+	return &adminHandlers{
+		adminService: adminService,
+	}
+}
+
+func (h *adminHandlers) ListUsers(c *gin.Context) {
+	var params requests.AdminSearch
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.adminService.ListUsers(&params)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *adminHandlers) ListAgents(c *gin.Context) {
+	var params requests.AdminSearch
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.adminService.ListAgents(&params)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *adminHandlers) SuspendUser(c *gin.Context) {
+	h.withReason(c, h.adminService.SuspendUser)
+}
+
+func (h *adminHandlers) UnsuspendUser(c *gin.Context) {
+	h.withReason(c, h.adminService.UnsuspendUser)
+}
+
+func (h *adminHandlers) UnlockUser(c *gin.Context) {
+	h.withReason(c, h.adminService.UnlockUser)
+}
+
+func (h *adminHandlers) ExpireOffer(c *gin.Context) {
+	h.withReason(c, h.adminService.ExpireOffer)
+}
+
+func (h *adminHandlers) TakeDownOffer(c *gin.Context) {
+	h.withReason(c, h.adminService.TakeDownOffer)
+}
+
+func (h *adminHandlers) GetPurchase(c *gin.Context) {
+	var admin *models.User = GetUserFromContext(c)
+	var purchaseUuid string = c.Param("uuid")
+
+	response, err := h.adminService.GetPurchase(admin, GetClientInfo(c), purchaseUuid)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (h *adminHandlers) GetContract(c *gin.Context) {
+	var admin *models.User = GetUserFromContext(c)
+	var purchaseUuid string = c.Param("uuid")
+
+	response, err := h.adminService.GetContract(admin, GetClientInfo(c), purchaseUuid)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (h *adminHandlers) CompletePurchase(c *gin.Context) {
+	h.withReason(c, h.adminService.CompletePurchase)
+}
+
+func (h *adminHandlers) CancelPurchase(c *gin.Context) {
+	h.withReason(c, h.adminService.CancelPurchase)
+}
+
+func (h *adminHandlers) ListActions(c *gin.Context) {
+	var params requests.AdminListActions
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.adminService.ListActions(&params)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// withReason handles the actions on a ":uuid" target that only take the
+// reason recorded in the audit.
+func (h *adminHandlers) withReason(
+	c *gin.Context,
+	action func(admin *models.User, client *requests.ClientInfo, uuid string, request *requests.AdminReason) *merr.ResponseError,
+) {
+	var admin *models.User = GetUserFromContext(c)
+	var payload requests.AdminReason
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	if err := action(admin, GetClientInfo(c), c.Param("uuid"), &payload); err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusNoContent)
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AgentHandlers interface {
+	Members(c *gin.Context)
+	UpdateMemberRole(c *gin.Context)
+	Invitations(c *gin.Context)
+	Invite(c *gin.Context)
+	RevokeInvitation(c *gin.Context)
+}
+
+type agentHandlers struct {
+	agentService services.AgentService
+}
+
+func NewAgentHandler(agentService services.AgentService) AgentHandlers {
+	return &agentHandlers{
+		agentService: agentService,
+	}
+}
+
+func (h *agentHandlers) Members(c *gin.Context) {
+	var user *models.User = GetUserFromContext(c)
+
+	response, err := h.agentService.Members(user)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (h *agentHandlers) UpdateMemberRole(c *gin.Context) {
+	var user *models.User = GetUserFromContext(c)
+	var memberUuid string = c.Param("uuid")
+	var payload requests.UpdateMemberRole
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	if err := h.agentService.UpdateMemberRole(user, memberUuid, &payload); err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+func (h *agentHandlers) Invitations(c *gin.Context) {
+	var user *models.User = GetUserFromContext(c)
+
+	response, err := h.agentService.Invitations(user)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (h *agentHandlers) Invite(c *gin.Context) {
+	var user *models.User = GetUserFromContext(c)
+	var payload requests.InviteMember
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.agentService.Invite(user, &payload)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": response})
+}
+
+func (h *agentHandlers) RevokeInvitation(c *gin.Context) {
+	var user *models.User = GetUserFromContext(c)
+	var invitationUuid string = c.Param("uuid")
+
+	if err := h.agentService.RevokeInvitation(user, invitationUuid); err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusNoContent)
+}
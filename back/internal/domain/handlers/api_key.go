@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ApiKeyHandlers interface {
+	List(c *gin.Context)
+	Create(c *gin.Context)
+	Revoke(c *gin.Context)
+}
+
+type apiKeyHandlers struct {
+	apiKeyService services.ApiKeyService
+}
+
+func NewApiKeyHandler(apiKeyService services.ApiKeyService) ApiKeyHandlers {
+	return &apiKeyHandlers{
+		apiKeyService: apiKeyService,
+	}
+}
+
+func (h *apiKeyHandlers) List(c *gin.Context) {
+	var user *models.User = GetUserFromContext(c)
+
+	response, err := h.apiKeyService.List(user)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (h *apiKeyHandlers) Create(c *gin.Context) {
+	var user *models.User = GetUserFromContext(c)
+	var payload requests.CreateApiKey
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.apiKeyService.Create(user, &payload)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": response})
+}
+
+func (h *apiKeyHandlers) Revoke(c *gin.Context) {
+	var user *models.User = GetUserFromContext(c)
+	var uuid string = c.Param("uuid")
+
+	if err := h.apiKeyService.Revoke(user, uuid); err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusNoContent)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuctionHandlers interface {
+	Bid(c *gin.Context)
+	Bids(c *gin.Context)
+}
+
+type auctionHandlers struct {
+	auctionService services.AuctionService
+}
+
+func NewAuctionHandler(auctionService services.AuctionService) AuctionHandlers {
+	return &auctionHandlers{
+		auctionService: auctionService,
+	}
+}
+
+func (h *auctionHandlers) Bid(c *gin.Context) {
+	var payload requests.PlaceAuctionBid
+	var uuid string = c.Param("uuid")
+	var user *models.User = GetUserFromContext(c)
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.auctionService.Bid(user, uuid, &payload)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": response})
+}
+
+func (h *auctionHandlers) Bids(c *gin.Context) {
+	var uuid string = c.Param("uuid")
+	var user *models.User = GetUserFromContext(c)
+
+	response, err := h.auctionService.Bids(user, uuid)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
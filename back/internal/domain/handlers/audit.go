@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandlers interface {
+	Activity(c *gin.Context)
+	List(c *gin.Context)
+}
+
+type auditHandlers struct {
+	auditService services.AuditService
+}
+
+func NewAuditHandler(auditService services.AuditService) AuditHandlers {
+	return &auditHandlers{
+		auditService: auditService,
+	}
+}
+
+func (h *auditHandlers) Activity(c *gin.Context) {
+	var params requests.ListActivity
+	var user *models.User = GetUserFromContext(c)
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.auditService.Activity(user, &params)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *auditHandlers) List(c *gin.Context) {
+	var params requests.AdminListAuditEvents
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.auditService.List(&params)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
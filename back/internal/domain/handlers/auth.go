@@ -16,6 +16,10 @@ type AuthHandlers interface {
 	Me(c *gin.Context)
 	Availability(c *gin.Context)
 	RefreshToken(c *gin.Context)
+	Logout(c *gin.Context)
+	LogoutAll(c *gin.Context)
+	LoginTwoFactor(c *gin.Context)
+	AcceptInvitation(c *gin.Context)
 }
 
 type authHandlers struct {
@@ -37,7 +41,7 @@ func (h *authHandlers) Login(c *gin.Context) {
 		return
 	}
 
-	response, err := h.authService.Login(&payload)
+	response, err := h.authService.Login(&payload, GetClientInfo(c))
 	if err != nil {
 		c.JSON(err.StatusCode, err)
 		return
@@ -55,7 +59,7 @@ func (h *authHandlers) SignUp(c *gin.Context) {
 		return
 	}
 
-	response, err := h.authService.SignUp(&payload)
+	response, err := h.authService.SignUp(&payload, GetClientInfo(c))
 	if err != nil {
 		c.JSON(err.StatusCode, err)
 		return
@@ -99,12 +103,83 @@ func (h *authHandlers) Availability(c *gin.Context) {
 }
 
 func (h *authHandlers) RefreshToken(c *gin.Context) {
-	token := c.GetString("token")
-	response, err := h.authService.RefreshToken(token)
+	var payload requests.RefreshToken
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.authService.RefreshToken(&payload, GetClientInfo(c))
 	if err != nil {
 		c.JSON(err.StatusCode, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": gin.H{"token": response}})
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (h *authHandlers) Logout(c *gin.Context) {
+	var payload requests.RefreshToken
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	if err := h.authService.Logout(&payload, GetClientInfo(c)); err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+func (h *authHandlers) LogoutAll(c *gin.Context) {
+	var user *models.User = GetUserFromContext(c)
+
+	if err := h.authService.LogoutAll(user, GetClientInfo(c)); err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+func (h *authHandlers) LoginTwoFactor(c *gin.Context) {
+	var payload requests.LoginTwoFactor
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.authService.LoginTwoFactor(&payload, GetClientInfo(c))
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (h *authHandlers) AcceptInvitation(c *gin.Context) {
+	var payload requests.AcceptInvitation
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.authService.AcceptInvitation(&payload, GetClientInfo(c))
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": response})
 }
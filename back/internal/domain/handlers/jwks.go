@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"ecoply/internal/domain/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JwksHandlers interface {
+	Get(c *gin.Context)
+}
+
+type jwksHandler struct {
+	jwtService services.JwtService
+}
+
+func NewJwksHandler(jwtService services.JwtService) JwksHandlers {
+	return &jwksHandler{
+		jwtService: jwtService,
+	}
+}
+
+func (h *jwksHandler) Get(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.jwtService.Jwks())
+}
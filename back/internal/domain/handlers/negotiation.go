@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NegotiationHandlers interface {
+	Open(c *gin.Context)
+	List(c *gin.Context)
+	FindByUuid(c *gin.Context)
+	Counter(c *gin.Context)
+	Accept(c *gin.Context)
+	Reject(c *gin.Context)
+	Cancel(c *gin.Context)
+}
+
+type negotiationHandlers struct {
+	negotiationService services.NegotiationService
+}
+
+func NewNegotiationHandler(negotiationService services.NegotiationService) NegotiationHandlers {
+	return &negotiationHandlers{
+		negotiationService: negotiationService,
+	}
+}
+
+func (h *negotiationHandlers) Open(c *gin.Context) {
+	var payload requests.OpenNegotiation
+	var uuid string = c.Param("uuid")
+	var user *models.User = GetUserFromContext(c)
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.negotiationService.Open(user, uuid, &payload)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": response})
+}
+
+func (h *negotiationHandlers) List(c *gin.Context) {
+	var uuid string = c.Param("uuid")
+	var user *models.User = GetUserFromContext(c)
+
+	response, err := h.negotiationService.List(user, uuid)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (h *negotiationHandlers) FindByUuid(c *gin.Context) {
+	var uuid string = c.Param("uuid")
+	var user *models.User = GetUserFromContext(c)
+
+	response, err := h.negotiationService.GetByUuid(user, uuid)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (h *negotiationHandlers) Counter(c *gin.Context) {
+	var payload requests.NegotiationProposal
+	var uuid string = c.Param("uuid")
+	var user *models.User = GetUserFromContext(c)
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.negotiationService.Counter(user, uuid, &payload)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": response})
+}
+
+func (h *negotiationHandlers) Accept(c *gin.Context) {
+	var uuid string = c.Param("uuid")
+	var user *models.User = GetUserFromContext(c)
+
+	response, err := h.negotiationService.Accept(user, GetClientInfo(c), uuid)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": response})
+}
+
+func (h *negotiationHandlers) Reject(c *gin.Context) {
+	var uuid string = c.Param("uuid")
+	var user *models.User = GetUserFromContext(c)
+
+	if err := h.negotiationService.Reject(user, uuid); err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+func (h *negotiationHandlers) Cancel(c *gin.Context) {
+	var uuid string = c.Param("uuid")
+	var user *models.User = GetUserFromContext(c)
+
+	if err := h.negotiationService.Cancel(user, uuid); err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusNoContent)
+}
@@ -5,7 +5,12 @@ import (
 	"ecoply/internal/domain/models"
 	"ecoply/internal/domain/requests"
 	"ecoply/internal/domain/services"
+	"ecoply/internal/domain/utils"
+	"fmt"
 	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,6 +23,11 @@ type OfferHandlers interface {
 	Update(c *gin.Context)
 	Delete(c *gin.Context)
 	Purchases(c *gin.Context)
+	Quote(c *gin.Context)
+	Import(c *gin.Context)
+	Export(c *gin.Context)
+	Pause(c *gin.Context)
+	Resume(c *gin.Context)
 }
 
 type offerHandler struct {
@@ -32,8 +42,9 @@ func NewOfferHandler(offerService services.OfferService) OfferHandlers {
 
 func (h *offerHandler) FindByUuid(c *gin.Context) {
 	var uuid string = c.Param("uuid")
+	var user *models.User = GetUserFromContext(c)
 
-	response, err := h.offerService.GetByUuid(uuid)
+	response, err := h.offerService.GetByUuid(uuid, user)
 	if err != nil {
 		c.JSON(err.StatusCode, err)
 		return
@@ -65,7 +76,7 @@ func (h *offerHandler) List(c *gin.Context) {
 func (h *offerHandler) FromUser(c *gin.Context) {
 	var user *models.User = GetUserFromContext(c)
 
-	response, err := h.offerService.BelongingToUser(user.ID)
+	response, err := h.offerService.BelongingToAgent(user.AgentId)
 	if err != nil {
 		c.JSON(err.StatusCode, err)
 		return
@@ -83,7 +94,7 @@ func (h *offerHandler) Create(c *gin.Context) {
 	}
 
 	var user *models.User = GetUserFromContext(c)
-	response, err := h.offerService.Create(user, &payload)
+	response, err := h.offerService.Create(user, GetClientInfo(c), &payload)
 	if err != nil {
 		c.JSON(err.StatusCode, err)
 		return
@@ -103,7 +114,7 @@ func (h *offerHandler) Update(c *gin.Context) {
 		return
 	}
 
-	err := h.offerService.Update(user, uuid, &payload)
+	err := h.offerService.Update(user, GetClientInfo(c), uuid, &payload)
 	if err != nil {
 		c.JSON(err.StatusCode, err)
 		return
@@ -116,7 +127,7 @@ func (h *offerHandler) Delete(c *gin.Context) {
 	var uuid string = c.Param("uuid")
 	var user *models.User = GetUserFromContext(c)
 
-	err := h.offerService.Delete(user, uuid)
+	err := h.offerService.Delete(user, GetClientInfo(c), uuid)
 	if err != nil {
 		c.JSON(err.StatusCode, err)
 		return
@@ -125,6 +136,32 @@ func (h *offerHandler) Delete(c *gin.Context) {
 	c.AbortWithStatus(http.StatusNoContent)
 }
 
+func (h *offerHandler) Pause(c *gin.Context) {
+	var uuid string = c.Param("uuid")
+	var user *models.User = GetUserFromContext(c)
+
+	response, err := h.offerService.Pause(user, GetClientInfo(c), uuid)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (h *offerHandler) Resume(c *gin.Context) {
+	var uuid string = c.Param("uuid")
+	var user *models.User = GetUserFromContext(c)
+
+	response, err := h.offerService.Resume(user, GetClientInfo(c), uuid)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
 func (h *offerHandler) Purchases(c *gin.Context) {
 	var params requests.ListPurchasesFromOffer
 	var uuid string = c.Param("uuid")
@@ -144,3 +181,95 @@ func (h *offerHandler) Purchases(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"data": response})
 }
+
+func (h *offerHandler) Quote(c *gin.Context) {
+	var params requests.QuoteOffer
+	var uuid string = c.Param("uuid")
+	var user *models.User = GetUserFromContext(c)
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.offerService.Quote(uuid, user, &params)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (h *offerHandler) Import(c *gin.Context) {
+	var params requests.ImportOffers
+	var user *models.User = GetUserFromContext(c)
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var response *merr.ResponseError = merr.NewResponseError(http.StatusUnprocessableEntity, services.ErrInvalidImportFile)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	if params.Format == "" {
+		params.Format = services.OfferFileFormatCsv
+		if strings.EqualFold(filepath.Ext(header.Filename), ".xlsx") {
+			params.Format = services.OfferFileFormatXlsx
+		}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		var response *merr.ResponseError = merr.NewResponseError(http.StatusUnprocessableEntity, services.ErrInvalidImportFile)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+	defer file.Close()
+
+	response, responseErr := h.offerService.Import(user, GetClientInfo(c), file, &params)
+	if responseErr != nil {
+		c.JSON(responseErr.StatusCode, responseErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (h *offerHandler) Export(c *gin.Context) {
+	var params requests.ExportOffers
+	var user *models.User = GetUserFromContext(c)
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.offerService.Export(user, &params)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	var extension string = services.OfferFileFormatCsv
+	var contentType string = "text/csv"
+	if params.Format == services.OfferFileFormatXlsx {
+		extension = services.OfferFileFormatXlsx
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+
+	var filename string = fmt.Sprintf("ecoply-offers-%s.%s", utils.NowInLocal().Format(time.DateOnly), extension)
+
+	// ContentType middleware already set a JSON content type, gin would not replace it
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Data(http.StatusOK, contentType, response)
+}
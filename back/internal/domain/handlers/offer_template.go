@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OfferTemplateHandlers interface {
+	Create(c *gin.Context)
+	List(c *gin.Context)
+	FindByUuid(c *gin.Context)
+	Update(c *gin.Context)
+	Delete(c *gin.Context)
+	Publish(c *gin.Context)
+	Preview(c *gin.Context)
+	Pause(c *gin.Context)
+	Resume(c *gin.Context)
+}
+
+type offerTemplateHandlers struct {
+	offerTemplateService services.OfferTemplateService
+}
+
+func NewOfferTemplateHandler(offerTemplateService services.OfferTemplateService) OfferTemplateHandlers {
+	return &offerTemplateHandlers{
+		offerTemplateService: offerTemplateService,
+	}
+}
+
+func (h *offerTemplateHandlers) Create(c *gin.Context) {
+	var payload requests.SaveOfferTemplate
+	var user *models.User = GetUserFromContext(c)
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.offerTemplateService.Create(user, &payload)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": response})
+}
+
+func (h *offerTemplateHandlers) List(c *gin.Context) {
+	var user *models.User = GetUserFromContext(c)
+
+	response, err := h.offerTemplateService.List(user)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (h *offerTemplateHandlers) FindByUuid(c *gin.Context) {
+	var uuid string = c.Param("uuid")
+	var user *models.User = GetUserFromContext(c)
+
+	response, err := h.offerTemplateService.GetByUuid(user, uuid)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (h *offerTemplateHandlers) Update(c *gin.Context) {
+	var payload requests.SaveOfferTemplate
+	var uuid string = c.Param("uuid")
+	var user *models.User = GetUserFromContext(c)
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.offerTemplateService.Update(user, uuid, &payload)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (h *offerTemplateHandlers) Delete(c *gin.Context) {
+	var uuid string = c.Param("uuid")
+	var user *models.User = GetUserFromContext(c)
+
+	if err := h.offerTemplateService.Delete(user, uuid); err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+func (h *offerTemplateHandlers) Publish(c *gin.Context) {
+	var payload requests.PublishOfferTemplate
+	var uuid string = c.Param("uuid")
+	var user *models.User = GetUserFromContext(c)
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.offerTemplateService.Publish(user, GetClientInfo(c), uuid, &payload)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": response})
+}
+
+func (h *offerTemplateHandlers) Preview(c *gin.Context) {
+	var params requests.PreviewOfferTemplate
+	var uuid string = c.Param("uuid")
+	var user *models.User = GetUserFromContext(c)
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.offerTemplateService.Preview(user, uuid, &params)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (h *offerTemplateHandlers) Pause(c *gin.Context) {
+	var uuid string = c.Param("uuid")
+	var user *models.User = GetUserFromContext(c)
+
+	response, err := h.offerTemplateService.Pause(user, uuid)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (h *offerTemplateHandlers) Resume(c *gin.Context) {
+	var uuid string = c.Param("uuid")
+	var user *models.User = GetUserFromContext(c)
+
+	response, err := h.offerTemplateService.Resume(user, uuid)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrderBookHandlers interface {
+	Depth(c *gin.Context)
+	PlaceBid(c *gin.Context)
+	CancelBid(c *gin.Context)
+	Bids(c *gin.Context)
+}
+
+type orderBookHandlers struct {
+	orderBookService services.OrderBookService
+}
+
+func NewOrderBookHandler(orderBookService services.OrderBookService) OrderBookHandlers {
+	return &orderBookHandlers{
+		orderBookService: orderBookService,
+	}
+}
+
+func (h *orderBookHandlers) Depth(c *gin.Context) {
+	var params requests.OrderBookDepth
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.orderBookService.Depth(&params)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (h *orderBookHandlers) PlaceBid(c *gin.Context) {
+	var payload requests.PlaceBid
+	var user *models.User = GetUserFromContext(c)
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.orderBookService.PlaceBid(user, &payload)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": response})
+}
+
+func (h *orderBookHandlers) CancelBid(c *gin.Context) {
+	var uuid string = c.Param("uuid")
+	var user *models.User = GetUserFromContext(c)
+
+	if err := h.orderBookService.CancelBid(user, uuid); err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+func (h *orderBookHandlers) Bids(c *gin.Context) {
+	var params requests.ListBids
+	var user *models.User = GetUserFromContext(c)
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.orderBookService.Bids(user, &params)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
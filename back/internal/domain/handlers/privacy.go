@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/services"
+	"ecoply/internal/domain/utils"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PrivacyHandlers interface {
+	Export(c *gin.Context)
+	DeleteAccount(c *gin.Context)
+}
+
+type privacyHandler struct {
+	privacyService services.PrivacyService
+}
+
+func NewPrivacyHandler(privacyService services.PrivacyService) PrivacyHandlers {
+	return &privacyHandler{
+		privacyService: privacyService,
+	}
+}
+
+func (h *privacyHandler) Export(c *gin.Context) {
+	var user *models.User = GetUserFromContext(c)
+
+	response, err := h.privacyService.Export(user)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	var filename string = fmt.Sprintf("ecoply-export-%s.zip", utils.NowInLocal().Format(time.DateOnly))
+
+	// ContentType middleware already set a JSON content type, gin would not replace it
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Data(http.StatusOK, "application/zip", response)
+}
+
+func (h *privacyHandler) DeleteAccount(c *gin.Context) {
+	var payload requests.DeleteAccount
+	var user *models.User = GetUserFromContext(c)
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	if err := h.privacyService.DeleteAccount(user, &payload); err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusNoContent)
+}
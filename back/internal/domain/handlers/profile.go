@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProfileHandlers interface {
+	Update(c *gin.Context)
+	ChangePassword(c *gin.Context)
+	UpdateAddress(c *gin.Context)
+}
+
+type profileHandlers struct {
+	profileService services.ProfileService
+}
+
+func NewProfileHandler(profileService services.ProfileService) ProfileHandlers {
+	return &profileHandlers{
+		profileService: profileService,
+	}
+}
+
+func (h *profileHandlers) Update(c *gin.Context) {
+	var user *models.User = GetUserFromContext(c)
+	var payload requests.UpdateProfile
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.profileService.Update(user, &payload)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (h *profileHandlers) ChangePassword(c *gin.Context) {
+	var user *models.User = GetUserFromContext(c)
+	var payload requests.ChangePassword
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	if err := h.profileService.ChangePassword(user, &payload); err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+func (h *profileHandlers) UpdateAddress(c *gin.Context) {
+	var user *models.User = GetUserFromContext(c)
+	var payload requests.Address
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.profileService.UpdateAddress(user, &payload)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
@@ -94,7 +94,7 @@ func (h *purchaseHandlers) Cancel(c *gin.Context) {
 	var purchaseUuid string = c.Param("uuid")
 	var user *models.User = GetUserFromContext(c)
 
-	err := h.purchaseService.Cancel(purchaseUuid, user)
+	err := h.purchaseService.Cancel(purchaseUuid, user, GetClientInfo(c))
 	if err != nil {
 		c.JSON(err.StatusCode, err)
 		return
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandlers interface {
+	List(c *gin.Context)
+	Revoke(c *gin.Context)
+}
+
+type sessionHandlers struct {
+	sessionService services.SessionService
+}
+
+func NewSessionHandler(sessionService services.SessionService) SessionHandlers {
+	return &sessionHandlers{
+		sessionService: sessionService,
+	}
+}
+
+func (h *sessionHandlers) List(c *gin.Context) {
+	var user *models.User = GetUserFromContext(c)
+	var currentSessionId string
+
+	if claims := GetClaimsFromContext(c); claims != nil {
+		currentSessionId = claims.SessionId
+	}
+
+	response, err := h.sessionService.List(user, currentSessionId)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (h *sessionHandlers) Revoke(c *gin.Context) {
+	var user *models.User = GetUserFromContext(c)
+	var id string = c.Param("id")
+
+	if err := h.sessionService.Revoke(user, GetClientInfo(c), id); err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusNoContent)
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorHandlers interface {
+	Status(c *gin.Context)
+	BeginEnrollment(c *gin.Context)
+	ConfirmEnrollment(c *gin.Context)
+	Disable(c *gin.Context)
+	RegenerateRecoveryCodes(c *gin.Context)
+}
+
+type twoFactorHandlers struct {
+	twoFactorService services.TwoFactorService
+}
+
+func NewTwoFactorHandler(twoFactorService services.TwoFactorService) TwoFactorHandlers {
+	return &twoFactorHandlers{
+		twoFactorService: twoFactorService,
+	}
+}
+
+func (h *twoFactorHandlers) Status(c *gin.Context) {
+	var user *models.User = GetUserFromContext(c)
+
+	response, err := h.twoFactorService.Status(user)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (h *twoFactorHandlers) BeginEnrollment(c *gin.Context) {
+	var user *models.User = GetUserFromContext(c)
+
+	response, err := h.twoFactorService.BeginEnrollment(user)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (h *twoFactorHandlers) ConfirmEnrollment(c *gin.Context) {
+	var user *models.User = GetUserFromContext(c)
+	var payload requests.TwoFactorCode
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.twoFactorService.ConfirmEnrollment(user, &payload)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func (h *twoFactorHandlers) Disable(c *gin.Context) {
+	var user *models.User = GetUserFromContext(c)
+	var payload requests.TwoFactorCode
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	if err := h.twoFactorService.Disable(user, &payload); err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+func (h *twoFactorHandlers) RegenerateRecoveryCodes(c *gin.Context) {
+	var user *models.User = GetUserFromContext(c)
+	var payload requests.TwoFactorCode
+
+	if err := c.ShouldBindBodyWithJSON(&payload); err != nil {
+		var response *merr.ResponseError = merr.BindValidationErrorsToResponse(err)
+		c.JSON(response.StatusCode, response)
+		return
+	}
+
+	response, err := h.twoFactorService.RegenerateRecoveryCodes(user, &payload)
+	if err != nil {
+		c.JSON(err.StatusCode, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
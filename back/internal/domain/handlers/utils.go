@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -10,3 +12,38 @@ func GetUserFromContext(c *gin.Context) *models.User {
 	user, _ := c.Get("user")
 	return user.(*models.User)
 }
+
+// GetApiKeyFromContext returns the key the request was authenticated with,
+// nil when it came with a JWT.
+func GetApiKeyFromContext(c *gin.Context) *models.ApiKey {
+	apiKey, exists := c.Get("api_key")
+	if !exists {
+		return nil
+	}
+	return apiKey.(*models.ApiKey)
+}
+
+// GetClaimsFromContext returns the claims of the JWT the request was
+// authenticated with, nil when it came with an API key.
+func GetClaimsFromContext(c *gin.Context) *services.Claims {
+	claims, exists := c.Get("claims")
+	if !exists {
+		return nil
+	}
+	return claims.(*services.Claims)
+}
+
+// GetPermissionsFromContext returns what the authenticated user may do, as
+// resolved by JwtAuthMiddleware.
+func GetPermissionsFromContext(c *gin.Context) []string {
+	permissions, _ := c.Get("permissions")
+	list, _ := permissions.([]string)
+	return list
+}
+
+func GetClientInfo(c *gin.Context) *requests.ClientInfo {
+	return &requests.ClientInfo{
+		Ip:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
@@ -1,6 +1,7 @@
 package middlewares
 
 import (
+	"ecoply/internal/domain/handlers"
 	"ecoply/internal/domain/merr"
 	"ecoply/internal/domain/models"
 	"ecoply/internal/domain/services"
@@ -10,7 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func JwtAuthMiddleware(userService services.UserService, jwtService services.JwtService) gin.HandlerFunc {
+func JwtAuthMiddleware(userService services.UserService, jwtService services.JwtService, sessionService services.SessionService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var responseError *merr.ResponseError
 		defer func() {
@@ -32,22 +33,101 @@ func JwtAuthMiddleware(userService services.UserService, jwtService services.Jwt
 			return
 		}
 
-		claims, err := jwtService.ValidateToken(tokenString)
-		if err != nil {
+		var user *models.User
+		var permissions []string
+
+		if services.IsApiKey(tokenString) {
+			// Keys were already resolved by ApiKeyMiddleware on the groups that take them
+			if handlers.GetApiKeyFromContext(c) == nil {
+				responseError = merr.NewResponseError(http.StatusForbidden, ErrApiKeyNotAllowed)
+				return
+			}
+			user = handlers.GetUserFromContext(c)
+			permissions = models.PermissionsForUserType(user.UserType.Type)
+		} else {
+			claims, err := jwtService.ValidateToken(tokenString)
+			if err != nil {
+				responseError = merr.NewResponseError(http.StatusUnauthorized, ErrJwtInvalidToken)
+				return
+			}
+
+			user, responseError = userService.FindByUuid(claims.UserUuid)
+			if responseError != nil {
+				responseError.StatusCode = http.StatusUnauthorized
+				return
+			}
+
+			// Tokens issued before sessions existed have no sid and are
+			// accepted until they expire
+			if claims.SessionId != "" {
+				responseError = sessionService.Authenticate(user, handlers.GetClientInfo(c), claims.SessionId)
+				if responseError != nil {
+					return
+				}
+			}
+
+			permissions = claims.Permissions
+			if permissions == nil {
+				// Issued before permissions were part of the claims
+				permissions = models.PermissionsForUserType(claims.UserType)
+			}
+
+			c.Set("claims", claims)
+		}
+
+		if user.IsAnonymized() {
 			responseError = merr.NewResponseError(http.StatusUnauthorized, ErrJwtInvalidToken)
 			return
 		}
 
-		var user *models.User
-		user, responseError = userService.FindByUuid(claims.UserUuid)
-		if responseError != nil {
-			responseError.StatusCode = http.StatusUnauthorized
+		if user.IsSuspended() {
+			responseError = merr.NewResponseError(http.StatusForbidden, ErrUserSuspended)
 			return
 		}
 
 		c.Set("user", user)
+		c.Set("permissions", permissions)
 		c.Set("token", tokenString)
-		c.Set("claims", claims)
+
+		c.Next()
+	}
+}
+
+// ApiKeyMiddleware lets a group accept API keys, it must come before
+// JwtAuthMiddleware. Each route of the group states the scope it needs with
+// RequireScope.
+func ApiKeyMiddleware(apiKeyService services.ApiKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var tokenString string = extractBearerToken(c.GetHeader("Authorization"))
+		if !services.IsApiKey(tokenString) {
+			c.Next()
+			return
+		}
+
+		user, apiKey, err := apiKeyService.Authenticate(tokenString)
+		if err != nil {
+			c.AbortWithStatusJSON(err.StatusCode, err)
+			return
+		}
+
+		c.Set("user", user)
+		c.Set("api_key", apiKey)
+
+		c.Next()
+	}
+}
+
+// RequireScope only restricts API keys, users authenticated with a JWT may
+// do anything their role allows.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var apiKey *models.ApiKey = handlers.GetApiKeyFromContext(c)
+
+		if apiKey != nil && !apiKey.HasScope(scope) {
+			err := merr.NewResponseError(http.StatusForbidden, ErrApiKeyMissingScope)
+			c.AbortWithStatusJSON(err.StatusCode, err)
+			return
+		}
 
 		c.Next()
 	}
@@ -7,5 +7,8 @@ var (
 	ErrJwtBearerTokenRequired         = errors.New("bearer token required")
 	ErrJwtInvalidToken                = errors.New("invalid token")
 	ErrMissingClaim                   = errors.New("missing claim")
-	ErrUserIsNotSupplier              = errors.New("user is not supplier")
+	ErrMissingPermission              = errors.New("user does not have the permission required by this resource")
+	ErrUserSuspended                  = errors.New("user is suspended")
+	ErrApiKeyNotAllowed               = errors.New("api keys are not accepted on this resource")
+	ErrApiKeyMissingScope             = errors.New("api key is missing the scope required by this resource")
 )
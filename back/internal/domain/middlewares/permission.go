@@ -0,0 +1,24 @@
+package middlewares
+
+import (
+	"ecoply/internal/domain/handlers"
+	"ecoply/internal/domain/merr"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission must come after JwtAuthMiddleware, which resolves the
+// permissions of the request.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !slices.Contains(handlers.GetPermissionsFromContext(c), permission) {
+			err := merr.NewResponseError(http.StatusForbidden, ErrMissingPermission)
+			c.AbortWithStatusJSON(err.StatusCode, err)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"ecoply/internal/domain/handlers"
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TwoFactorEnrollmentMiddleware blocks users whose type requires 2FA until
+// they enroll, leaving /me reachable so they can do it.
+func TwoFactorEnrollmentMiddleware(twoFactorService services.TwoFactorService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user *models.User = handlers.GetUserFromContext(c)
+
+		if !twoFactorService.IsRequired(user) {
+			c.Next()
+			return
+		}
+
+		enabled, err := twoFactorService.IsEnabled(user)
+		if err != nil {
+			c.AbortWithStatusJSON(err.StatusCode, err)
+			return
+		}
+
+		if !enabled {
+			err := merr.NewResponseError(http.StatusForbidden, services.ErrTwoFactorEnrollmentRequired)
+			c.AbortWithStatusJSON(err.StatusCode, err)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+const (
+	AdminActionUserSuspend      = "user.suspend"
+	AdminActionUserUnsuspend    = "user.unsuspend"
+	AdminActionUserUnlock       = "user.unlock"
+	AdminActionOfferExpire      = "offer.expire"
+	AdminActionOfferTakeDown    = "offer.take_down"
+	AdminActionPurchaseView     = "purchase.view"
+	AdminActionContractView     = "contract.view"
+	AdminActionPurchaseComplete = "purchase.complete"
+	AdminActionPurchaseCancel   = "purchase.cancel"
+
+	AdminTargetUser     = "user"
+	AdminTargetOffer    = "offer"
+	AdminTargetPurchase = "purchase"
+)
+
+// AdminAction records what an administrator did, it is never updated nor
+// deleted.
+type AdminAction struct {
+	ID uint `gorm:"primarykey"`
+
+	Uuid       string `gorm:"type:uuid;uniqueIndex;not null"`
+	Action     string `gorm:"type:varchar(50);not null;index"`
+	TargetType string `gorm:"type:varchar(50);not null"`
+	TargetUuid string `gorm:"type:varchar(64);not null;index"`
+	Reason     string `gorm:"type:text;not null;default:''"`
+
+	AdminId uint `gorm:"references:ID;not null;index"`
+	Admin   User `gorm:"foreignKey:AdminId"`
+
+	CreatedAt time.Time `gorm:"not null;index"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"ecoply/internal/domain/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AgentInvitation struct {
+	gorm.Model
+
+	Uuid      string `gorm:"type:uuid;uniqueIndex;not null"`
+	Email     string `gorm:"type:text;not null;index"`
+	Role      string `gorm:"type:varchar(20);not null"`
+	TokenHash string `gorm:"type:varchar(64);uniqueIndex;not null"`
+
+	ExpiresAt  time.Time  `gorm:"not null"`
+	AcceptedAt *time.Time `gorm:""`
+	RevokedAt  *time.Time `gorm:""`
+
+	AgentId uint  `gorm:"references:ID;not null;index"`
+	Agent   Agent `gorm:"foreignKey:AgentId"`
+
+	InvitedById uint `gorm:"references:ID;not null"`
+	InvitedBy   User `gorm:"foreignKey:InvitedById"`
+}
+
+func (i *AgentInvitation) IsPending() bool {
+	return i.AcceptedAt == nil && i.RevokedAt == nil && utils.NowInLocal().Before(i.ExpiresAt)
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"ecoply/internal/domain/utils"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ApiKeyScopeOffersRead     string = "offers:read"
+	ApiKeyScopeOffersWrite    string = "offers:write"
+	ApiKeyScopePurchasesRead  string = "purchases:read"
+	ApiKeyScopePurchasesWrite string = "purchases:write"
+	ApiKeyScopeSalesRead      string = "sales:read"
+)
+
+// ApiKey lets an integration act as the user that created it, limited to
+// its scopes. Only the hash of the key is stored, the prefix is kept in
+// clear so the user can tell keys apart.
+type ApiKey struct {
+	gorm.Model
+
+	Uuid    string `gorm:"type:uuid;uniqueIndex;not null"`
+	Name    string `gorm:"type:text;not null"`
+	Prefix  string `gorm:"type:varchar(20);not null"`
+	KeyHash string `gorm:"type:varchar(64);uniqueIndex;not null"`
+	Scopes  string `gorm:"type:text;not null"`
+
+	ExpiresAt  *time.Time `gorm:""`
+	LastUsedAt *time.Time `gorm:""`
+	RevokedAt  *time.Time `gorm:""`
+
+	UserId uint `gorm:"references:ID;not null;index"`
+	User   User `gorm:"foreignKey:UserId"`
+}
+
+func (k *ApiKey) IsUsable() bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || utils.NowInLocal().Before(*k.ExpiresAt)
+}
+
+func (k *ApiKey) ScopeList() []string {
+	return strings.Split(k.Scopes, ",")
+}
+
+func (k *ApiKey) HasScope(scope string) bool {
+	return slices.Contains(k.ScopeList(), scope)
+}
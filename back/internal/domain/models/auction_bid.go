@@ -0,0 +1,36 @@
+package models
+
+import "gorm.io/gorm"
+
+const (
+	AuctionBidStatusActive string = "active"
+	AuctionBidStatusOutbid string = "outbid"
+	AuctionBidStatusWon    string = "won"
+	AuctionBidStatusLost   string = "lost"
+)
+
+// AuctionBid is a buyer's bid for the whole quantity of an auctioned offer.
+// Dutch auctions take no bids, the first buyer to accept the current price
+// wins right away.
+type AuctionBid struct {
+	gorm.Model
+
+	Uuid string `gorm:"type:uuid;uniqueIndex;not null"`
+
+	PricePerMwh   float64 `gorm:"type:decimal(10,2);not null"`
+	PaymentMethod string  `gorm:"type:varchar(20);not null"`
+	Status        string  `gorm:"type:varchar(20);not null"`
+
+	OfferId uint  `gorm:"references:ID;not null;index"`
+	Offer   Offer `gorm:"foreignKey:OfferId"`
+
+	BidderId uint `gorm:"references:ID;not null;index"`
+	Bidder   User `gorm:"foreignKey:BidderId"`
+
+	// Set on the winning bid
+	PurchaseId *uint
+}
+
+func (b *AuctionBid) IsActive() bool {
+	return b.Status == AuctionBidStatusActive
+}
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+const (
+	AuditEventLogin               = "auth.login"
+	AuditEventLoginFailed         = "auth.login_failed"
+	AuditEventLoginTwoFactor      = "auth.login_two_factor"
+	AuditEventLoginTwoFactorFail  = "auth.login_two_factor_failed"
+	AuditEventSignUp              = "auth.signup"
+	AuditEventInvitationAccepted  = "auth.invitation_accepted"
+	AuditEventTokenRefreshed      = "auth.token_refreshed"
+	AuditEventTokenReused         = "auth.token_reused"
+	AuditEventLogout              = "auth.logout"
+	AuditEventLogoutAll           = "auth.logout_all"
+	AuditEventSessionRevoked      = "auth.session_revoked"
+	AuditEventOfferCreated        = "offer.created"
+	AuditEventOfferUpdated        = "offer.updated"
+	AuditEventOfferDeleted        = "offer.deleted"
+	AuditEventOfferPaused         = "offer.paused"
+	AuditEventOfferResumed        = "offer.resumed"
+	AuditEventPurchaseCancelled   = "purchase.cancelled"
+	AuditEventRfqQuoteAccepted    = "rfq.quote_accepted"
+	AuditEventNegotiationAccepted = "negotiation.accepted"
+
+	// Admin actions are recorded as "admin." followed by the AdminAction
+	AuditEventAdminPrefix = "admin."
+
+	AuditTargetUser        = "user"
+	AuditTargetOffer       = "offer"
+	AuditTargetPurchase    = "purchase"
+	AuditTargetSession     = "session"
+	AuditTargetRfq         = "rfq"
+	AuditTargetNegotiation = "negotiation"
+)
+
+// AuditEvent is a security relevant event. Rows are append-only, updates
+// and deletes are refused by a database trigger, except blanking the
+// personal data when the account is deleted. Before and After hold JSON
+// snapshots of the target, empty when they don't apply.
+type AuditEvent struct {
+	ID uint `gorm:"primarykey"`
+
+	Uuid  string `gorm:"type:uuid;uniqueIndex;not null"`
+	Event string `gorm:"type:varchar(50);not null;index"`
+
+	// Empty for events without an authenticated actor, like failed logins
+	ActorId *uint `gorm:"index"`
+	Actor   *User `gorm:"foreignKey:ActorId"`
+
+	Ip        string `gorm:"type:varchar(45);not null;default:''"`
+	UserAgent string `gorm:"type:text;not null;default:''"`
+
+	TargetType string `gorm:"type:varchar(50);not null;default:'';index:idx_audit_event_target"`
+	TargetUuid string `gorm:"type:varchar(64);not null;default:'';index:idx_audit_event_target"`
+
+	Before string `gorm:"type:text;not null;default:''"`
+	After  string `gorm:"type:text;not null;default:''"`
+
+	CreatedAt time.Time `gorm:"not null;index"`
+}
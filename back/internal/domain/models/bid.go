@@ -0,0 +1,62 @@
+package models
+
+import (
+	"ecoply/internal/domain/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	BidStatusOpen      string = "open"
+	BidStatusFilled    string = "filled"
+	BidStatusCancelled string = "cancelled"
+	BidStatusExpired   string = "expired"
+)
+
+// Bid is a buyer's limit order on the order book. It rests until offers of
+// the same submarket, energy type and period at or below its limit price
+// fill it, each fill being a Purchase.
+type Bid struct {
+	gorm.Model
+
+	Uuid string `gorm:"type:uuid;uniqueIndex;not null"`
+
+	LimitPricePerMwh     float64 `gorm:"type:decimal(10,2);not null"`
+	QuantityMwh          float64 `gorm:"type:decimal(10,3);not null"`
+	RemainingQuantityMwh float64 `gorm:"type:decimal(10,3);not null"`
+
+	PeriodStart time.Time `gorm:"type:date;not null"`
+	PeriodEnd   time.Time `gorm:"type:date;not null"`
+
+	Status        string `gorm:"type:varchar(20);not null;index"`
+	PaymentMethod string `gorm:"type:varchar(20);not null"`
+
+	EnergyTypeId uint       `gorm:"references:ID;not null"`
+	EnergyType   EnergyType `gorm:"foreignKey:EnergyTypeId"`
+
+	SubmarketId uint      `gorm:"references:ID;not null"`
+	Submarket   Submarket `gorm:"foreignKey:SubmarketId"`
+
+	BuyerId uint `gorm:"references:ID;not null;index"`
+	Buyer   User `gorm:"foreignKey:BuyerId"`
+
+	Purchases []Purchase `gorm:"foreignKey:BidId"`
+}
+
+func (b *Bid) IsOpen() bool {
+	return b.Status == BidStatusOpen
+}
+
+func (b *Bid) IsExpired() bool {
+	var now time.Time = utils.NowInLocalZeroHour()
+	var periodEnd time.Time = utils.TruncateDateToLocalZeroHour(b.PeriodEnd)
+
+	return now.After(periodEnd)
+}
+
+// IsOwner is true for any user of the buyer's company, which requires the
+// Buyer to be loaded.
+func (b *Bid) IsOwner(user *User) bool {
+	return b.BuyerId == user.ID || b.Buyer.IsSameAgent(user)
+}
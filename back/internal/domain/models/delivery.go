@@ -0,0 +1,32 @@
+package models
+
+import "gorm.io/gorm"
+
+const (
+	DeliveryProfileFlat    string = "flat"
+	DeliveryProfileMonthly string = "monthly"
+	DeliveryProfileHourly  string = "hourly"
+)
+
+// OfferDeliveryBlock is the volume an offer delivers in a slot of its
+// profile: a month as "2006-01" on monthly profiles or an hour of the day
+// from "00" to "23" on hourly ones.
+type OfferDeliveryBlock struct {
+	gorm.Model
+
+	Slot        string  `gorm:"type:varchar(7);not null"`
+	QuantityMwh float64 `gorm:"type:decimal(10,3);not null"`
+
+	OfferId uint `gorm:"references:ID;not null;index"`
+}
+
+// PurchaseDelivery is the share of a purchase delivered in a slot of the
+// offer's profile.
+type PurchaseDelivery struct {
+	gorm.Model
+
+	Slot        string  `gorm:"type:varchar(7);not null"`
+	QuantityMwh float64 `gorm:"type:decimal(10,3);not null"`
+
+	PurchaseId uint `gorm:"references:ID;not null;index"`
+}
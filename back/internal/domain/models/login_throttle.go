@@ -0,0 +1,32 @@
+package models
+
+import (
+	"ecoply/internal/domain/utils"
+	"time"
+)
+
+const (
+	LoginThrottleScopeAccount string = "account"
+	LoginThrottleScopeIp      string = "ip"
+)
+
+// LoginThrottle counts the recent failed logins of an email or of an IP
+// address. The email is counted whether it belongs to a user or not, so the
+// lockout itself doesn't reveal registered accounts.
+type LoginThrottle struct {
+	ID uint `gorm:"primarykey"`
+
+	Scope      string `gorm:"type:varchar(20);not null;uniqueIndex:idx_login_throttle_key"`
+	Identifier string `gorm:"type:text;not null;uniqueIndex:idx_login_throttle_key"`
+
+	Failures      int        `gorm:"not null;default:0"`
+	LastFailureAt *time.Time `gorm:""`
+	LockedUntil   *time.Time `gorm:""`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (t *LoginThrottle) IsLocked() bool {
+	return t.LockedUntil != nil && utils.NowInLocal().Before(*t.LockedUntil)
+}
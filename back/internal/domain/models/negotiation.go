@@ -0,0 +1,89 @@
+package models
+
+import (
+	"ecoply/internal/domain/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	NegotiationStatusOpen      string = "open"
+	NegotiationStatusAccepted  string = "accepted"
+	NegotiationStatusRejected  string = "rejected"
+	NegotiationStatusCancelled string = "cancelled"
+	NegotiationStatusExpired   string = "expired"
+
+	NegotiationProposalStatusPending   string = "pending"
+	NegotiationProposalStatusAccepted  string = "accepted"
+	NegotiationProposalStatusRejected  string = "rejected"
+	NegotiationProposalStatusCountered string = "countered"
+	NegotiationProposalStatusCancelled string = "cancelled"
+	NegotiationProposalStatusExpired   string = "expired"
+)
+
+// Negotiation is a thread between a buyer and the seller of an offer. Each
+// side answers the other's pending proposal by accepting, rejecting or
+// countering it until a purchase is made at the agreed terms.
+type Negotiation struct {
+	gorm.Model
+
+	Uuid string `gorm:"type:uuid;uniqueIndex;not null"`
+
+	Status        string `gorm:"type:varchar(20);not null;index"`
+	PaymentMethod string `gorm:"type:varchar(20);not null"`
+
+	OfferId uint  `gorm:"references:ID;not null;index"`
+	Offer   Offer `gorm:"foreignKey:OfferId"`
+
+	BuyerId uint `gorm:"references:ID;not null;index"`
+	Buyer   User `gorm:"foreignKey:BuyerId"`
+
+	// Set once a proposal is accepted
+	PurchaseId *uint     `gorm:""`
+	Purchase   *Purchase `gorm:"foreignKey:PurchaseId"`
+
+	Proposals []NegotiationProposal `gorm:"foreignKey:NegotiationId"`
+}
+
+func (n *Negotiation) IsOpen() bool {
+	return n.Status == NegotiationStatusOpen
+}
+
+// IsBuyer is true for any user of the buyer's company, which requires the
+// Buyer to be loaded.
+func (n *Negotiation) IsBuyer(user *User) bool {
+	return n.BuyerId == user.ID || n.Buyer.IsSameAgent(user)
+}
+
+type NegotiationProposal struct {
+	gorm.Model
+
+	Uuid string `gorm:"type:uuid;uniqueIndex;not null"`
+
+	PricePerMwh float64 `gorm:"type:decimal(10,2);not null"`
+	QuantityMwh float64 `gorm:"type:decimal(10,3);not null"`
+
+	PeriodStart time.Time `gorm:"type:date;not null"`
+	PeriodEnd   time.Time `gorm:"type:date;not null"`
+
+	Message string `gorm:"type:text;not null;default:''"`
+	Status  string `gorm:"type:varchar(20);not null;index"`
+
+	// Made by the seller's side, the buyer's otherwise
+	FromSeller bool      `gorm:"not null"`
+	ExpiresAt  time.Time `gorm:"not null"`
+
+	NegotiationId uint `gorm:"references:ID;not null;index"`
+
+	AuthorId uint `gorm:"references:ID;not null"`
+	Author   User `gorm:"foreignKey:AuthorId"`
+}
+
+func (p *NegotiationProposal) IsPending() bool {
+	return p.Status == NegotiationProposalStatusPending
+}
+
+func (p *NegotiationProposal) IsExpired() bool {
+	return !utils.NowInLocal().Before(p.ExpiresAt)
+}
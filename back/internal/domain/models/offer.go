@@ -2,16 +2,31 @@ package models
 
 import (
 	"ecoply/internal/domain/utils"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
 	"time"
 
 	"gorm.io/gorm"
 )
 
 const (
+	OfferStatusScheduled string = "scheduled"
 	OfferStatusFresh     string = "fresh"
 	OfferStatusOpen      string = "open"
+	OfferStatusPaused    string = "paused"
 	OfferStatusFulfilled string = "fulfilled"
 	OfferStatusExpired   string = "expired"
+	OfferStatusTakenDown string = "taken_down"
+
+	OfferVisibilityPublic     string = "public"
+	OfferVisibilityRestricted string = "restricted"
+
+	OfferAuctionNone    string = "none"
+	OfferAuctionEnglish string = "english"
+	OfferAuctionDutch   string = "dutch"
+	OfferAuctionSealed  string = "sealed"
 )
 
 type Offer struct {
@@ -23,13 +38,41 @@ type Offer struct {
 	InitialQuantityMwh   float64 `gorm:"type:decimal(10,3);not null"`
 	RemainingQuantityMwh float64 `gorm:"type:decimal(10,3);not null"`
 
+	// Purchases take at least MinQuantityMwh in multiples of QuantityStepMwh,
+	// unless they take all that remains
+	MinQuantityMwh  *float64 `gorm:"type:decimal(10,3)"`
+	QuantityStepMwh *float64 `gorm:"type:decimal(10,3)"`
+
 	Description string `gorm:"type:text;not null"`
 
 	PeriodStart time.Time `gorm:"type:date;not null"`
 	PeriodEnd   time.Time `gorm:"type:date;not null"`
 
+	// How the quantity is delivered over the period, flat offers have no
+	// blocks
+	DeliveryProfile string               `gorm:"type:varchar(20);not null;default:flat"`
+	DeliveryBlocks  []OfferDeliveryBlock `gorm:"foreignKey:OfferId"`
+
 	Status string `gorm:"type:varchar(20);not null"`
 
+	// Scheduled offers are published as fresh once PublishAt is reached
+	PublishAt *time.Time `gorm:"index"`
+
+	// Auctions sell the whole quantity to a single buyer. PricePerMwh is the
+	// opening price: the lowest accepted bid of english and sealed auctions,
+	// where dutch auctions start before dropping to the reserve price.
+	AuctionType        string     `gorm:"type:varchar(20);not null;default:none"`
+	AuctionStartsAt    *time.Time `gorm:""`
+	AuctionEndsAt      *time.Time `gorm:"index"`
+	ReservePricePerMwh *float64   `gorm:"type:decimal(10,2)"`
+	BidIncrementPerMwh *float64   `gorm:"type:decimal(10,2)"`
+
+	// Restricted offers are only seen by the allowed agents and by the
+	// buyers located in the allowed submarkets.
+	Visibility        string      `gorm:"type:varchar(20);not null;default:public"`
+	AllowedAgents     []Agent     `gorm:"many2many:offer_allowed_agents"`
+	AllowedSubmarkets []Submarket `gorm:"many2many:offer_allowed_submarkets"`
+
 	EnergyTypeId uint       `gorm:"references:ID;not null"`
 	EnergyType   EnergyType `gorm:"foreignKey:EnergyTypeId"`
 
@@ -39,7 +82,56 @@ type Offer struct {
 	SellerId uint `gorm:"references:ID;not null"`
 	Seller   User `gorm:"foreignKey:SellerId"`
 
-	Purchases []Purchase `gorm:"foreignKey:OfferId"`
+	// Volume discounts of direct purchases, by ascending MinQuantityMwh
+	PriceTiers []OfferPriceTier `gorm:"foreignKey:OfferId"`
+
+	Purchases   []Purchase   `gorm:"foreignKey:OfferId"`
+	AuctionBids []AuctionBid `gorm:"foreignKey:OfferId"`
+}
+
+var ErrInvalidOfferTransition = errors.New("offer status transition not allowed")
+
+// offerStatusTransitions lists the statuses each status can move to. Single
+// offers change status through TransitionTo and bulk updates take their
+// statuses from OfferStatusesTransitioningTo. Fulfilled offers open again
+// when a purchase is cancelled, and any offer can be taken down.
+var offerStatusTransitions = map[string][]string{
+	OfferStatusScheduled: {OfferStatusFresh, OfferStatusExpired, OfferStatusTakenDown},
+	OfferStatusFresh:     {OfferStatusOpen, OfferStatusPaused, OfferStatusFulfilled, OfferStatusExpired, OfferStatusTakenDown},
+	OfferStatusOpen:      {OfferStatusPaused, OfferStatusFulfilled, OfferStatusExpired, OfferStatusTakenDown},
+	OfferStatusPaused:    {OfferStatusFresh, OfferStatusOpen, OfferStatusExpired, OfferStatusTakenDown},
+	OfferStatusFulfilled: {OfferStatusOpen, OfferStatusExpired, OfferStatusTakenDown},
+	OfferStatusExpired:   {OfferStatusTakenDown},
+}
+
+func (o *Offer) CanTransitionTo(status string) bool {
+	return slices.Contains(offerStatusTransitions[o.Status], status)
+}
+
+// TransitionTo changes the status of the offer, it fails with
+// ErrInvalidOfferTransition and leaves the offer untouched when the
+// transition isn't allowed.
+func (o *Offer) TransitionTo(status string) error {
+	if !o.CanTransitionTo(status) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidOfferTransition, o.Status, status)
+	}
+
+	o.Status = status
+	return nil
+}
+
+// OfferStatusesTransitioningTo lists, sorted, the statuses that can move to
+// the status.
+func OfferStatusesTransitioningTo(status string) []string {
+	var statuses []string
+	for from, to := range offerStatusTransitions {
+		if slices.Contains(to, status) {
+			statuses = append(statuses, from)
+		}
+	}
+
+	slices.Sort(statuses)
+	return statuses
 }
 
 func (o *Offer) IsExpired() bool {
@@ -49,6 +141,10 @@ func (o *Offer) IsExpired() bool {
 	return now.After(periodEnd)
 }
 
+func (o *Offer) IsScheduled() bool {
+	return o.Status == OfferStatusScheduled
+}
+
 func (o *Offer) IsFresh() bool {
 	return o.Status == OfferStatusFresh
 }
@@ -57,10 +153,164 @@ func (o *Offer) IsOpen() bool {
 	return o.Status == OfferStatusOpen
 }
 
+func (o *Offer) IsPaused() bool {
+	return o.Status == OfferStatusPaused
+}
+
+// IsOnSale is true for published offers taking purchases, neither paused nor
+// past their period.
+func (o *Offer) IsOnSale() bool {
+	return (o.IsFresh() || o.IsOpen()) && !o.IsExpired()
+}
+
+// IsEditable is true while nothing can have been sold: scheduled and fresh
+// offers, before their auction starts.
+func (o *Offer) IsEditable() bool {
+	return (o.IsScheduled() || o.IsFresh()) && !o.HasAuctionStarted()
+}
+
 func (o *Offer) IsFulfilled() bool {
 	return o.Status == OfferStatusFulfilled
 }
 
+func (o *Offer) IsTakenDown() bool {
+	return o.Status == OfferStatusTakenDown
+}
+
+// IsOwner is true for any user of the seller's company, which requires the
+// Seller to be loaded.
 func (o *Offer) IsOwner(user *User) bool {
-	return o.SellerId == user.ID
+	return o.SellerId == user.ID || o.Seller.IsSameAgent(user)
+}
+
+// Sell takes the quantity out of the offer, which opens with its first sale
+// and is fulfilled once nothing is left. A remainder below the minimum lot
+// can't be sold either, the offer closes and keeps it.
+func (o *Offer) Sell(quantityMwh float64) error {
+	o.RemainingQuantityMwh = math.Round((o.RemainingQuantityMwh-quantityMwh)*1000) / 1000
+
+	if o.RemainingQuantityMwh <= 0 {
+		o.RemainingQuantityMwh = 0
+		return o.TransitionTo(OfferStatusFulfilled)
+	} else if o.MinQuantityMwh != nil && o.RemainingQuantityMwh < *o.MinQuantityMwh {
+		return o.TransitionTo(OfferStatusFulfilled)
+	} else if o.IsFresh() {
+		return o.TransitionTo(OfferStatusOpen)
+	}
+	return nil
+}
+
+// Restock gives back the quantity of a cancelled purchase, fulfilled offers
+// open again. Paused offers stay paused.
+func (o *Offer) Restock(quantityMwh float64) error {
+	o.RemainingQuantityMwh = math.Round((o.RemainingQuantityMwh+quantityMwh)*1000) / 1000
+
+	if o.IsFulfilled() {
+		return o.TransitionTo(OfferStatusOpen)
+	}
+	return nil
+}
+
+// Resume takes a paused offer back to sale, as open when part of it was
+// already sold.
+func (o *Offer) Resume() error {
+	if !o.IsPaused() {
+		return fmt.Errorf("%w: %s is not paused", ErrInvalidOfferTransition, o.Status)
+	}
+
+	if o.RemainingQuantityMwh < o.InitialQuantityMwh {
+		return o.TransitionTo(OfferStatusOpen)
+	}
+	return o.TransitionTo(OfferStatusFresh)
+}
+
+func (o *Offer) HasDeliveryProfile() bool {
+	return o.DeliveryProfile != "" && o.DeliveryProfile != DeliveryProfileFlat
+}
+
+func (o *Offer) HasPriceTiers() bool {
+	return len(o.PriceTiers) > 0
+}
+
+func (o *Offer) HasLotRules() bool {
+	return o.MinQuantityMwh != nil || o.QuantityStepMwh != nil
+}
+
+// IsValidLot is true for quantities of at least the minimum lot, in steps of
+// the increment, and for the whole remaining quantity.
+func (o *Offer) IsValidLot(quantityMwh float64) bool {
+	if quantityMwh == o.RemainingQuantityMwh {
+		return true
+	}
+
+	if o.MinQuantityMwh != nil && quantityMwh < *o.MinQuantityMwh {
+		return false
+	}
+
+	if o.QuantityStepMwh != nil {
+		var step int64 = int64(math.Round(*o.QuantityStepMwh * 1000))
+		if step > 0 && int64(math.Round(quantityMwh*1000))%step != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// PriceFor is the price per MWh of buying quantityMwh, the one of the largest
+// tier reached or PricePerMwh below the first. Requires PriceTiers loaded in
+// ascending order.
+func (o *Offer) PriceFor(quantityMwh float64) float64 {
+	var price float64 = o.PricePerMwh
+
+	for _, tier := range o.PriceTiers {
+		if quantityMwh < tier.MinQuantityMwh {
+			break
+		}
+		price = tier.PricePerMwh
+	}
+
+	return price
+}
+
+func (o *Offer) IsRestricted() bool {
+	return o.Visibility == OfferVisibilityRestricted
+}
+
+func (o *Offer) IsAuction() bool {
+	return o.AuctionType != "" && o.AuctionType != OfferAuctionNone
+}
+
+func (o *Offer) HasAuctionStarted() bool {
+	return o.IsAuction() && o.AuctionStartsAt != nil && !utils.NowInLocal().Before(*o.AuctionStartsAt)
+}
+
+// IsAuctionRunning is true between the start and the end of the auction.
+func (o *Offer) IsAuctionRunning() bool {
+	if !o.IsAuction() || o.AuctionStartsAt == nil || o.AuctionEndsAt == nil {
+		return false
+	}
+
+	var now time.Time = utils.NowInLocal()
+
+	return !now.Before(*o.AuctionStartsAt) && now.Before(*o.AuctionEndsAt)
+}
+
+// DutchPriceAt is the price of a dutch auction at t. It drops linearly from
+// PricePerMwh at the start to the reserve price at the end, in steps of the
+// bid increment when there is one.
+func (o *Offer) DutchPriceAt(t time.Time) float64 {
+	if o.AuctionStartsAt == nil || o.AuctionEndsAt == nil || o.ReservePricePerMwh == nil {
+		return o.PricePerMwh
+	}
+
+	var total time.Duration = o.AuctionEndsAt.Sub(*o.AuctionStartsAt)
+	var elapsed time.Duration = min(max(t.Sub(*o.AuctionStartsAt), 0), total)
+
+	var drop float64 = (o.PricePerMwh - *o.ReservePricePerMwh) * float64(elapsed) / float64(total)
+	if o.BidIncrementPerMwh != nil && *o.BidIncrementPerMwh > 0 {
+		drop = math.Floor(drop / *o.BidIncrementPerMwh) * *o.BidIncrementPerMwh
+	}
+
+	return math.Round((o.PricePerMwh-drop)*100) / 100
 }
@@ -0,0 +1,13 @@
+package models
+
+import "gorm.io/gorm"
+
+// OfferPriceTier discounts purchases of at least MinQuantityMwh from an offer.
+type OfferPriceTier struct {
+	gorm.Model
+
+	MinQuantityMwh float64 `gorm:"type:decimal(10,3);not null"`
+	PricePerMwh    float64 `gorm:"type:decimal(10,2);not null"`
+
+	OfferId uint `gorm:"references:ID;not null;index"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	OfferTemplateStatusActive string = "active"
+	OfferTemplateStatusPaused string = "paused"
+)
+
+// OfferTemplate keeps an offer a supplier publishes over and over. A template
+// with a publish day is a monthly series: on that day an offer for the whole
+// month PeriodOffsetMonths ahead is published from it.
+type OfferTemplate struct {
+	gorm.Model
+
+	Uuid string `gorm:"type:uuid;uniqueIndex;not null"`
+	Name string `gorm:"type:varchar(100);not null"`
+
+	PricePerMwh float64 `gorm:"type:decimal(10,2);not null"`
+	QuantityMwh float64 `gorm:"type:decimal(10,3);not null"`
+
+	Description string `gorm:"type:text;not null"`
+
+	PublishDay         *int `gorm:""`
+	PeriodOffsetMonths int  `gorm:"not null;default:1"`
+
+	Status        string     `gorm:"type:varchar(20);not null"`
+	NextPublishAt *time.Time `gorm:"index"`
+
+	EnergyTypeId uint       `gorm:"references:ID;not null"`
+	EnergyType   EnergyType `gorm:"foreignKey:EnergyTypeId"`
+
+	SellerId uint `gorm:"references:ID;not null;index"`
+	Seller   User `gorm:"foreignKey:SellerId"`
+}
+
+func (t *OfferTemplate) IsRecurring() bool {
+	return t.PublishDay != nil
+}
+
+func (t *OfferTemplate) IsPaused() bool {
+	return t.Status == OfferTemplateStatusPaused
+}
+
+// IsOwner is true for any user of the seller's company, which requires the
+// Seller to be loaded.
+func (t *OfferTemplate) IsOwner(user *User) bool {
+	return t.SellerId == user.ID || t.Seller.IsSameAgent(user)
+}
+
+// NextPublishAfter is the first publish day strictly after after.
+func (t *OfferTemplate) NextPublishAfter(after time.Time) time.Time {
+	var publishAt time.Time = time.Date(after.Year(), after.Month(), *t.PublishDay, 0, 0, 0, 0, after.Location())
+	if !publishAt.After(after) {
+		publishAt = publishAt.AddDate(0, 1, 0)
+	}
+
+	return publishAt
+}
+
+// PeriodFor is the month covered by the offer published at publishAt.
+func (t *OfferTemplate) PeriodFor(publishAt time.Time) (time.Time, time.Time) {
+	var start time.Time = time.Date(publishAt.Year(), publishAt.Month()+time.Month(t.PeriodOffsetMonths), 1, 0, 0, 0, 0, publishAt.Location())
+
+	return start, start.AddDate(0, 1, -1)
+}
@@ -0,0 +1,33 @@
+package models
+
+// Permissions gate routes by user type. They are carried in the access token
+// so the check needs no database lookup. What a user may do within the
+// company, given by its role, is still checked by the services.
+const (
+	PermissionOfferCreate  = "offer:create"
+	PermissionOfferUpdate  = "offer:update"
+	PermissionOfferDelete  = "offer:delete"
+	PermissionOfferReadOwn = "offer:read_own"
+	PermissionSaleRead     = "sale:read"
+	PermissionRfqQuote     = "rfq:quote"
+	PermissionAdminAccess  = "admin:access"
+)
+
+var userTypePermissions = map[string][]string{
+	UserTypeBuyer: {},
+	UserTypeSupplier: {
+		PermissionOfferCreate,
+		PermissionOfferUpdate,
+		PermissionOfferDelete,
+		PermissionOfferReadOwn,
+		PermissionSaleRead,
+		PermissionRfqQuote,
+	},
+	UserTypeAdmin: {
+		PermissionAdminAccess,
+	},
+}
+
+func PermissionsForUserType(userType string) []string {
+	return append([]string{}, userTypePermissions[userType]...)
+}
@@ -1,6 +1,10 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 const (
 	PurchaseStatusCompleted = "completed"
@@ -29,6 +33,17 @@ type Purchase struct {
 
 	OfferId uint  `gorm:"references:ID;not null"`
 	Offer   Offer `gorm:"foreignKey:OfferId"`
+
+	// Set when the purchase is a fill of a bid on the order book
+	BidId *uint `gorm:"index"`
+
+	// Part of the offer period agreed in a negotiation, the purchase supplies
+	// the whole period of the offer when empty
+	PeriodStart *time.Time `gorm:"type:date"`
+	PeriodEnd   *time.Time `gorm:"type:date"`
+
+	// Split of the quantity over the offer's delivery profile
+	Deliveries []PurchaseDelivery `gorm:"foreignKey:PurchaseId"`
 }
 
 func (p *Purchase) IsCompleted() bool {
@@ -55,6 +70,17 @@ func (p *Purchase) IsBillet() bool {
 	return p.PaymentMethod == PurchasePaymentBillet
 }
 
+// SupplyPeriod is the period the purchase is delivered in, the negotiated
+// one or else the period of its offer.
+func (p *Purchase) SupplyPeriod(offer *Offer) (time.Time, time.Time) {
+	if p.PeriodStart != nil && p.PeriodEnd != nil {
+		return *p.PeriodStart, *p.PeriodEnd
+	}
+	return offer.PeriodStart, offer.PeriodEnd
+}
+
+// IsOwner is true for any user of the buyer's company, which requires the
+// Buyer to be loaded.
 func (p *Purchase) IsOwner(user *User) bool {
-	return user.ID == p.BuyerId
+	return user.ID == p.BuyerId || p.Buyer.IsSameAgent(user)
 }
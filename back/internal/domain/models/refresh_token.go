@@ -0,0 +1,35 @@
+package models
+
+import (
+	"ecoply/internal/domain/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RefreshToken struct {
+	gorm.Model
+
+	Uuid      string `gorm:"type:uuid;uniqueIndex;not null"`
+	TokenHash string `gorm:"type:varchar(64);uniqueIndex;not null"`
+	FamilyId  string `gorm:"type:uuid;index;not null"`
+
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time `gorm:""`
+	RevokedAt *time.Time `gorm:""`
+
+	UserId uint `gorm:"references:ID;not null;index"`
+	User   User `gorm:"foreignKey:UserId"`
+}
+
+func (t *RefreshToken) IsExpired() bool {
+	return utils.NowInLocal().After(t.ExpiresAt)
+}
+
+func (t *RefreshToken) IsUsed() bool {
+	return t.UsedAt != nil
+}
+
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"ecoply/internal/domain/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	RfqStatusOpen      string = "open"
+	RfqStatusAwarded   string = "awarded"
+	RfqStatusCancelled string = "cancelled"
+	RfqStatusExpired   string = "expired"
+
+	RfqQuoteStatusPending   string = "pending"
+	RfqQuoteStatusAccepted  string = "accepted"
+	RfqQuoteStatusRejected  string = "rejected"
+	RfqQuoteStatusCancelled string = "cancelled"
+)
+
+// Rfq is a request for quote: a buyer asks suppliers for energy it could not
+// find among the offers.
+type Rfq struct {
+	gorm.Model
+
+	Uuid string `gorm:"type:uuid;uniqueIndex;not null"`
+
+	QuantityMwh    float64 `gorm:"type:decimal(10,3);not null"`
+	MaxPricePerMwh float64 `gorm:"type:decimal(10,2);not null"`
+
+	Description string `gorm:"type:text;not null"`
+
+	PeriodStart time.Time `gorm:"type:date;not null"`
+	PeriodEnd   time.Time `gorm:"type:date;not null"`
+
+	Status string `gorm:"type:varchar(20);not null;index"`
+
+	EnergyTypeId uint       `gorm:"references:ID;not null"`
+	EnergyType   EnergyType `gorm:"foreignKey:EnergyTypeId"`
+
+	SubmarketId uint      `gorm:"references:ID;not null"`
+	Submarket   Submarket `gorm:"foreignKey:SubmarketId"`
+
+	BuyerId uint `gorm:"references:ID;not null;index"`
+	Buyer   User `gorm:"foreignKey:BuyerId"`
+
+	// Set once a quote is accepted
+	PurchaseId *uint     `gorm:""`
+	Purchase   *Purchase `gorm:"foreignKey:PurchaseId"`
+
+	Quotes []RfqQuote `gorm:"foreignKey:RfqId"`
+}
+
+func (r *Rfq) IsOpen() bool {
+	return r.Status == RfqStatusOpen
+}
+
+func (r *Rfq) IsExpired() bool {
+	var now time.Time = utils.NowInLocalZeroHour()
+	var periodEnd time.Time = utils.TruncateDateToLocalZeroHour(r.PeriodEnd)
+
+	return now.After(periodEnd)
+}
+
+// IsOwner is true for any user of the buyer's company, which requires the
+// Buyer to be loaded.
+func (r *Rfq) IsOwner(user *User) bool {
+	return r.BuyerId == user.ID || r.Buyer.IsSameAgent(user)
+}
+
+// RfqQuote is a supplier's answer to an Rfq. Accepting it creates an Offer
+// already fulfilled by the Purchase of the buyer, so sales and contracts
+// work as for any other offer.
+type RfqQuote struct {
+	gorm.Model
+
+	Uuid string `gorm:"type:uuid;uniqueIndex;not null"`
+
+	PricePerMwh float64 `gorm:"type:decimal(10,2);not null"`
+	Message     string  `gorm:"type:text;not null;default:''"`
+
+	Status string `gorm:"type:varchar(20);not null"`
+
+	RfqId uint `gorm:"references:ID;not null;index"`
+	Rfq   Rfq  `gorm:"foreignKey:RfqId"`
+
+	SupplierId uint `gorm:"references:ID;not null;index"`
+	Supplier   User `gorm:"foreignKey:SupplierId"`
+
+	OfferId *uint  `gorm:"index"`
+	Offer   *Offer `gorm:"foreignKey:OfferId"`
+}
+
+func (q *RfqQuote) IsPending() bool {
+	return q.Status == RfqQuoteStatusPending
+}
+
+// IsOwner is true for any user of the supplier's company, which requires the
+// Supplier to be loaded.
+func (q *RfqQuote) IsOwner(user *User) bool {
+	return q.SupplierId == user.ID || q.Supplier.IsSameAgent(user)
+}
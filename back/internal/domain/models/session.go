@@ -0,0 +1,30 @@
+package models
+
+import (
+	"ecoply/internal/domain/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session is a device the user is logged in from. Its uuid is the family of
+// the refresh tokens issued to that device and the sid claim of its access
+// tokens, so revoking it cuts both off.
+type Session struct {
+	gorm.Model
+
+	Uuid      string `gorm:"type:uuid;uniqueIndex;not null"`
+	Ip        string `gorm:"type:varchar(45);not null;default:''"`
+	UserAgent string `gorm:"type:text;not null;default:''"`
+
+	LastSeenAt time.Time  `gorm:"not null"`
+	ExpiresAt  time.Time  `gorm:"not null"`
+	RevokedAt  *time.Time `gorm:""`
+
+	UserId uint `gorm:"references:ID;not null;index"`
+	User   User `gorm:"foreignKey:UserId"`
+}
+
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil && utils.NowInLocal().Before(s.ExpiresAt)
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TwoFactor holds the TOTP enrollment of a user. It only protects the account
+// once EnabledAt is set, that is, after the user proved the authenticator app
+// produces valid codes.
+type TwoFactor struct {
+	gorm.Model
+
+	Secret       string     `gorm:"type:varchar(64);not null"`
+	EnabledAt    *time.Time `gorm:""`
+	LastUsedStep int64      `gorm:"not null;default:0"`
+
+	UserId uint `gorm:"references:ID;not null;uniqueIndex"`
+	User   User `gorm:"foreignKey:UserId"`
+
+	RecoveryCodes []TwoFactorRecoveryCode `gorm:"foreignKey:TwoFactorId;constraint:OnDelete:CASCADE"`
+}
+
+type TwoFactorRecoveryCode struct {
+	ID       uint       `gorm:"primarykey"`
+	CodeHash string     `gorm:"type:varchar(64);not null"`
+	UsedAt   *time.Time `gorm:""`
+
+	TwoFactorId uint `gorm:"references:ID;not null;index"`
+}
+
+func (t *TwoFactor) IsEnabled() bool {
+	return t.EnabledAt != nil
+}
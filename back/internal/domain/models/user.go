@@ -1,9 +1,22 @@
 package models
 
 import (
+	"slices"
+	"time"
+
 	"gorm.io/gorm"
 )
 
+// Roles are per company, every user of an Agent has exactly one.
+const (
+	UserRoleOwner   = "owner"
+	UserRoleTrader  = "trader"
+	UserRoleViewer  = "viewer"
+	UserRoleFinance = "finance"
+)
+
+const AnonymizedUserName = "Deleted user"
+
 type User struct {
 	gorm.Model
 
@@ -12,9 +25,52 @@ type User struct {
 	Email    string `gorm:"type:text;not null;unique"`
 	Password string `gorm:"type:varchar(255);not null"`
 
+	EmailVerifiedAt *time.Time `gorm:""`
+
+	Role string `gorm:"type:varchar(20);not null;default:owner"`
+
+	SuspendedAt      *time.Time `gorm:""`
+	SuspensionReason string     `gorm:"type:text;not null;default:''"`
+
+	// Set when the user asked for the account to be deleted. The row is kept
+	// with personal fields scrubbed, purchases and contracts still refer to it.
+	AnonymizedAt *time.Time `gorm:""`
+
 	UserTypeId uint     `gorm:"references:ID;not null"`
 	UserType   UserType `gorm:"foreignKey:UserTypeId"`
 
 	AgentId uint  `gorm:"references:ID;not null"`
 	Agent   Agent `gorm:"foreignKey:AgentId"`
 }
+
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+func (u *User) IsSuspended() bool {
+	return u.SuspendedAt != nil
+}
+
+func (u *User) IsAnonymized() bool {
+	return u.AnonymizedAt != nil
+}
+
+func (u *User) IsSameAgent(other *User) bool {
+	return u.AgentId != 0 && u.AgentId == other.AgentId
+}
+
+func (u *User) IsAgentOwner() bool {
+	return u.Role == UserRoleOwner
+}
+
+// CanTrade tells whether the user may create, change or buy offers on behalf
+// of the company.
+func (u *User) CanTrade() bool {
+	return slices.Contains([]string{UserRoleOwner, UserRoleTrader}, u.Role)
+}
+
+// CanViewFinancials tells whether the user may see purchases, sales and
+// contracts of the company.
+func (u *User) CanViewFinancials() bool {
+	return slices.Contains([]string{UserRoleOwner, UserRoleTrader, UserRoleFinance}, u.Role)
+}
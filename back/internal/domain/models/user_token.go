@@ -0,0 +1,34 @@
+package models
+
+import (
+	"ecoply/internal/domain/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	UserTokenPurposePasswordReset     string = "password_reset"
+	UserTokenPurposeEmailVerification string = "email_verification"
+	UserTokenPurposeTwoFactorLogin    string = "two_factor_login"
+)
+
+// UserToken is a single use secret handed to the user, by email or as a
+// login challenge. Only its hash is stored.
+type UserToken struct {
+	gorm.Model
+
+	TokenHash string `gorm:"type:varchar(64);uniqueIndex;not null"`
+	Purpose   string `gorm:"type:varchar(30);not null;index"`
+	Email     string `gorm:"type:text;not null"`
+
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time `gorm:""`
+
+	UserId uint `gorm:"references:ID;not null;index"`
+	User   User `gorm:"foreignKey:UserId"`
+}
+
+func (t *UserToken) IsUsable() bool {
+	return t.UsedAt == nil && utils.NowInLocal().Before(t.ExpiresAt)
+}
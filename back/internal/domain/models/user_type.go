@@ -3,6 +3,7 @@ package models
 const (
 	UserTypeBuyer    = "buyer"
 	UserTypeSupplier = "supplier"
+	UserTypeAdmin    = "admin"
 )
 
 type UserType struct {
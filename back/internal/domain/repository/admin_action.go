@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/scopes"
+	"ecoply/internal/domain/utils"
+	"ecoply/internal/mlog"
+
+	"gorm.io/gorm"
+)
+
+type AdminActionRepository interface {
+	WithTransaction(tx *gorm.DB) AdminActionRepository
+
+	Create(action *models.AdminAction) error
+	List(request *requests.AdminListActions) (*utils.PaginationWrapper[*models.AdminAction], error)
+}
+
+type adminActionRepository struct {
+	db *gorm.DB
+}
+
+func NewAdminActionRepository(db *gorm.DB) AdminActionRepository {
+	return &adminActionRepository{db: db}
+}
+
+func (r *adminActionRepository) WithTransaction(tx *gorm.DB) AdminActionRepository {
+	return NewAdminActionRepository(tx)
+}
+
+func (r *adminActionRepository) Create(action *models.AdminAction) error {
+	if err := r.db.Create(action).Error; err != nil {
+		mlog.Log("Failed to create admin action: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *adminActionRepository) List(request *requests.AdminListActions) (*utils.PaginationWrapper[*models.AdminAction], error) {
+	var actions []*models.AdminAction
+
+	result := r.db.
+		Preload("Admin").
+		InnerJoins("Admin")
+
+	if request.Action != "" {
+		result = result.Where("admin_actions.action = ?", request.Action)
+	}
+
+	if request.AdminUuid != "" {
+		result = result.Where("\"Admin\".uuid = ?", request.AdminUuid)
+	}
+
+	if request.TargetUuid != "" {
+		result = result.Where("admin_actions.target_uuid = ?", request.TargetUuid)
+	}
+
+	result = result.Order("admin_actions.created_at DESC").
+		Scopes(scopes.Paginate(r.db, request.Page, request.PageSize))
+
+	if err := result.Find(&actions).Error; err != nil {
+		mlog.Log("Failed to list admin actions: " + err.Error())
+		return nil, err
+	}
+
+	return utils.NewPaginationWrapper(request.Page, request.PageSize, actions), nil
+}
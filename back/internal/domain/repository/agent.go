@@ -2,9 +2,13 @@ package repository
 
 import (
 	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/scopes"
+	"ecoply/internal/domain/utils"
 	"ecoply/internal/mlog"
+	"errors"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AgentRepository interface {
@@ -12,8 +16,14 @@ type AgentRepository interface {
 
 	Create(agent *models.Agent) (*models.Agent, error)
 	FindById(id uint) (*models.Agent, error)
+	FindByIdForUpdate(id uint) (*models.Agent, error)
 	FindByCnpj(cnpj string) (*models.Agent, error)
 	FindByCceeCode(cceeCode string) (*models.Agent, error)
+	FindByCnpjOrCceeCode(value string) (*models.Agent, error)
+	UpdateCompanyName(agent *models.Agent, companyName string) error
+	UpdateAddress(agent *models.Agent, address *models.Address) error
+	Search(search string, page int, pageSize int) (*utils.PaginationWrapper[*models.Agent], error)
+	CountMembers(agentIds []uint) (map[uint]int64, error)
 }
 
 type agentRepository struct {
@@ -49,6 +59,18 @@ func (a *agentRepository) FindById(id uint) (*models.Agent, error) {
 	return &agent, nil
 }
 
+func (a *agentRepository) FindByIdForUpdate(id uint) (*models.Agent, error) {
+	var agent models.Agent
+	err := a.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&agent, id).Error
+
+	if err != nil {
+		mlog.Log("Failed to find agent by ID: " + err.Error())
+		return nil, err
+	}
+
+	return &agent, nil
+}
+
 func (a *agentRepository) FindByCnpj(cnpj string) (*models.Agent, error) {
 	var agent models.Agent
 	err := a.db.Where("cnpj = ?", cnpj).First(&agent).Error
@@ -72,3 +94,82 @@ func (a *agentRepository) FindByCceeCode(cceeCode string) (*models.Agent, error)
 
 	return &agent, nil
 }
+
+func (a *agentRepository) FindByCnpjOrCceeCode(value string) (*models.Agent, error) {
+	var agent models.Agent
+	err := a.db.Where("cnpj = ? OR ccee_code = ?", value, value).First(&agent).Error
+
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			mlog.Log("Failed to find agent by CNPJ or CCEE Code: " + err.Error())
+		}
+		return nil, err
+	}
+
+	return &agent, nil
+}
+
+func (a *agentRepository) UpdateCompanyName(agent *models.Agent, companyName string) error {
+	if err := a.db.Model(agent).Update("company_name", companyName).Error; err != nil {
+		mlog.Log("Failed to update agent company name: " + err.Error())
+		return err
+	}
+
+	agent.CompanyName = companyName
+	return nil
+}
+
+func (a *agentRepository) UpdateAddress(agent *models.Agent, address *models.Address) error {
+	if err := a.db.Model(agent).Update("address_id", address.ID).Error; err != nil {
+		mlog.Log("Failed to update agent address: " + err.Error())
+		return err
+	}
+
+	agent.AddressId = address.ID
+	agent.Address = *address
+	return nil
+}
+
+func (a *agentRepository) Search(search string, page int, pageSize int) (*utils.PaginationWrapper[*models.Agent], error) {
+	var agents []*models.Agent
+
+	result := a.db.Preload("Submarket")
+
+	if search != "" {
+		var like string = "%" + search + "%"
+		result = result.Where("cnpj LIKE ? OR company_name ILIKE ? OR ccee_code ILIKE ?", like, like, like)
+	}
+
+	result = result.Order("created_at DESC").Scopes(scopes.Paginate(a.db, page, pageSize))
+
+	if err := result.Find(&agents).Error; err != nil {
+		mlog.Log("Failed to search agents: " + err.Error())
+		return nil, err
+	}
+
+	return utils.NewPaginationWrapper(page, pageSize, agents), nil
+}
+
+func (a *agentRepository) CountMembers(agentIds []uint) (map[uint]int64, error) {
+	var rows []struct {
+		AgentId uint
+		Count   int64
+	}
+
+	err := a.db.Model(&models.User{}).
+		Select("agent_id, COUNT(*) AS count").
+		Where("agent_id IN ?", agentIds).
+		Group("agent_id").
+		Scan(&rows).Error
+	if err != nil {
+		mlog.Log("Failed to count agent members: " + err.Error())
+		return nil, err
+	}
+
+	var counts map[uint]int64 = make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.AgentId] = row.Count
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/utils"
+	"ecoply/internal/mlog"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AgentInvitationRepository interface {
+	WithTransaction(tx *gorm.DB) AgentInvitationRepository
+
+	Create(invitation *models.AgentInvitation) error
+	FindByUuid(agentId uint, uuid string) (*models.AgentInvitation, error)
+	FindByHashForUpdate(tokenHash string) (*models.AgentInvitation, error)
+	ListPendingFromAgent(agentId uint) ([]*models.AgentInvitation, error)
+	MarkAccepted(invitation *models.AgentInvitation) error
+	Revoke(invitation *models.AgentInvitation) error
+	RevokePendingFromEmail(agentId uint, email string) error
+}
+
+type agentInvitationRepository struct {
+	db *gorm.DB
+}
+
+func NewAgentInvitationRepository(db *gorm.DB) AgentInvitationRepository {
+	return &agentInvitationRepository{db: db}
+}
+
+func (r *agentInvitationRepository) WithTransaction(tx *gorm.DB) AgentInvitationRepository {
+	return NewAgentInvitationRepository(tx)
+}
+
+func (r *agentInvitationRepository) Create(invitation *models.AgentInvitation) error {
+	if err := r.db.Create(invitation).Error; err != nil {
+		mlog.Log("Failed to create agent invitation: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *agentInvitationRepository) FindByUuid(agentId uint, uuid string) (*models.AgentInvitation, error) {
+	var invitation models.AgentInvitation
+	err := r.db.Where("agent_id = ? AND uuid = ?", agentId, uuid).First(&invitation).Error
+
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			mlog.Log("Failed to find agent invitation by uuid: " + err.Error())
+		}
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+func (r *agentInvitationRepository) FindByHashForUpdate(tokenHash string) (*models.AgentInvitation, error) {
+	var invitation models.AgentInvitation
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", tokenHash).
+		First(&invitation).Error
+
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			mlog.Log("Failed to find agent invitation by hash: " + err.Error())
+		}
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+func (r *agentInvitationRepository) ListPendingFromAgent(agentId uint) ([]*models.AgentInvitation, error) {
+	var invitations []*models.AgentInvitation
+	err := r.db.Preload("InvitedBy").
+		Where("agent_id = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", agentId, utils.NowInLocal()).
+		Order("created_at DESC").
+		Find(&invitations).Error
+
+	if err != nil {
+		mlog.Log("Failed to list pending agent invitations: " + err.Error())
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+func (r *agentInvitationRepository) MarkAccepted(invitation *models.AgentInvitation) error {
+	var now = utils.NowInLocal()
+
+	if err := r.db.Model(invitation).Update("accepted_at", now).Error; err != nil {
+		mlog.Log("Failed to mark agent invitation as accepted: " + err.Error())
+		return err
+	}
+
+	invitation.AcceptedAt = &now
+	return nil
+}
+
+func (r *agentInvitationRepository) Revoke(invitation *models.AgentInvitation) error {
+	var now = utils.NowInLocal()
+
+	if err := r.db.Model(invitation).Update("revoked_at", now).Error; err != nil {
+		mlog.Log("Failed to revoke agent invitation: " + err.Error())
+		return err
+	}
+
+	invitation.RevokedAt = &now
+	return nil
+}
+
+// RevokePendingFromEmail keeps a single valid invitation per email, so
+// inviting again invalidates the link sent before.
+func (r *agentInvitationRepository) RevokePendingFromEmail(agentId uint, email string) error {
+	err := r.db.Model(&models.AgentInvitation{}).
+		Where("agent_id = ? AND email = ? AND accepted_at IS NULL AND revoked_at IS NULL", agentId, email).
+		Update("revoked_at", utils.NowInLocal()).Error
+
+	if err != nil {
+		mlog.Log("Failed to revoke pending agent invitations: " + err.Error())
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/utils"
+	"ecoply/internal/mlog"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ApiKeyRepository interface {
+	WithTransaction(tx *gorm.DB) ApiKeyRepository
+
+	Create(apiKey *models.ApiKey) error
+	FindByHash(keyHash string) (*models.ApiKey, error)
+	FindByUuid(userId uint, uuid string) (*models.ApiKey, error)
+	ListActiveFromUser(userId uint) ([]*models.ApiKey, error)
+	TouchLastUsed(apiKey *models.ApiKey, at time.Time) error
+	Revoke(apiKey *models.ApiKey) error
+	RevokeAllFromUser(userId uint) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewApiKeyRepository(db *gorm.DB) ApiKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) WithTransaction(tx *gorm.DB) ApiKeyRepository {
+	return NewApiKeyRepository(tx)
+}
+
+func (r *apiKeyRepository) Create(apiKey *models.ApiKey) error {
+	if err := r.db.Create(apiKey).Error; err != nil {
+		mlog.Log("Failed to create api key: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) FindByHash(keyHash string) (*models.ApiKey, error) {
+	var apiKey models.ApiKey
+	err := r.db.Where("key_hash = ?", keyHash).First(&apiKey).Error
+
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			mlog.Log("Failed to find api key by hash: " + err.Error())
+		}
+		return nil, err
+	}
+
+	return &apiKey, nil
+}
+
+func (r *apiKeyRepository) FindByUuid(userId uint, uuid string) (*models.ApiKey, error) {
+	var apiKey models.ApiKey
+	err := r.db.Where("user_id = ? AND uuid = ?", userId, uuid).First(&apiKey).Error
+
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			mlog.Log("Failed to find api key by uuid: " + err.Error())
+		}
+		return nil, err
+	}
+
+	return &apiKey, nil
+}
+
+func (r *apiKeyRepository) ListActiveFromUser(userId uint) ([]*models.ApiKey, error) {
+	var apiKeys []*models.ApiKey
+	err := r.db.
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userId, utils.NowInLocal()).
+		Order("created_at DESC").
+		Find(&apiKeys).Error
+
+	if err != nil {
+		mlog.Log("Failed to list api keys: " + err.Error())
+		return nil, err
+	}
+
+	return apiKeys, nil
+}
+
+func (r *apiKeyRepository) TouchLastUsed(apiKey *models.ApiKey, at time.Time) error {
+	if err := r.db.Model(apiKey).Update("last_used_at", at).Error; err != nil {
+		mlog.Log("Failed to update api key last use: " + err.Error())
+		return err
+	}
+
+	apiKey.LastUsedAt = &at
+	return nil
+}
+
+func (r *apiKeyRepository) Revoke(apiKey *models.ApiKey) error {
+	var now = utils.NowInLocal()
+
+	if err := r.db.Model(apiKey).Update("revoked_at", now).Error; err != nil {
+		mlog.Log("Failed to revoke api key: " + err.Error())
+		return err
+	}
+
+	apiKey.RevokedAt = &now
+	return nil
+}
+
+func (r *apiKeyRepository) RevokeAllFromUser(userId uint) error {
+	err := r.db.Model(&models.ApiKey{}).
+		Where("user_id = ? AND revoked_at IS NULL", userId).
+		Update("revoked_at", utils.NowInLocal()).Error
+
+	if err != nil {
+		mlog.Log("Failed to revoke api keys from user: " + err.Error())
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"ecoply/internal/domain/models"
+	"ecoply/internal/mlog"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AuctionBidRepository interface {
+	WithTransaction(tx *gorm.DB) AuctionBidRepository
+
+	Create(bid *models.AuctionBid) error
+	FindHighestActive(offerId uint) (*models.AuctionBid, error)
+	HasActiveFromAgent(offerId uint, agentId uint) (bool, error)
+	ListFromOffer(offerId uint) ([]*models.AuctionBid, error)
+	ListFromOfferAndAgent(offerId uint, agentId uint) ([]*models.AuctionBid, error)
+	ListActiveForUpdate(offerId uint) ([]*models.AuctionBid, error)
+	Update(bid *models.AuctionBid) error
+	OutbidActive(offerId uint, exceptId uint) error
+	LoseOpenFromOffer(offerId uint) error
+}
+
+type auctionBidRepository struct {
+	db *gorm.DB
+}
+
+func NewAuctionBidRepository(db *gorm.DB) AuctionBidRepository {
+	return &auctionBidRepository{db: db}
+}
+
+func (r *auctionBidRepository) WithTransaction(tx *gorm.DB) AuctionBidRepository {
+	return NewAuctionBidRepository(tx)
+}
+
+func (r *auctionBidRepository) Create(bid *models.AuctionBid) error {
+	if err := r.db.Create(bid).Error; err != nil {
+		mlog.Log("Failed to create auction bid: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+// FindHighestActive returns nil when the auction has no active bid.
+func (r *auctionBidRepository) FindHighestActive(offerId uint) (*models.AuctionBid, error) {
+	var bid models.AuctionBid
+	err := r.db.
+		Where("offer_id = ? AND status = ?", offerId, models.AuctionBidStatusActive).
+		Order("price_per_mwh DESC, id ASC").
+		First(&bid).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		mlog.Log("Failed to find highest auction bid: " + err.Error())
+		return nil, err
+	}
+
+	return &bid, nil
+}
+
+func (r *auctionBidRepository) HasActiveFromAgent(offerId uint, agentId uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.AuctionBid{}).
+		Joins("JOIN users bidders ON bidders.id = auction_bids.bidder_id").
+		Where("auction_bids.offer_id = ? AND auction_bids.status = ? AND bidders.agent_id = ?",
+			offerId, models.AuctionBidStatusActive, agentId).
+		Count(&count).Error
+
+	if err != nil {
+		mlog.Log("Failed to count auction bids from agent: " + err.Error())
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *auctionBidRepository) ListFromOffer(offerId uint) ([]*models.AuctionBid, error) {
+	var bids []*models.AuctionBid
+	err := r.db.
+		Preload("Bidder").
+		Where("offer_id = ?", offerId).
+		Order("price_per_mwh DESC, id ASC").
+		Find(&bids).Error
+
+	if err != nil {
+		mlog.Log("Failed to list auction bids: " + err.Error())
+		return nil, err
+	}
+
+	return bids, nil
+}
+
+func (r *auctionBidRepository) ListFromOfferAndAgent(offerId uint, agentId uint) ([]*models.AuctionBid, error) {
+	var bids []*models.AuctionBid
+	err := r.db.
+		Preload("Bidder").
+		Joins("JOIN users bidders ON bidders.id = auction_bids.bidder_id").
+		Where("auction_bids.offer_id = ? AND bidders.agent_id = ?", offerId, agentId).
+		Order("auction_bids.price_per_mwh DESC, auction_bids.id ASC").
+		Find(&bids).Error
+
+	if err != nil {
+		mlog.Log("Failed to list auction bids from agent: " + err.Error())
+		return nil, err
+	}
+
+	return bids, nil
+}
+
+// ListActiveForUpdate locks the active bids of the auction, best first.
+func (r *auctionBidRepository) ListActiveForUpdate(offerId uint) ([]*models.AuctionBid, error) {
+	var bids []*models.AuctionBid
+	err := r.db.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Bidder").
+		Where("offer_id = ? AND status = ?", offerId, models.AuctionBidStatusActive).
+		Order("price_per_mwh DESC, id ASC").
+		Find(&bids).Error
+
+	if err != nil {
+		mlog.Log("Failed to list active auction bids: " + err.Error())
+		return nil, err
+	}
+
+	return bids, nil
+}
+
+func (r *auctionBidRepository) Update(bid *models.AuctionBid) error {
+	err := r.db.Model(bid).Select("status", "purchase_id").Updates(bid).Error
+	if err != nil {
+		mlog.Log("Failed to update auction bid: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *auctionBidRepository) OutbidActive(offerId uint, exceptId uint) error {
+	err := r.db.Model(&models.AuctionBid{}).
+		Where("offer_id = ? AND status = ? AND id <> ?", offerId, models.AuctionBidStatusActive, exceptId).
+		Update("status", models.AuctionBidStatusOutbid).Error
+	if err != nil {
+		mlog.Log("Failed to outbid auction bids: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+// LoseOpenFromOffer closes every bid of the auction that did not win.
+func (r *auctionBidRepository) LoseOpenFromOffer(offerId uint) error {
+	err := r.db.Model(&models.AuctionBid{}).
+		Where("offer_id = ? AND status IN (?)", offerId, []string{
+			models.AuctionBidStatusActive,
+			models.AuctionBidStatusOutbid,
+		}).
+		Update("status", models.AuctionBidStatusLost).Error
+	if err != nil {
+		mlog.Log("Failed to close auction bids: " + err.Error())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/scopes"
+	"ecoply/internal/domain/utils"
+	"ecoply/internal/mlog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AuditEventRepository interface {
+	WithTransaction(tx *gorm.DB) AuditEventRepository
+
+	Create(event *models.AuditEvent) error
+	ListFromUser(user *models.User, page int, pageSize int) (*utils.PaginationWrapper[*models.AuditEvent], error)
+	List(request *requests.AdminListAuditEvents) (*utils.PaginationWrapper[*models.AuditEvent], error)
+	AnonymizeFromUser(user *models.User) error
+}
+
+type auditEventRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditEventRepository(db *gorm.DB) AuditEventRepository {
+	return &auditEventRepository{db: db}
+}
+
+func (r *auditEventRepository) WithTransaction(tx *gorm.DB) AuditEventRepository {
+	return NewAuditEventRepository(tx)
+}
+
+func (r *auditEventRepository) Create(event *models.AuditEvent) error {
+	if err := r.db.Create(event).Error; err != nil {
+		mlog.Log("Failed to create audit event: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+// ListFromUser returns what the user did and what was done to the account,
+// like failed logins or an administrator suspending it.
+func (r *auditEventRepository) ListFromUser(user *models.User, page int, pageSize int) (*utils.PaginationWrapper[*models.AuditEvent], error) {
+	var events []*models.AuditEvent
+
+	err := r.db.
+		Preload("Actor").
+		Where("actor_id = ? OR (target_type = ? AND target_uuid = ?)", user.ID, models.AuditTargetUser, user.Uuid).
+		Order("created_at DESC").
+		Scopes(scopes.Paginate(r.db, page, pageSize)).
+		Find(&events).Error
+	if err != nil {
+		mlog.Log("Failed to list audit events from user: " + err.Error())
+		return nil, err
+	}
+
+	return utils.NewPaginationWrapper(page, pageSize, events), nil
+}
+
+func (r *auditEventRepository) List(request *requests.AdminListAuditEvents) (*utils.PaginationWrapper[*models.AuditEvent], error) {
+	var events []*models.AuditEvent
+
+	result := r.db.Preload("Actor")
+
+	if request.Event != "" {
+		result = result.Where("audit_events.event = ?", request.Event)
+	}
+
+	if request.ActorUuid != "" {
+		result = result.Where("audit_events.actor_id = (SELECT id FROM users WHERE uuid = ?)", request.ActorUuid)
+	}
+
+	if request.TargetType != "" {
+		result = result.Where("audit_events.target_type = ?", request.TargetType)
+	}
+
+	if request.TargetUuid != "" {
+		result = result.Where("audit_events.target_uuid = ?", request.TargetUuid)
+	}
+
+	if request.Ip != "" {
+		result = result.Where("audit_events.ip = ?", request.Ip)
+	}
+
+	if request.From != "" {
+		from, _ := time.ParseInLocation(time.DateOnly, request.From, time.Local)
+		result = result.Where("audit_events.created_at >= ?", from)
+	}
+
+	if request.To != "" {
+		to, _ := time.ParseInLocation(time.DateOnly, request.To, time.Local)
+		result = result.Where("audit_events.created_at < ?", to.AddDate(0, 0, 1))
+	}
+
+	result = result.Order("audit_events.created_at DESC").
+		Scopes(scopes.Paginate(r.db, request.Page, request.PageSize))
+
+	if err := result.Find(&events).Error; err != nil {
+		mlog.Log("Failed to list audit events: " + err.Error())
+		return nil, err
+	}
+
+	return utils.NewPaginationWrapper(request.Page, request.PageSize, events), nil
+}
+
+// AnonymizeFromUser blanks the personal data of the events of the user, the
+// only update the append-only trigger lets through. The snapshot of failed
+// logins is dropped as well, it holds the email they were attempted with.
+func (r *auditEventRepository) AnonymizeFromUser(user *models.User) error {
+	err := r.db.Model(&models.AuditEvent{}).
+		Where("actor_id = ? OR (target_type = ? AND target_uuid = ?)", user.ID, models.AuditTargetUser, user.Uuid).
+		Updates(map[string]any{
+			"ip":         "",
+			"user_agent": "",
+			"after":      gorm.Expr("CASE WHEN event = ? THEN '' ELSE after END", models.AuditEventLoginFailed),
+		}).Error
+	if err != nil {
+		mlog.Log("Failed to anonymize audit events from user: " + err.Error())
+		return err
+	}
+	return nil
+}
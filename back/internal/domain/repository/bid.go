@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/scopes"
+	"ecoply/internal/domain/utils"
+	"ecoply/internal/mlog"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OrderBookKey identifies a book: orders only match others of the same
+// submarket, energy type and delivery period.
+type OrderBookKey struct {
+	SubmarketId  uint
+	EnergyTypeId uint
+	PeriodStart  time.Time
+	PeriodEnd    time.Time
+}
+
+// PriceLevel is the quantity resting on the book at a price.
+type PriceLevel struct {
+	PricePerMwh float64
+	QuantityMwh float64
+	Orders      int64
+}
+
+type BidRepository interface {
+	WithTransaction(tx *gorm.DB) BidRepository
+
+	Create(bid *models.Bid) error
+	FindByUuidForUpdate(uuid string) (*models.Bid, error)
+	ListCrossingForUpdate(key OrderBookKey, minPrice float64, excludeAgentId uint) ([]*models.Bid, error)
+	ListFromAgent(agentId uint, request *requests.ListBids) (*utils.PaginationWrapper[*models.Bid], error)
+	Depth(key OrderBookKey) ([]*PriceLevel, error)
+	Update(bid *models.Bid) error
+	UpdateExpiredBids() error
+	CancelOpenFromBuyer(buyerId uint) error
+}
+
+type bidRepository struct {
+	db *gorm.DB
+}
+
+func NewBidRepository(db *gorm.DB) BidRepository {
+	return &bidRepository{db: db}
+}
+
+func (r *bidRepository) WithTransaction(tx *gorm.DB) BidRepository {
+	return NewBidRepository(tx)
+}
+
+func (r *bidRepository) Create(bid *models.Bid) error {
+	if err := r.db.Create(bid).Error; err != nil {
+		mlog.Log("Failed to create bid: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *bidRepository) FindByUuidForUpdate(uuid string) (*models.Bid, error) {
+	var bid models.Bid
+	err := r.db.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Buyer").
+		Where("uuid = ?", uuid).
+		First(&bid).Error
+
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			mlog.Log("Failed to find bid by uuid: " + err.Error())
+		}
+		return nil, err
+	}
+
+	return &bid, nil
+}
+
+// ListCrossingForUpdate locks the open bids an ask at minPrice would fill,
+// best price first then oldest first. Bids of the seller's own company are
+// left out.
+func (r *bidRepository) ListCrossingForUpdate(key OrderBookKey, minPrice float64, excludeAgentId uint) ([]*models.Bid, error) {
+	var bids []*models.Bid
+	err := r.db.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Buyer").
+		Where("submarket_id = ? AND energy_type_id = ? AND period_start = ? AND period_end = ?",
+			key.SubmarketId, key.EnergyTypeId, key.PeriodStart, key.PeriodEnd).
+		Where("status = ? AND remaining_quantity_mwh > 0 AND limit_price_per_mwh >= ?", models.BidStatusOpen, minPrice).
+		Where("buyer_id NOT IN (?)", r.db.Model(&models.User{}).Select("id").Where("agent_id = ?", excludeAgentId)).
+		Order("limit_price_per_mwh DESC, id ASC").
+		Find(&bids).Error
+
+	if err != nil {
+		mlog.Log("Failed to list crossing bids: " + err.Error())
+		return nil, err
+	}
+
+	return bids, nil
+}
+
+func (r *bidRepository) ListFromAgent(agentId uint, request *requests.ListBids) (*utils.PaginationWrapper[*models.Bid], error) {
+	var bids []*models.Bid
+
+	result := r.db.
+		Preload("Submarket").
+		Preload("EnergyType").
+		Preload("Buyer").
+		Joins("JOIN users buyers ON buyers.id = bids.buyer_id").
+		Where("buyers.agent_id = ?", agentId)
+
+	if request.Status != "" {
+		result = result.Where("bids.status = ?", request.Status)
+	}
+
+	err := result.
+		Order("bids.created_at DESC").
+		Scopes(scopes.Paginate(r.db, request.Page, request.PageSize)).
+		Find(&bids).Error
+	if err != nil {
+		mlog.Log("Failed to list bids from agent: " + err.Error())
+		return nil, err
+	}
+
+	return utils.NewPaginationWrapper(request.Page, request.PageSize, bids), nil
+}
+
+func (r *bidRepository) Depth(key OrderBookKey) ([]*PriceLevel, error) {
+	var levels []*PriceLevel
+	err := r.db.Model(&models.Bid{}).
+		Select("limit_price_per_mwh AS price_per_mwh, SUM(remaining_quantity_mwh) AS quantity_mwh, COUNT(*) AS orders").
+		Where("submarket_id = ? AND energy_type_id = ? AND period_start = ? AND period_end = ?",
+			key.SubmarketId, key.EnergyTypeId, key.PeriodStart, key.PeriodEnd).
+		Where("status = ? AND remaining_quantity_mwh > 0", models.BidStatusOpen).
+		Group("limit_price_per_mwh").
+		Order("limit_price_per_mwh DESC").
+		Scan(&levels).Error
+
+	if err != nil {
+		mlog.Log("Failed to get bid depth: " + err.Error())
+		return nil, err
+	}
+
+	return levels, nil
+}
+
+func (r *bidRepository) Update(bid *models.Bid) error {
+	err := r.db.Model(bid).Updates(map[string]any{
+		"remaining_quantity_mwh": bid.RemainingQuantityMwh,
+		"status":                 bid.Status,
+	}).Error
+	if err != nil {
+		mlog.Log("Failed to update bid: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *bidRepository) CancelOpenFromBuyer(buyerId uint) error {
+	err := r.db.Model(&models.Bid{}).
+		Where("buyer_id = ? AND status = ?", buyerId, models.BidStatusOpen).
+		Update("status", models.BidStatusCancelled).Error
+	if err != nil {
+		mlog.Log("Failed to cancel bids from buyer: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *bidRepository) UpdateExpiredBids() error {
+	return r.db.Model(&models.Bid{}).
+		Where("period_end < ? AND status = ?", utils.NowInLocal(), models.BidStatusOpen).
+		Update("status", models.BidStatusExpired).Error
+}
+
+// LockOrderBook serializes matching on a book until the transaction ends, so
+// a bid and an offer placed at the same time can't both miss each other.
+func LockOrderBook(tx *gorm.DB, key OrderBookKey) error {
+	err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", fmt.Sprintf(
+		"order_book:%d:%d:%s:%s",
+		key.SubmarketId,
+		key.EnergyTypeId,
+		key.PeriodStart.Format(time.DateOnly),
+		key.PeriodEnd.Format(time.DateOnly),
+	)).Error
+	if err != nil {
+		mlog.Log("Failed to lock order book: " + err.Error())
+		return err
+	}
+	return nil
+}
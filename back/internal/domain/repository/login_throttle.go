@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"ecoply/internal/domain/models"
+	"ecoply/internal/mlog"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LoginThrottleRepository interface {
+	WithTransaction(tx *gorm.DB) LoginThrottleRepository
+
+	Find(scope string, identifier string) (*models.LoginThrottle, error)
+	FindOrCreateForUpdate(scope string, identifier string) (*models.LoginThrottle, error)
+	Save(throttle *models.LoginThrottle) error
+	Reset(scope string, identifier string) error
+}
+
+type loginThrottleRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginThrottleRepository(db *gorm.DB) LoginThrottleRepository {
+	return &loginThrottleRepository{db: db}
+}
+
+func (r *loginThrottleRepository) WithTransaction(tx *gorm.DB) LoginThrottleRepository {
+	return NewLoginThrottleRepository(tx)
+}
+
+func (r *loginThrottleRepository) Find(scope string, identifier string) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	err := r.db.Where("scope = ? AND identifier = ?", scope, identifier).First(&throttle).Error
+
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			mlog.Log("Failed to find login throttle: " + err.Error())
+		}
+		return nil, err
+	}
+
+	return &throttle, nil
+}
+
+// FindOrCreateForUpdate must run inside a transaction. Concurrent failures
+// for the same key are serialized by the row lock.
+func (r *loginThrottleRepository) FindOrCreateForUpdate(scope string, identifier string) (*models.LoginThrottle, error) {
+	err := r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.LoginThrottle{Scope: scope, Identifier: identifier}).Error
+	if err != nil {
+		mlog.Log("Failed to create login throttle: " + err.Error())
+		return nil, err
+	}
+
+	var throttle models.LoginThrottle
+	err = r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("scope = ? AND identifier = ?", scope, identifier).
+		First(&throttle).Error
+	if err != nil {
+		mlog.Log("Failed to lock login throttle: " + err.Error())
+		return nil, err
+	}
+
+	return &throttle, nil
+}
+
+func (r *loginThrottleRepository) Save(throttle *models.LoginThrottle) error {
+	if err := r.db.Save(throttle).Error; err != nil {
+		mlog.Log("Failed to save login throttle: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *loginThrottleRepository) Reset(scope string, identifier string) error {
+	err := r.db.Model(&models.LoginThrottle{}).
+		Where("scope = ? AND identifier = ?", scope, identifier).
+		Updates(map[string]any{
+			"failures":        0,
+			"last_failure_at": nil,
+			"locked_until":    nil,
+		}).Error
+	if err != nil {
+		mlog.Log("Failed to reset login throttle: " + err.Error())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/utils"
+	"ecoply/internal/mlog"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NegotiationRepository interface {
+	WithTransaction(tx *gorm.DB) NegotiationRepository
+
+	Create(negotiation *models.Negotiation) error
+	FindByUuid(uuid string) (*models.Negotiation, error)
+	FindByUuidForUpdate(uuid string) (*models.Negotiation, error)
+	HasOpenFromAgent(offerId uint, agentId uint) (bool, error)
+	ListFromOffer(offerId uint) ([]*models.Negotiation, error)
+	ListFromOfferAndAgent(offerId uint, agentId uint) ([]*models.Negotiation, error)
+	Update(negotiation *models.Negotiation) error
+	UpdateExpiredNegotiations() error
+
+	CreateProposal(proposal *models.NegotiationProposal) error
+	FindPendingProposal(negotiationId uint) (*models.NegotiationProposal, error)
+	UpdateProposal(proposal *models.NegotiationProposal) error
+}
+
+type negotiationRepository struct {
+	db *gorm.DB
+}
+
+func NewNegotiationRepository(db *gorm.DB) NegotiationRepository {
+	return &negotiationRepository{db: db}
+}
+
+func (r *negotiationRepository) WithTransaction(tx *gorm.DB) NegotiationRepository {
+	return NewNegotiationRepository(tx)
+}
+
+func (r *negotiationRepository) Create(negotiation *models.Negotiation) error {
+	if err := r.db.Create(negotiation).Error; err != nil {
+		mlog.Log("Failed to create negotiation: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *negotiationRepository) FindByUuid(uuid string) (*models.Negotiation, error) {
+	return r.find(r.db, uuid)
+}
+
+// FindByUuidForUpdate serializes the answers to the same negotiation.
+func (r *negotiationRepository) FindByUuidForUpdate(uuid string) (*models.Negotiation, error) {
+	return r.find(r.db.Clauses(clause.Locking{Strength: "UPDATE"}), uuid)
+}
+
+func (r *negotiationRepository) find(db *gorm.DB, uuid string) (*models.Negotiation, error) {
+	var negotiation models.Negotiation
+	err := db.
+		Preload("Buyer").
+		Preload("Offer").
+		Preload("Offer.Seller").
+		Preload("Purchase").
+		Preload("Proposals", func(db *gorm.DB) *gorm.DB {
+			return db.Order("negotiation_proposals.id ASC")
+		}).
+		Preload("Proposals.Author").
+		Where("uuid = ?", uuid).
+		First(&negotiation).Error
+
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			mlog.Log("Failed to find negotiation: " + err.Error())
+		}
+		return nil, err
+	}
+
+	return &negotiation, nil
+}
+
+func (r *negotiationRepository) HasOpenFromAgent(offerId uint, agentId uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Negotiation{}).
+		Joins("JOIN users buyers ON buyers.id = negotiations.buyer_id").
+		Where("negotiations.offer_id = ? AND negotiations.status = ? AND buyers.agent_id = ?",
+			offerId, models.NegotiationStatusOpen, agentId).
+		Count(&count).Error
+
+	if err != nil {
+		mlog.Log("Failed to count open negotiations from agent: " + err.Error())
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *negotiationRepository) ListFromOffer(offerId uint) ([]*models.Negotiation, error) {
+	return r.list(r.db.Where("negotiations.offer_id = ?", offerId))
+}
+
+func (r *negotiationRepository) ListFromOfferAndAgent(offerId uint, agentId uint) ([]*models.Negotiation, error) {
+	return r.list(r.db.
+		Joins("JOIN users buyers ON buyers.id = negotiations.buyer_id").
+		Where("negotiations.offer_id = ? AND buyers.agent_id = ?", offerId, agentId))
+}
+
+func (r *negotiationRepository) list(db *gorm.DB) ([]*models.Negotiation, error) {
+	var negotiations []*models.Negotiation
+	err := db.
+		Preload("Buyer").
+		Preload("Offer").
+		Preload("Purchase").
+		Preload("Proposals", func(db *gorm.DB) *gorm.DB {
+			return db.Order("negotiation_proposals.id ASC")
+		}).
+		Preload("Proposals.Author").
+		Order("negotiations.created_at DESC").
+		Find(&negotiations).Error
+
+	if err != nil {
+		mlog.Log("Failed to list negotiations: " + err.Error())
+		return nil, err
+	}
+
+	return negotiations, nil
+}
+
+func (r *negotiationRepository) Update(negotiation *models.Negotiation) error {
+	err := r.db.Model(negotiation).
+		Select("status", "purchase_id").
+		Updates(map[string]any{"status": negotiation.Status, "purchase_id": negotiation.PurchaseId}).Error
+	if err != nil {
+		mlog.Log("Failed to update negotiation: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+// UpdateExpiredNegotiations expires the pending proposals past their
+// expiry, along with the negotiations left with nothing to answer.
+func (r *negotiationRepository) UpdateExpiredNegotiations() error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.NegotiationProposal{}).
+			Where("status = ? AND expires_at <= ?", models.NegotiationProposalStatusPending, utils.NowInLocal()).
+			Update("status", models.NegotiationProposalStatusExpired).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Negotiation{}).
+			Where("status = ?", models.NegotiationStatusOpen).
+			Where("NOT EXISTS (?)", tx.Model(&models.NegotiationProposal{}).
+				Select("1").
+				Where("negotiation_proposals.negotiation_id = negotiations.id AND negotiation_proposals.status = ?",
+					models.NegotiationProposalStatusPending)).
+			Update("status", models.NegotiationStatusExpired).Error
+	})
+}
+
+func (r *negotiationRepository) CreateProposal(proposal *models.NegotiationProposal) error {
+	if err := r.db.Create(proposal).Error; err != nil {
+		mlog.Log("Failed to create negotiation proposal: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *negotiationRepository) FindPendingProposal(negotiationId uint) (*models.NegotiationProposal, error) {
+	var proposal models.NegotiationProposal
+	err := r.db.
+		Where("negotiation_id = ? AND status = ?", negotiationId, models.NegotiationProposalStatusPending).
+		Order("id DESC").
+		First(&proposal).Error
+
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			mlog.Log("Failed to find pending negotiation proposal: " + err.Error())
+		}
+		return nil, err
+	}
+
+	return &proposal, nil
+}
+
+func (r *negotiationRepository) UpdateProposal(proposal *models.NegotiationProposal) error {
+	err := r.db.Model(proposal).Update("status", proposal.Status).Error
+	if err != nil {
+		mlog.Log("Failed to update negotiation proposal: " + err.Error())
+		return err
+	}
+	return nil
+}
@@ -6,8 +6,10 @@ import (
 	"ecoply/internal/domain/scopes"
 	"ecoply/internal/domain/utils"
 	"ecoply/internal/mlog"
+	"slices"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OfferRepository interface {
@@ -15,13 +17,26 @@ type OfferRepository interface {
 
 	GetByUuid(uuid string) (*models.Offer, error)
 	GetById(id uint) (*models.Offer, error)
-	GetBySellerId(userId uint) ([]*models.Offer, error)
+	FindByUuidForUpdate(uuid string) (*models.Offer, error)
+	FindByIdForUpdate(id uint) (*models.Offer, error)
+	GetByAgentId(agentId uint) ([]*models.Offer, error)
+	GetBySellerId(sellerId uint) ([]*models.Offer, error)
+	ExpireActiveFromSeller(sellerId uint) error
 	Create(*models.Offer) (*models.Offer, error)
 	List(request *requests.ListOffers, user *models.User) (*utils.PaginationWrapper[*models.Offer], error)
 	Purchases(offerUuid string, request *requests.ListPurchasesFromOffer) ([]*models.Purchase, error)
 	Update(offer *models.Offer) error
 	Delete(uuid string) error
 	UpdateExpiredOffers() error
+	ListCrossingForUpdate(key OrderBookKey, maxPrice float64, excludeAgentId uint) ([]*models.Offer, error)
+	Depth(key OrderBookKey) ([]*PriceLevel, error)
+	ListEndedAuctionIds() ([]uint, error)
+	ListDueScheduledIds() ([]uint, error)
+	IsVisibleTo(offerId uint, agentId uint) (bool, error)
+	ReplaceAudience(offer *models.Offer, agents []*models.Agent, submarkets []*models.Submarket) error
+	ReplacePriceTiers(offer *models.Offer, tiers []models.OfferPriceTier) error
+	ReplaceDeliveryBlocks(offer *models.Offer, blocks []models.OfferDeliveryBlock) error
+	DeliveryBlocks(offerId uint) ([]models.OfferDeliveryBlock, error)
 }
 
 type offerRepository struct {
@@ -41,18 +56,43 @@ func (r *offerRepository) GetByUuid(uuid string) (*models.Offer, error) {
 	if err := r.db.Preload("Submarket").
 		Preload("EnergyType").
 		Preload("Seller").
+		Preload("PriceTiers", orderPriceTiers).
+		Preload("DeliveryBlocks", orderDeliveryBlocks).
 		Where("uuid = ?", uuid).First(&offer).Error; err != nil {
 		return nil, err
 	}
 	return &offer, nil
 }
 
-func (r *offerRepository) GetBySellerId(userId uint) ([]*models.Offer, error) {
+// GetByAgentId returns the offers of every user of the company, along with
+// who they are restricted to.
+func (r *offerRepository) GetByAgentId(agentId uint) ([]*models.Offer, error) {
 	var offers []*models.Offer
 	if err := r.db.Preload("Submarket").
 		Preload("EnergyType").
 		Preload("Seller").
-		Where("seller_id = ?", userId).
+		Preload("PriceTiers", orderPriceTiers).
+		Preload("DeliveryBlocks", orderDeliveryBlocks).
+		Preload("AllowedAgents").
+		Preload("AllowedSubmarkets").
+		Joins("JOIN users sellers ON sellers.id = offers.seller_id").
+		Where("sellers.agent_id = ?", agentId).
+		Order("offers.created_at DESC").
+		Find(&offers).Error; err != nil {
+		mlog.Log("Failed to get by agent id: " + err.Error())
+		return nil, err
+	}
+	return offers, nil
+}
+
+func (r *offerRepository) GetBySellerId(sellerId uint) ([]*models.Offer, error) {
+	var offers []*models.Offer
+	if err := r.db.Preload("Submarket").
+		Preload("EnergyType").
+		Preload("Seller").
+		Preload("PriceTiers", orderPriceTiers).
+		Preload("DeliveryBlocks", orderDeliveryBlocks).
+		Where("seller_id = ?", sellerId).
 		Order("created_at DESC").
 		Find(&offers).Error; err != nil {
 		mlog.Log("Failed to get by seller id: " + err.Error())
@@ -61,6 +101,17 @@ func (r *offerRepository) GetBySellerId(userId uint) ([]*models.Offer, error) {
 	return offers, nil
 }
 
+func (r *offerRepository) ExpireActiveFromSeller(sellerId uint) error {
+	err := r.db.Model(&models.Offer{}).
+		Where("seller_id = ? AND status IN (?)", sellerId, expirableOfferStatuses()).
+		Update("status", models.OfferStatusExpired).Error
+	if err != nil {
+		mlog.Log("Failed to expire offers from seller: " + err.Error())
+		return err
+	}
+	return nil
+}
+
 func (r *offerRepository) Create(offer *models.Offer) (*models.Offer, error) {
 	if err := r.db.Create(offer).Error; err != nil {
 		mlog.Log("Failed to create offer: " + err.Error())
@@ -96,10 +147,13 @@ func (r *offerRepository) List(request *requests.ListOffers, user *models.User)
 		Preload("Submarket").
 		Preload("EnergyType").
 		Preload("Seller").
+		Preload("PriceTiers", orderPriceTiers).
+		Preload("DeliveryBlocks", orderDeliveryBlocks).
 		InnerJoins("Submarket").
 		InnerJoins("EnergyType").
-		Where("seller_id != ?", user.ID).
-		Where("status NOT IN (?)", []string{models.OfferStatusExpired, models.OfferStatusFulfilled})
+		Where("offers.seller_id NOT IN (?)", r.db.Model(&models.User{}).Select("id").Where("agent_id = ?", user.AgentId)).
+		Where("status IN (?)", []string{models.OfferStatusFresh, models.OfferStatusOpen}).
+		Scopes(scopes.OfferVisibleToAgent(user.AgentId))
 
 	if request.Submarket != "" {
 		result = result.Where("\"Submarket\".name = ?", request.Submarket)
@@ -130,20 +184,235 @@ func (r *offerRepository) List(request *requests.ListOffers, user *models.User)
 	return paginationWrapper, nil
 }
 
+// ListCrossingForUpdate locks the offers a bid at maxPrice would buy from,
+// best price first then oldest first. Offers of the buyer's own company are
+// left out, as are auctions, restricted offers and offers sold in lots which
+// stay off the book.
+func (r *offerRepository) ListCrossingForUpdate(key OrderBookKey, maxPrice float64, excludeAgentId uint) ([]*models.Offer, error) {
+	var offers []*models.Offer
+	err := r.db.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Seller").
+		Where("submarket_id = ? AND energy_type_id = ? AND period_start = ? AND period_end = ?",
+			key.SubmarketId, key.EnergyTypeId, key.PeriodStart, key.PeriodEnd).
+		Where("status IN (?) AND remaining_quantity_mwh > 0 AND price_per_mwh <= ?",
+			[]string{models.OfferStatusFresh, models.OfferStatusOpen}, maxPrice).
+		Where("auction_type = ? AND visibility = ?", models.OfferAuctionNone, models.OfferVisibilityPublic).
+		Where("min_quantity_mwh IS NULL AND quantity_step_mwh IS NULL").
+		Where("NOT EXISTS (?)", r.db.Model(&models.OfferPriceTier{}).Select("1").Where("offer_price_tiers.offer_id = offers.id")).
+		Where("seller_id NOT IN (?)", r.db.Model(&models.User{}).Select("id").Where("agent_id = ?", excludeAgentId)).
+		Order("price_per_mwh ASC, id ASC").
+		Find(&offers).Error
+
+	if err != nil {
+		mlog.Log("Failed to list crossing offers: " + err.Error())
+		return nil, err
+	}
+
+	return offers, nil
+}
+
+func (r *offerRepository) Depth(key OrderBookKey) ([]*PriceLevel, error) {
+	var levels []*PriceLevel
+	err := r.db.Model(&models.Offer{}).
+		Select("price_per_mwh, SUM(remaining_quantity_mwh) AS quantity_mwh, COUNT(*) AS orders").
+		Where("submarket_id = ? AND energy_type_id = ? AND period_start = ? AND period_end = ?",
+			key.SubmarketId, key.EnergyTypeId, key.PeriodStart, key.PeriodEnd).
+		Where("status IN (?) AND remaining_quantity_mwh > 0", []string{models.OfferStatusFresh, models.OfferStatusOpen}).
+		Where("auction_type = ? AND visibility = ?", models.OfferAuctionNone, models.OfferVisibilityPublic).
+		Where("min_quantity_mwh IS NULL AND quantity_step_mwh IS NULL").
+		Where("NOT EXISTS (?)", r.db.Model(&models.OfferPriceTier{}).Select("1").Where("offer_price_tiers.offer_id = offers.id")).
+		Group("price_per_mwh").
+		Order("price_per_mwh ASC").
+		Scan(&levels).Error
+
+	if err != nil {
+		mlog.Log("Failed to get offer depth: " + err.Error())
+		return nil, err
+	}
+
+	return levels, nil
+}
+
+// ListEndedAuctionIds returns the auctions past their end that were not
+// awarded yet.
+func (r *offerRepository) ListEndedAuctionIds() ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.Offer{}).
+		Where("auction_type <> ? AND status = ? AND auction_ends_at <= ?",
+			models.OfferAuctionNone, models.OfferStatusFresh, utils.NowInLocal()).
+		Pluck("id", &ids).Error
+
+	if err != nil {
+		mlog.Log("Failed to list ended auctions: " + err.Error())
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// ListDueScheduledIds returns the scheduled offers whose publication time
+// has come.
+func (r *offerRepository) ListDueScheduledIds() ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.Offer{}).
+		Where("status = ? AND publish_at <= ?", models.OfferStatusScheduled, utils.NowInLocal()).
+		Pluck("id", &ids).Error
+
+	if err != nil {
+		mlog.Log("Failed to list due scheduled offers: " + err.Error())
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (r *offerRepository) IsVisibleTo(offerId uint, agentId uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Offer{}).
+		Where("offers.id = ?", offerId).
+		Scopes(scopes.OfferVisibleToAgent(agentId)).
+		Count(&count).Error
+
+	if err != nil {
+		mlog.Log("Failed to check offer visibility: " + err.Error())
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// ReplaceAudience sets who may see the offer, restricting it when there is
+// anyone to restrict it to.
+func (r *offerRepository) ReplaceAudience(offer *models.Offer, agents []*models.Agent, submarkets []*models.Submarket) error {
+	offer.Visibility = models.OfferVisibilityPublic
+	if len(agents) > 0 || len(submarkets) > 0 {
+		offer.Visibility = models.OfferVisibilityRestricted
+	}
+
+	err := r.db.Model(offer).Update("visibility", offer.Visibility).Error
+	if err == nil {
+		err = r.db.Model(offer).Association("AllowedAgents").Replace(agents)
+	}
+	if err == nil {
+		err = r.db.Model(offer).Association("AllowedSubmarkets").Replace(submarkets)
+	}
+
+	if err != nil {
+		mlog.Log("Failed to replace offer audience: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// ReplacePriceTiers swaps the volume discounts of the offer for tiers.
+func (r *offerRepository) ReplacePriceTiers(offer *models.Offer, tiers []models.OfferPriceTier) error {
+	err := r.db.Unscoped().Where("offer_id = ?", offer.ID).Delete(&models.OfferPriceTier{}).Error
+	if err == nil && len(tiers) > 0 {
+		for i := range tiers {
+			tiers[i].OfferId = offer.ID
+		}
+		err = r.db.Create(&tiers).Error
+	}
+
+	if err != nil {
+		mlog.Log("Failed to replace offer price tiers: " + err.Error())
+		return err
+	}
+
+	offer.PriceTiers = tiers
+	return nil
+}
+
+// ReplaceDeliveryBlocks swaps the delivery profile of the offer for blocks.
+func (r *offerRepository) ReplaceDeliveryBlocks(offer *models.Offer, blocks []models.OfferDeliveryBlock) error {
+	err := r.db.Unscoped().Where("offer_id = ?", offer.ID).Delete(&models.OfferDeliveryBlock{}).Error
+	if err == nil && len(blocks) > 0 {
+		for i := range blocks {
+			blocks[i].OfferId = offer.ID
+		}
+		err = r.db.Create(&blocks).Error
+	}
+
+	if err != nil {
+		mlog.Log("Failed to replace offer delivery blocks: " + err.Error())
+		return err
+	}
+
+	offer.DeliveryBlocks = blocks
+	return nil
+}
+
+func (r *offerRepository) DeliveryBlocks(offerId uint) ([]models.OfferDeliveryBlock, error) {
+	var blocks []models.OfferDeliveryBlock
+	if err := r.db.Where("offer_id = ?", offerId).Scopes(orderDeliveryBlocks).Find(&blocks).Error; err != nil {
+		mlog.Log("Failed to list offer delivery blocks: " + err.Error())
+		return nil, err
+	}
+	return blocks, nil
+}
+
+func orderPriceTiers(db *gorm.DB) *gorm.DB {
+	return db.Order("min_quantity_mwh")
+}
+
+func orderDeliveryBlocks(db *gorm.DB) *gorm.DB {
+	return db.Order("slot")
+}
+
 func (r *offerRepository) UpdateExpiredOffers() error {
 	return r.db.Model(&models.Offer{}).
-		Where("period_end < ? AND status IN (?)", utils.NowInLocal(), []string{
-			models.OfferStatusFresh,
-			models.OfferStatusOpen,
-		}).
+		Where("period_end < ? AND status IN (?)", utils.NowInLocal(), expirableOfferStatuses()).
 		Update("status", models.OfferStatusExpired).Error
 }
 
+// expirableOfferStatuses are the statuses bulk expiries move to expired.
+// Fulfilled offers are left as they are, they sold out.
+func expirableOfferStatuses() []string {
+	return slices.DeleteFunc(models.OfferStatusesTransitioningTo(models.OfferStatusExpired), func(status string) bool {
+		return status == models.OfferStatusFulfilled
+	})
+}
+
 func (r *offerRepository) GetById(id uint) (*models.Offer, error) {
 	var offer models.Offer
 	if err := r.db.Preload("Submarket").
 		Preload("EnergyType").
 		Preload("Seller").
+		Preload("PriceTiers", orderPriceTiers).
+		Preload("DeliveryBlocks", orderDeliveryBlocks).
+		First(&offer, id).Error; err != nil {
+		return nil, err
+	}
+	return &offer, nil
+}
+
+func (r *offerRepository) FindByUuidForUpdate(uuid string) (*models.Offer, error) {
+	var offer models.Offer
+	if err := r.db.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Submarket").
+		Preload("EnergyType").
+		Preload("Seller").
+		Preload("PriceTiers", orderPriceTiers).
+		Preload("DeliveryBlocks", orderDeliveryBlocks).
+		Where("uuid = ?", uuid).
+		First(&offer).Error; err != nil {
+		return nil, err
+	}
+	return &offer, nil
+}
+
+func (r *offerRepository) FindByIdForUpdate(id uint) (*models.Offer, error) {
+	var offer models.Offer
+	if err := r.db.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Submarket").
+		Preload("EnergyType").
+		Preload("Seller").
+		Preload("PriceTiers", orderPriceTiers).
+		Preload("DeliveryBlocks", orderDeliveryBlocks).
 		First(&offer, id).Error; err != nil {
 		return nil, err
 	}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"ecoply/internal/domain/models"
+	"ecoply/internal/mlog"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OfferTemplateRepository interface {
+	WithTransaction(tx *gorm.DB) OfferTemplateRepository
+
+	Create(template *models.OfferTemplate) error
+	FindByUuid(uuid string) (*models.OfferTemplate, error)
+	ListFromAgent(agentId uint) ([]*models.OfferTemplate, error)
+	ListDue(now time.Time) ([]*models.OfferTemplate, error)
+	ClaimRun(template *models.OfferTemplate, nextPublishAt time.Time) (bool, error)
+	Update(template *models.OfferTemplate) error
+	Delete(template *models.OfferTemplate) error
+	PauseActiveFromSeller(sellerId uint) error
+}
+
+type offerTemplateRepository struct {
+	db *gorm.DB
+}
+
+func NewOfferTemplateRepository(db *gorm.DB) OfferTemplateRepository {
+	return &offerTemplateRepository{db: db}
+}
+
+func (r *offerTemplateRepository) WithTransaction(tx *gorm.DB) OfferTemplateRepository {
+	return NewOfferTemplateRepository(tx)
+}
+
+func (r *offerTemplateRepository) Create(template *models.OfferTemplate) error {
+	if err := r.db.Create(template).Error; err != nil {
+		mlog.Log("Failed to create offer template: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *offerTemplateRepository) FindByUuid(uuid string) (*models.OfferTemplate, error) {
+	var template models.OfferTemplate
+	err := r.db.
+		Preload("EnergyType").
+		Preload("Seller").
+		Where("uuid = ?", uuid).
+		First(&template).Error
+
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			mlog.Log("Failed to find offer template: " + err.Error())
+		}
+		return nil, err
+	}
+
+	return &template, nil
+}
+
+func (r *offerTemplateRepository) ListFromAgent(agentId uint) ([]*models.OfferTemplate, error) {
+	var templates []*models.OfferTemplate
+	err := r.db.
+		Preload("EnergyType").
+		Preload("Seller").
+		Joins("JOIN users sellers ON sellers.id = offer_templates.seller_id").
+		Where("sellers.agent_id = ?", agentId).
+		Order("offer_templates.created_at DESC").
+		Find(&templates).Error
+
+	if err != nil {
+		mlog.Log("Failed to list offer templates: " + err.Error())
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+// ListDue returns the active series whose next offer should be published.
+func (r *offerTemplateRepository) ListDue(now time.Time) ([]*models.OfferTemplate, error) {
+	var templates []*models.OfferTemplate
+	err := r.db.
+		Preload("EnergyType").
+		Preload("Seller").
+		Where("status = ? AND next_publish_at <= ?", models.OfferTemplateStatusActive, now).
+		Find(&templates).Error
+
+	if err != nil {
+		mlog.Log("Failed to list due offer templates: " + err.Error())
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+// ClaimRun moves the series to its next publish date, only when no one else
+// did it first, so each run is published once.
+func (r *offerTemplateRepository) ClaimRun(template *models.OfferTemplate, nextPublishAt time.Time) (bool, error) {
+	result := r.db.Model(&models.OfferTemplate{}).
+		Where("id = ? AND status = ? AND next_publish_at = ?", template.ID, models.OfferTemplateStatusActive, template.NextPublishAt).
+		Update("next_publish_at", nextPublishAt)
+
+	if result.Error != nil {
+		mlog.Log("Failed to claim offer template run: " + result.Error.Error())
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (r *offerTemplateRepository) Update(template *models.OfferTemplate) error {
+	if err := r.db.Omit("EnergyType", "Seller").Save(template).Error; err != nil {
+		mlog.Log("Failed to update offer template: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *offerTemplateRepository) Delete(template *models.OfferTemplate) error {
+	if err := r.db.Delete(template).Error; err != nil {
+		mlog.Log("Failed to delete offer template: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *offerTemplateRepository) PauseActiveFromSeller(sellerId uint) error {
+	return r.db.Model(&models.OfferTemplate{}).
+		Where("seller_id = ? AND status = ?", sellerId, models.OfferTemplateStatusActive).
+		Updates(map[string]any{"status": models.OfferTemplateStatusPaused, "next_publish_at": nil}).Error
+}
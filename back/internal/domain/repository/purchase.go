@@ -16,8 +16,12 @@ type PurchaseRepository interface {
 	Create(purchase *models.Purchase) error
 	Update(purchase *models.Purchase) error
 	FindByUuid(uuid string) (*models.Purchase, error)
-	ListPurchases(buyerId uint64, request *requests.ListPurchase) (*utils.PaginationWrapper[*models.Purchase], error)
-	ListSold(sellerId uint64, request *requests.ListSold) (*utils.PaginationWrapper[*models.Purchase], error)
+	ListPurchases(buyerAgentId uint64, request *requests.ListPurchase) (*utils.PaginationWrapper[*models.Purchase], error)
+	ListSold(sellerAgentId uint64, request *requests.ListSold) (*utils.PaginationWrapper[*models.Purchase], error)
+	AllFromBuyer(buyerId uint) ([]*models.Purchase, error)
+	AllFromSeller(sellerId uint) ([]*models.Purchase, error)
+	CreateDeliveries(deliveries []models.PurchaseDelivery) error
+	Deliveries(purchaseId uint) ([]models.PurchaseDelivery, error)
 }
 
 type purchaseRepository struct {
@@ -42,6 +46,23 @@ func (r *purchaseRepository) Create(purchase *models.Purchase) error {
 	return nil
 }
 
+func (r *purchaseRepository) CreateDeliveries(deliveries []models.PurchaseDelivery) error {
+	if err := r.db.Create(&deliveries).Error; err != nil {
+		mlog.Log("Failed to create purchase deliveries: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *purchaseRepository) Deliveries(purchaseId uint) ([]models.PurchaseDelivery, error) {
+	var deliveries []models.PurchaseDelivery
+	if err := r.db.Where("purchase_id = ?", purchaseId).Order("slot").Find(&deliveries).Error; err != nil {
+		mlog.Log("Failed to list purchase deliveries: " + err.Error())
+		return nil, err
+	}
+	return deliveries, nil
+}
+
 func (r *purchaseRepository) Delete(uuid string) error {
 	if err := r.db.Where("uuid = ?", uuid).Delete(&models.Purchase{}).Error; err != nil {
 		mlog.Log("Failed to delete purchase: " + err.Error())
@@ -58,7 +79,7 @@ func (r *purchaseRepository) FindByUuid(uuid string) (*models.Purchase, error) {
 			return db.Select("id, uuid, seller_id")
 		}).
 		Preload("Offer.Seller", func(db *gorm.DB) *gorm.DB {
-			return db.Select("id, uuid")
+			return db.Select("id, uuid, agent_id")
 		}).
 		Where("uuid = ?", uuid).First(&purchase).Error; err != nil {
 		mlog.Log("Failed to find purchase by uuid: " + err.Error())
@@ -75,7 +96,7 @@ func (r *purchaseRepository) Update(purchase *models.Purchase) error {
 	return nil
 }
 
-func (r *purchaseRepository) ListPurchases(buyerId uint64, request *requests.ListPurchase) (*utils.PaginationWrapper[*models.Purchase], error) {
+func (r *purchaseRepository) ListPurchases(buyerAgentId uint64, request *requests.ListPurchase) (*utils.PaginationWrapper[*models.Purchase], error) {
 	var purchases []*models.Purchase
 
 	result := r.db.Debug().
@@ -86,7 +107,8 @@ func (r *purchaseRepository) ListPurchases(buyerId uint64, request *requests.Lis
 		Preload("Offer.Seller", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id, uuid, name")
 		}).
-		Where("purchases.buyer_id = ?", buyerId).
+		Joins("JOIN users buyers ON buyers.id = purchases.buyer_id").
+		Where("buyers.agent_id = ?", buyerAgentId).
 		Select("purchases.*, (purchases.price_per_mwh * purchases.quantity_mwh) AS purchase_value")
 
 	if request.Status != "" {
@@ -98,7 +120,7 @@ func (r *purchaseRepository) ListPurchases(buyerId uint64, request *requests.Lis
 	}
 
 	if request.OrderPrice == "" && request.OrderQuantity == "" {
-		result = result.Order("created_at DESC")
+		result = result.Order("purchases.created_at DESC")
 	}
 
 	switch request.OrderPrice {
@@ -127,16 +149,16 @@ func (r *purchaseRepository) ListPurchases(buyerId uint64, request *requests.Lis
 	return paginationWrapper, nil
 }
 
-func (r *purchaseRepository) ListSold(sellerId uint64, request *requests.ListSold) (*utils.PaginationWrapper[*models.Purchase], error) {
+func (r *purchaseRepository) ListSold(sellerAgentId uint64, request *requests.ListSold) (*utils.PaginationWrapper[*models.Purchase], error) {
 	var purchases []*models.Purchase
 
 	result := r.db.
 		Joins("JOIN offers ON offers.id = purchases.offer_id").
-		Where("offers.seller_id = ?", sellerId).
+		Joins("JOIN users sellers ON sellers.id = offers.seller_id").
+		Where("sellers.agent_id = ?", sellerAgentId).
 		Preload("Buyer").
 		Preload("Offer", func(db *gorm.DB) *gorm.DB {
-			return db.Select("id, uuid, seller_id").
-				Where("seller_id = ?", sellerId)
+			return db.Select("id, uuid, seller_id")
 		}).
 		Preload("Offer.Seller", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id, uuid, name")
@@ -152,7 +174,7 @@ func (r *purchaseRepository) ListSold(sellerId uint64, request *requests.ListSol
 	}
 
 	if request.OrderPrice == "" && request.OrderQuantity == "" {
-		result = result.Order("created_at DESC")
+		result = result.Order("purchases.created_at DESC")
 	}
 
 	switch request.OrderPrice {
@@ -180,3 +202,48 @@ func (r *purchaseRepository) ListSold(sellerId uint64, request *requests.ListSol
 
 	return paginationWrapper, nil
 }
+
+func (r *purchaseRepository) AllFromBuyer(buyerId uint) ([]*models.Purchase, error) {
+	var purchases []*models.Purchase
+
+	err := r.db.
+		Preload("Buyer").
+		Preload("Offer", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, uuid, seller_id")
+		}).
+		Preload("Offer.Seller", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, uuid, name")
+		}).
+		Where("buyer_id = ?", buyerId).
+		Order("created_at ASC").
+		Find(&purchases).Error
+	if err != nil {
+		mlog.Log("Failed to list purchases from buyer: " + err.Error())
+		return nil, err
+	}
+
+	return purchases, nil
+}
+
+func (r *purchaseRepository) AllFromSeller(sellerId uint) ([]*models.Purchase, error) {
+	var purchases []*models.Purchase
+
+	err := r.db.
+		Joins("JOIN offers ON offers.id = purchases.offer_id").
+		Where("offers.seller_id = ?", sellerId).
+		Preload("Buyer").
+		Preload("Offer", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, uuid, seller_id")
+		}).
+		Preload("Offer.Seller", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, uuid, name")
+		}).
+		Order("purchases.created_at ASC").
+		Find(&purchases).Error
+	if err != nil {
+		mlog.Log("Failed to list purchases from seller: " + err.Error())
+		return nil, err
+	}
+
+	return purchases, nil
+}
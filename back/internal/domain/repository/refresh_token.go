@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/utils"
+	"ecoply/internal/mlog"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RefreshTokenRepository interface {
+	WithTransaction(tx *gorm.DB) RefreshTokenRepository
+
+	Create(token *models.RefreshToken) error
+	FindByHashForUpdate(tokenHash string) (*models.RefreshToken, error)
+	MarkUsed(token *models.RefreshToken) error
+	RevokeFamily(familyId string) error
+	RevokeAllFromUser(userId uint) error
+}
+
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+func (r *refreshTokenRepository) WithTransaction(tx *gorm.DB) RefreshTokenRepository {
+	return NewRefreshTokenRepository(tx)
+}
+
+func (r *refreshTokenRepository) Create(token *models.RefreshToken) error {
+	if err := r.db.Create(token).Error; err != nil {
+		mlog.Log("Failed to create refresh token: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *refreshTokenRepository) FindByHashForUpdate(tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", tokenHash).
+		First(&token).Error
+
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			mlog.Log("Failed to find refresh token by hash: " + err.Error())
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (r *refreshTokenRepository) MarkUsed(token *models.RefreshToken) error {
+	var now = utils.NowInLocal()
+
+	if err := r.db.Model(token).Update("used_at", now).Error; err != nil {
+		mlog.Log("Failed to mark refresh token as used: " + err.Error())
+		return err
+	}
+
+	token.UsedAt = &now
+	return nil
+}
+
+// RevokeFamily also revokes the session of the family, so its access tokens
+// stop working right away.
+func (r *refreshTokenRepository) RevokeFamily(familyId string) error {
+	var now = utils.NowInLocal()
+
+	err := r.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyId).
+		Update("revoked_at", now).Error
+
+	if err == nil {
+		err = r.db.Model(&models.Session{}).
+			Where("uuid = ? AND revoked_at IS NULL", familyId).
+			Update("revoked_at", now).Error
+	}
+
+	if err != nil {
+		mlog.Log("Failed to revoke refresh token family: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// RevokeAllFromUser logs the user out of every session.
+func (r *refreshTokenRepository) RevokeAllFromUser(userId uint) error {
+	var now = utils.NowInLocal()
+
+	err := r.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userId).
+		Update("revoked_at", now).Error
+
+	if err == nil {
+		err = r.db.Model(&models.Session{}).
+			Where("user_id = ? AND revoked_at IS NULL", userId).
+			Update("revoked_at", now).Error
+	}
+
+	if err != nil {
+		mlog.Log("Failed to revoke user refresh tokens: " + err.Error())
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/scopes"
+	"ecoply/internal/domain/utils"
+	"ecoply/internal/mlog"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RfqRepository interface {
+	WithTransaction(tx *gorm.DB) RfqRepository
+
+	Create(rfq *models.Rfq) error
+	FindByUuid(uuid string) (*models.Rfq, error)
+	FindByUuidForUpdate(uuid string) (*models.Rfq, error)
+	FindById(id uint) (*models.Rfq, error)
+	ListOpen(request *requests.ListRfqs, user *models.User) (*utils.PaginationWrapper[*models.Rfq], error)
+	ListFromAgent(agentId uint, request *requests.ListOwnRfqs) (*utils.PaginationWrapper[*models.Rfq], error)
+	Update(rfq *models.Rfq) error
+	UpdateExpiredRfqs() error
+	CancelOpenFromBuyer(buyerId uint) error
+}
+
+type rfqRepository struct {
+	db *gorm.DB
+}
+
+func NewRfqRepository(db *gorm.DB) RfqRepository {
+	return &rfqRepository{db: db}
+}
+
+func (r *rfqRepository) WithTransaction(tx *gorm.DB) RfqRepository {
+	return NewRfqRepository(tx)
+}
+
+func (r *rfqRepository) Create(rfq *models.Rfq) error {
+	if err := r.db.Create(rfq).Error; err != nil {
+		mlog.Log("Failed to create rfq: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *rfqRepository) FindByUuid(uuid string) (*models.Rfq, error) {
+	return r.find(r.db, "uuid = ?", uuid)
+}
+
+// FindByUuidForUpdate serializes the acceptance of quotes of the same rfq.
+func (r *rfqRepository) FindByUuidForUpdate(uuid string) (*models.Rfq, error) {
+	return r.find(r.db.Clauses(clause.Locking{Strength: "UPDATE"}), "uuid = ?", uuid)
+}
+
+func (r *rfqRepository) FindById(id uint) (*models.Rfq, error) {
+	return r.find(r.db, "id = ?", id)
+}
+
+func (r *rfqRepository) find(db *gorm.DB, query string, arg any) (*models.Rfq, error) {
+	var rfq models.Rfq
+	err := db.
+		Preload("Submarket").
+		Preload("EnergyType").
+		Preload("Buyer").
+		Preload("Purchase").
+		Where(query, arg).
+		First(&rfq).Error
+
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			mlog.Log("Failed to find rfq: " + err.Error())
+		}
+		return nil, err
+	}
+
+	return &rfq, nil
+}
+
+// ListOpen leaves out the rfqs of the user's own company, it can't quote
+// them.
+func (r *rfqRepository) ListOpen(request *requests.ListRfqs, user *models.User) (*utils.PaginationWrapper[*models.Rfq], error) {
+	var rfqs []*models.Rfq
+
+	result := r.db.
+		Preload("Submarket").
+		Preload("EnergyType").
+		Preload("Buyer").
+		InnerJoins("Submarket").
+		InnerJoins("EnergyType").
+		Where("rfqs.buyer_id NOT IN (?)", r.db.Model(&models.User{}).Select("id").Where("agent_id = ?", user.AgentId)).
+		Where("rfqs.status = ?", models.RfqStatusOpen)
+
+	if request.Submarket != "" {
+		result = result.Where("\"Submarket\".name = ?", request.Submarket)
+	}
+
+	if request.EnergyType != "" {
+		result = result.Where("\"EnergyType\".type = ?", request.EnergyType)
+	}
+
+	err := result.
+		Order("rfqs.created_at DESC").
+		Scopes(scopes.Paginate(r.db, request.Page, request.PageSize)).
+		Find(&rfqs).Error
+	if err != nil {
+		mlog.Log("Failed to list open rfqs: " + err.Error())
+		return nil, err
+	}
+
+	return utils.NewPaginationWrapper(request.Page, request.PageSize, rfqs), nil
+}
+
+func (r *rfqRepository) ListFromAgent(agentId uint, request *requests.ListOwnRfqs) (*utils.PaginationWrapper[*models.Rfq], error) {
+	var rfqs []*models.Rfq
+
+	result := r.db.
+		Preload("Submarket").
+		Preload("EnergyType").
+		Preload("Buyer").
+		Preload("Purchase").
+		Joins("JOIN users buyers ON buyers.id = rfqs.buyer_id").
+		Where("buyers.agent_id = ?", agentId)
+
+	if request.Status != "" {
+		result = result.Where("rfqs.status = ?", request.Status)
+	}
+
+	err := result.
+		Order("rfqs.created_at DESC").
+		Scopes(scopes.Paginate(r.db, request.Page, request.PageSize)).
+		Find(&rfqs).Error
+	if err != nil {
+		mlog.Log("Failed to list rfqs from agent: " + err.Error())
+		return nil, err
+	}
+
+	return utils.NewPaginationWrapper(request.Page, request.PageSize, rfqs), nil
+}
+
+func (r *rfqRepository) Update(rfq *models.Rfq) error {
+	err := r.db.Model(rfq).
+		Select("status", "purchase_id").
+		Updates(map[string]any{"status": rfq.Status, "purchase_id": rfq.PurchaseId}).Error
+	if err != nil {
+		mlog.Log("Failed to update rfq: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *rfqRepository) CancelOpenFromBuyer(buyerId uint) error {
+	err := r.db.Model(&models.Rfq{}).
+		Where("buyer_id = ? AND status = ?", buyerId, models.RfqStatusOpen).
+		Update("status", models.RfqStatusCancelled).Error
+	if err != nil {
+		mlog.Log("Failed to cancel rfqs from buyer: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *rfqRepository) UpdateExpiredRfqs() error {
+	return r.db.Model(&models.Rfq{}).
+		Where("period_end < ? AND status = ?", utils.NowInLocal(), models.RfqStatusOpen).
+		Update("status", models.RfqStatusExpired).Error
+}
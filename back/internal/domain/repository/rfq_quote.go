@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"ecoply/internal/domain/models"
+	"ecoply/internal/mlog"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type RfqQuoteRepository interface {
+	WithTransaction(tx *gorm.DB) RfqQuoteRepository
+
+	Create(quote *models.RfqQuote) error
+	FindByUuid(rfqId uint, uuid string) (*models.RfqQuote, error)
+	FindByOfferId(offerId uint) (*models.RfqQuote, error)
+	ListFromRfq(rfqId uint) ([]*models.RfqQuote, error)
+	ListFromRfqAndAgent(rfqId uint, agentId uint) ([]*models.RfqQuote, error)
+	HasPendingFromAgent(rfqId uint, agentId uint) (bool, error)
+	Update(quote *models.RfqQuote) error
+	RejectPendingFromRfq(rfqId uint) error
+}
+
+type rfqQuoteRepository struct {
+	db *gorm.DB
+}
+
+func NewRfqQuoteRepository(db *gorm.DB) RfqQuoteRepository {
+	return &rfqQuoteRepository{db: db}
+}
+
+func (r *rfqQuoteRepository) WithTransaction(tx *gorm.DB) RfqQuoteRepository {
+	return NewRfqQuoteRepository(tx)
+}
+
+func (r *rfqQuoteRepository) Create(quote *models.RfqQuote) error {
+	if err := r.db.Create(quote).Error; err != nil {
+		mlog.Log("Failed to create rfq quote: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *rfqQuoteRepository) FindByUuid(rfqId uint, uuid string) (*models.RfqQuote, error) {
+	var quote models.RfqQuote
+	err := r.db.
+		Preload("Supplier").
+		Where("rfq_id = ? AND uuid = ?", rfqId, uuid).
+		First(&quote).Error
+
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			mlog.Log("Failed to find rfq quote by uuid: " + err.Error())
+		}
+		return nil, err
+	}
+
+	return &quote, nil
+}
+
+func (r *rfqQuoteRepository) FindByOfferId(offerId uint) (*models.RfqQuote, error) {
+	var quote models.RfqQuote
+	err := r.db.Where("offer_id = ?", offerId).First(&quote).Error
+
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			mlog.Log("Failed to find rfq quote by offer: " + err.Error())
+		}
+		return nil, err
+	}
+
+	return &quote, nil
+}
+
+func (r *rfqQuoteRepository) ListFromRfq(rfqId uint) ([]*models.RfqQuote, error) {
+	var quotes []*models.RfqQuote
+	err := r.db.
+		Preload("Supplier").
+		Where("rfq_id = ?", rfqId).
+		Order("price_per_mwh ASC, created_at ASC").
+		Find(&quotes).Error
+
+	if err != nil {
+		mlog.Log("Failed to list rfq quotes: " + err.Error())
+		return nil, err
+	}
+
+	return quotes, nil
+}
+
+func (r *rfqQuoteRepository) ListFromRfqAndAgent(rfqId uint, agentId uint) ([]*models.RfqQuote, error) {
+	var quotes []*models.RfqQuote
+	err := r.db.
+		Preload("Supplier").
+		Joins("JOIN users suppliers ON suppliers.id = rfq_quotes.supplier_id").
+		Where("rfq_quotes.rfq_id = ? AND suppliers.agent_id = ?", rfqId, agentId).
+		Order("rfq_quotes.created_at DESC").
+		Find(&quotes).Error
+
+	if err != nil {
+		mlog.Log("Failed to list rfq quotes from agent: " + err.Error())
+		return nil, err
+	}
+
+	return quotes, nil
+}
+
+func (r *rfqQuoteRepository) HasPendingFromAgent(rfqId uint, agentId uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.RfqQuote{}).
+		Joins("JOIN users suppliers ON suppliers.id = rfq_quotes.supplier_id").
+		Where("rfq_quotes.rfq_id = ? AND rfq_quotes.status = ? AND suppliers.agent_id = ?", rfqId, models.RfqQuoteStatusPending, agentId).
+		Count(&count).Error
+
+	if err != nil {
+		mlog.Log("Failed to count pending rfq quotes: " + err.Error())
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *rfqQuoteRepository) Update(quote *models.RfqQuote) error {
+	err := r.db.Model(quote).
+		Select("status", "offer_id").
+		Updates(map[string]any{"status": quote.Status, "offer_id": quote.OfferId}).Error
+	if err != nil {
+		mlog.Log("Failed to update rfq quote: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *rfqQuoteRepository) RejectPendingFromRfq(rfqId uint) error {
+	err := r.db.Model(&models.RfqQuote{}).
+		Where("rfq_id = ? AND status = ?", rfqId, models.RfqQuoteStatusPending).
+		Update("status", models.RfqQuoteStatusRejected).Error
+	if err != nil {
+		mlog.Log("Failed to reject pending rfq quotes: " + err.Error())
+		return err
+	}
+	return nil
+}
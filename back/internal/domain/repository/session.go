@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/utils"
+	"ecoply/internal/mlog"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type SessionRepository interface {
+	WithTransaction(tx *gorm.DB) SessionRepository
+
+	Create(session *models.Session) error
+	FindByUuid(uuid string) (*models.Session, error)
+	ListActiveFromUser(userId uint) ([]*models.Session, error)
+	Touch(session *models.Session) error
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) WithTransaction(tx *gorm.DB) SessionRepository {
+	return NewSessionRepository(tx)
+}
+
+func (r *sessionRepository) Create(session *models.Session) error {
+	if err := r.db.Create(session).Error; err != nil {
+		mlog.Log("Failed to create session: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *sessionRepository) FindByUuid(uuid string) (*models.Session, error) {
+	var session models.Session
+	err := r.db.Where("uuid = ?", uuid).First(&session).Error
+
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			mlog.Log("Failed to find session by uuid: " + err.Error())
+		}
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (r *sessionRepository) ListActiveFromUser(userId uint) ([]*models.Session, error) {
+	var sessions []*models.Session
+	err := r.db.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userId, utils.NowInLocal()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+
+	if err != nil {
+		mlog.Log("Failed to list sessions: " + err.Error())
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// Touch only writes the activity columns, so it can't undo a revocation made
+// in the meantime.
+func (r *sessionRepository) Touch(session *models.Session) error {
+	err := r.db.Model(session).Updates(map[string]any{
+		"ip":           session.Ip,
+		"user_agent":   session.UserAgent,
+		"last_seen_at": session.LastSeenAt,
+		"expires_at":   session.ExpiresAt,
+	}).Error
+
+	if err != nil {
+		mlog.Log("Failed to update session activity: " + err.Error())
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/utils"
+	"ecoply/internal/mlog"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TwoFactorRepository interface {
+	WithTransaction(tx *gorm.DB) TwoFactorRepository
+
+	FindByUserId(userId uint) (*models.TwoFactor, error)
+	FindByUserIdForUpdate(userId uint) (*models.TwoFactor, error)
+	Save(twoFactor *models.TwoFactor) error
+	Delete(twoFactor *models.TwoFactor) error
+	ReplaceRecoveryCodes(twoFactor *models.TwoFactor, codeHashes []string) error
+	UseRecoveryCode(twoFactor *models.TwoFactor, codeHash string) (bool, error)
+}
+
+type twoFactorRepository struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorRepository(db *gorm.DB) TwoFactorRepository {
+	return &twoFactorRepository{db: db}
+}
+
+func (r *twoFactorRepository) WithTransaction(tx *gorm.DB) TwoFactorRepository {
+	return NewTwoFactorRepository(tx)
+}
+
+func (r *twoFactorRepository) FindByUserId(userId uint) (*models.TwoFactor, error) {
+	var twoFactor models.TwoFactor
+	err := r.db.Where("user_id = ?", userId).First(&twoFactor).Error
+
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			mlog.Log("Failed to find two factor by user id: " + err.Error())
+		}
+		return nil, err
+	}
+
+	return &twoFactor, nil
+}
+
+func (r *twoFactorRepository) FindByUserIdForUpdate(userId uint) (*models.TwoFactor, error) {
+	var twoFactor models.TwoFactor
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userId).
+		First(&twoFactor).Error
+
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			mlog.Log("Failed to find two factor by user id: " + err.Error())
+		}
+		return nil, err
+	}
+
+	return &twoFactor, nil
+}
+
+func (r *twoFactorRepository) Save(twoFactor *models.TwoFactor) error {
+	if err := r.db.Omit("RecoveryCodes").Save(twoFactor).Error; err != nil {
+		mlog.Log("Failed to save two factor: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *twoFactorRepository) Delete(twoFactor *models.TwoFactor) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("two_factor_id = ?", twoFactor.ID).Delete(&models.TwoFactorRecoveryCode{}).Error; err != nil {
+			mlog.Log("Failed to delete two factor recovery codes: " + err.Error())
+			return err
+		}
+
+		if err := tx.Unscoped().Delete(twoFactor).Error; err != nil {
+			mlog.Log("Failed to delete two factor: " + err.Error())
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (r *twoFactorRepository) ReplaceRecoveryCodes(twoFactor *models.TwoFactor, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("two_factor_id = ?", twoFactor.ID).Delete(&models.TwoFactorRecoveryCode{}).Error; err != nil {
+			mlog.Log("Failed to delete two factor recovery codes: " + err.Error())
+			return err
+		}
+
+		var codes []models.TwoFactorRecoveryCode = make([]models.TwoFactorRecoveryCode, 0, len(codeHashes))
+		for _, hash := range codeHashes {
+			codes = append(codes, models.TwoFactorRecoveryCode{CodeHash: hash, TwoFactorId: twoFactor.ID})
+		}
+
+		if err := tx.Create(&codes).Error; err != nil {
+			mlog.Log("Failed to create two factor recovery codes: " + err.Error())
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (r *twoFactorRepository) UseRecoveryCode(twoFactor *models.TwoFactor, codeHash string) (bool, error) {
+	result := r.db.Model(&models.TwoFactorRecoveryCode{}).
+		Where("two_factor_id = ? AND code_hash = ? AND used_at IS NULL", twoFactor.ID, codeHash).
+		Update("used_at", utils.NowInLocal())
+
+	if result.Error != nil {
+		mlog.Log("Failed to use two factor recovery code: " + result.Error.Error())
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
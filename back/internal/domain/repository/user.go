@@ -2,6 +2,8 @@ package repository
 
 import (
 	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/scopes"
+	"ecoply/internal/domain/utils"
 	"ecoply/internal/mlog"
 	"errors"
 
@@ -15,6 +17,17 @@ type UserRepository interface {
 	FindByEmail(email string) (*models.User, error)
 	FindById(id uint) (*models.User, error)
 	FindByUuid(uuid string) (*models.User, error)
+	UpdatePassword(user *models.User, password string) error
+	MarkEmailVerified(user *models.User) error
+	ListByAgentId(agentId uint) ([]*models.User, error)
+	CountByAgentIdAndRole(agentId uint, role string) (int64, error)
+	UpdateRole(user *models.User, role string) error
+	UpdateName(user *models.User, name string) error
+	UpdateEmail(user *models.User, email string) error
+	Suspend(user *models.User, reason string) error
+	Anonymize(user *models.User, email string, password string) error
+	Unsuspend(user *models.User) error
+	Search(search string, page int, pageSize int) (*utils.PaginationWrapper[*models.User], error)
 	PreloadUserType(user *models.User) error
 	PreloadAddress(user *models.User) error
 }
@@ -36,6 +49,7 @@ type UserCreateParams struct {
 	Name     string
 	Email    string
 	Password string
+	Role     string
 	UserType *models.UserType
 	Agent    *models.Agent
 }
@@ -46,6 +60,7 @@ func (e *userRepository) Create(params UserCreateParams) (*models.User, error) {
 		Name:       params.Name,
 		Email:      params.Email,
 		Password:   params.Password,
+		Role:       params.Role,
 		UserTypeId: params.UserType.ID,
 		AgentId:    params.Agent.ID,
 	}
@@ -100,6 +115,169 @@ func (e *userRepository) FindByUuid(uuid string) (*models.User, error) {
 	return &user, nil
 }
 
+func (e *userRepository) UpdatePassword(user *models.User, password string) error {
+	if err := e.db.Model(user).Update("password", password).Error; err != nil {
+		mlog.Log("Failed to update user password: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (e *userRepository) MarkEmailVerified(user *models.User) error {
+	var now = utils.NowInLocal()
+
+	if err := e.db.Model(user).Update("email_verified_at", now).Error; err != nil {
+		mlog.Log("Failed to mark user email as verified: " + err.Error())
+		return err
+	}
+
+	user.EmailVerifiedAt = &now
+	return nil
+}
+
+func (e *userRepository) ListByAgentId(agentId uint) ([]*models.User, error) {
+	var users []*models.User
+	err := e.db.Where("agent_id = ? AND anonymized_at IS NULL", agentId).Order("created_at ASC").Find(&users).Error
+
+	if err != nil {
+		mlog.Log("Failed to list users by agent ID: " + err.Error())
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (e *userRepository) CountByAgentIdAndRole(agentId uint, role string) (int64, error) {
+	var count int64
+	err := e.db.Model(&models.User{}).Where("agent_id = ? AND role = ? AND anonymized_at IS NULL", agentId, role).Count(&count).Error
+
+	if err != nil {
+		mlog.Log("Failed to count users by agent ID and role: " + err.Error())
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (e *userRepository) UpdateRole(user *models.User, role string) error {
+	if err := e.db.Model(user).Update("role", role).Error; err != nil {
+		mlog.Log("Failed to update user role: " + err.Error())
+		return err
+	}
+
+	user.Role = role
+	return nil
+}
+
+func (e *userRepository) UpdateName(user *models.User, name string) error {
+	if err := e.db.Model(user).Update("name", name).Error; err != nil {
+		mlog.Log("Failed to update user name: " + err.Error())
+		return err
+	}
+
+	user.Name = name
+	return nil
+}
+
+// UpdateEmail also marks the new email as not verified.
+func (e *userRepository) UpdateEmail(user *models.User, email string) error {
+	err := e.db.Model(user).Updates(map[string]any{
+		"email":             email,
+		"email_verified_at": nil,
+	}).Error
+	if err != nil {
+		mlog.Log("Failed to update user email: " + err.Error())
+		return err
+	}
+
+	user.Email = email
+	user.EmailVerifiedAt = nil
+	return nil
+}
+
+func (e *userRepository) Anonymize(user *models.User, email string, password string) error {
+	var now = utils.NowInLocal()
+
+	err := e.db.Model(user).Updates(map[string]any{
+		"name":              models.AnonymizedUserName,
+		"email":             email,
+		"password":          password,
+		"email_verified_at": nil,
+		"anonymized_at":     now,
+	}).Error
+	if err != nil {
+		mlog.Log("Failed to anonymize user: " + err.Error())
+		return err
+	}
+
+	user.Name = models.AnonymizedUserName
+	user.Email = email
+	user.Password = password
+	user.EmailVerifiedAt = nil
+	user.AnonymizedAt = &now
+	return nil
+}
+
+func (e *userRepository) Suspend(user *models.User, reason string) error {
+	var now = utils.NowInLocal()
+
+	err := e.db.Model(user).Updates(map[string]any{
+		"suspended_at":      now,
+		"suspension_reason": reason,
+	}).Error
+	if err != nil {
+		mlog.Log("Failed to suspend user: " + err.Error())
+		return err
+	}
+
+	user.SuspendedAt = &now
+	user.SuspensionReason = reason
+	return nil
+}
+
+func (e *userRepository) Unsuspend(user *models.User) error {
+	err := e.db.Model(user).Updates(map[string]any{
+		"suspended_at":      nil,
+		"suspension_reason": "",
+	}).Error
+	if err != nil {
+		mlog.Log("Failed to unsuspend user: " + err.Error())
+		return err
+	}
+
+	user.SuspendedAt = nil
+	user.SuspensionReason = ""
+	return nil
+}
+
+// Search matches name, email, CNPJ or company name, an empty search lists
+// every user.
+func (e *userRepository) Search(search string, page int, pageSize int) (*utils.PaginationWrapper[*models.User], error) {
+	var users []*models.User
+
+	result := e.db.
+		Preload("UserType").
+		Preload("Agent").
+		InnerJoins("Agent")
+
+	if search != "" {
+		var like string = "%" + search + "%"
+		result = result.Where(
+			"users.name ILIKE ? OR users.email ILIKE ? OR \"Agent\".cnpj LIKE ? OR \"Agent\".company_name ILIKE ?",
+			like, like, like, like,
+		)
+	}
+
+	result = result.Order("users.created_at DESC").Scopes(scopes.Paginate(e.db, page, pageSize))
+
+	if err := result.Find(&users).Error; err != nil {
+		mlog.Log("Failed to search users: " + err.Error())
+		return nil, err
+	}
+
+	return utils.NewPaginationWrapper(page, pageSize, users), nil
+}
+
 func (e *userRepository) PreloadUserType(user *models.User) error {
 	if err := e.db.Preload("UserType").First(user).Error; err != nil {
 		mlog.Log("Failed to preload user type: " + err.Error())
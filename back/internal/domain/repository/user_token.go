@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/utils"
+	"ecoply/internal/mlog"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserTokenRepository interface {
+	WithTransaction(tx *gorm.DB) UserTokenRepository
+
+	Create(token *models.UserToken) error
+	FindByHashForUpdate(tokenHash string, purpose string) (*models.UserToken, error)
+	MarkUsed(token *models.UserToken) error
+	InvalidateFromUser(userId uint, purpose string) error
+	DeleteFromUser(userId uint) error
+}
+
+type userTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewUserTokenRepository(db *gorm.DB) UserTokenRepository {
+	return &userTokenRepository{db: db}
+}
+
+func (r *userTokenRepository) WithTransaction(tx *gorm.DB) UserTokenRepository {
+	return NewUserTokenRepository(tx)
+}
+
+func (r *userTokenRepository) Create(token *models.UserToken) error {
+	if err := r.db.Create(token).Error; err != nil {
+		mlog.Log("Failed to create user token: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *userTokenRepository) FindByHashForUpdate(tokenHash string, purpose string) (*models.UserToken, error) {
+	var token models.UserToken
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ? AND purpose = ?", tokenHash, purpose).
+		First(&token).Error
+
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			mlog.Log("Failed to find user token by hash: " + err.Error())
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (r *userTokenRepository) MarkUsed(token *models.UserToken) error {
+	var now = utils.NowInLocal()
+
+	if err := r.db.Model(token).Update("used_at", now).Error; err != nil {
+		mlog.Log("Failed to mark user token as used: " + err.Error())
+		return err
+	}
+
+	token.UsedAt = &now
+	return nil
+}
+
+// InvalidateFromUser marks every pending token of the purpose as used, so
+// only the most recently sent link works.
+func (r *userTokenRepository) InvalidateFromUser(userId uint, purpose string) error {
+	err := r.db.Model(&models.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userId, purpose).
+		Update("used_at", utils.NowInLocal()).Error
+
+	if err != nil {
+		mlog.Log("Failed to invalidate user tokens: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *userTokenRepository) DeleteFromUser(userId uint) error {
+	if err := r.db.Where("user_id = ?", userId).Delete(&models.UserToken{}).Error; err != nil {
+		mlog.Log("Failed to delete user tokens: " + err.Error())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package requests
+
+type AdminSearch struct {
+	Page     int    `form:"page" binding:"required,min=1"`
+	PageSize int    `form:"page_size" binding:"required,min=1,max=100"`
+	Search   string `form:"search" binding:"omitempty,max=255"`
+}
+
+type AdminListActions struct {
+	Page       int    `form:"page" binding:"required,min=1"`
+	PageSize   int    `form:"page_size" binding:"required,min=1,max=100"`
+	Action     string `form:"action" binding:"omitempty"`
+	AdminUuid  string `form:"admin_uuid" binding:"omitempty,uuid"`
+	TargetUuid string `form:"target_uuid" binding:"omitempty"`
+}
+
+type AdminReason struct {
+	Reason string `json:"reason" binding:"required,min=3,max=1000"`
+}
+
+type AdminListAuditEvents struct {
+	Page       int    `form:"page" binding:"required,min=1"`
+	PageSize   int    `form:"page_size" binding:"required,min=1,max=100"`
+	Event      string `form:"event" binding:"omitempty"`
+	ActorUuid  string `form:"actor_uuid" binding:"omitempty,uuid"`
+	TargetType string `form:"target_type" binding:"omitempty"`
+	TargetUuid string `form:"target_uuid" binding:"omitempty"`
+	Ip         string `form:"ip" binding:"omitempty,ip"`
+	From       string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To         string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+}
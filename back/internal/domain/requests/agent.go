@@ -0,0 +1,10 @@
+package requests
+
+type InviteMember struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required,oneof=owner trader viewer finance"`
+}
+
+type UpdateMemberRole struct {
+	Role string `json:"role" binding:"required,oneof=owner trader viewer finance"`
+}
@@ -0,0 +1,7 @@
+package requests
+
+type CreateApiKey struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1,dive,oneof=offers:read offers:write purchases:read purchases:write sales:read"`
+	ExpiresInDays *int     `json:"expires_in_days" binding:"omitempty,min=1,max=365"`
+}
@@ -0,0 +1,13 @@
+package requests
+
+// ClientInfo identifies where a request came from, it is recorded along
+// with audit events.
+type ClientInfo struct {
+	Ip        string
+	UserAgent string
+}
+
+type ListActivity struct {
+	Page     int `form:"page" binding:"required,min=1"`
+	PageSize int `form:"page_size" binding:"required,min=1,max=100"`
+}
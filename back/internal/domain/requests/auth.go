@@ -37,3 +37,56 @@ type Availability struct {
 	Type  string `form:"type" json:"type" binding:"required,oneof=email cnpj ccee"`
 	Value string `form:"value" json:"value" binding:"required"`
 }
+
+type RefreshToken struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type ForgotPassword struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPassword struct {
+	Token           string `json:"token" binding:"required"`
+	Password        string `json:"password" binding:"required,min=8,max=50,containsuppercase,containslowercase,containsdigit,containsspecial"`
+	ConfirmPassword string `json:"confirm_password" binding:"required,eqfield=Password"`
+}
+
+type VerifyEmail struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type LoginTwoFactor struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+type TwoFactorCode struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type AcceptInvitation struct {
+	Token           string `json:"token" binding:"required"`
+	Name            string `json:"name" binding:"required,min=2,max=100"`
+	Password        string `json:"password" binding:"required,min=8,max=50,containsuppercase,containslowercase,containsdigit,containsspecial"`
+	ConfirmPassword string `json:"confirm_password" binding:"required,eqfield=Password"`
+}
+
+// UpdateProfile only changes the fields present in the payload. Changing the
+// email requires the current password.
+type UpdateProfile struct {
+	Name            *string `json:"name" binding:"omitempty,min=2,max=100"`
+	Email           *string `json:"email" binding:"omitempty,email"`
+	CompanyName     *string `json:"company_name" binding:"omitempty,min=2,max=255"`
+	CurrentPassword string  `json:"current_password"`
+}
+
+type ChangePassword struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	Password        string `json:"password" binding:"required,min=8,max=50,containsuppercase,containslowercase,containsdigit,containsspecial"`
+	ConfirmPassword string `json:"confirm_password" binding:"required,eqfield=Password"`
+}
+
+type DeleteAccount struct {
+	Password string `json:"password" binding:"required"`
+}
@@ -0,0 +1,24 @@
+package requests
+
+type PlaceBid struct {
+	LimitPricePerMwh float64 `json:"limit_price_per_mwh" binding:"required,gt=0"`
+	QuantityMwh      float64 `json:"quantity_mwh" binding:"required,gt=0"`
+	PeriodStart      string  `json:"period_start" binding:"required"`
+	PeriodEnd        string  `json:"period_end" binding:"required"`
+	EnergyType       string  `json:"energy_type" binding:"required"`
+	Submarket        string  `json:"submarket" binding:"required,oneof=SE_CO S NE N"`
+	PaymentMethod    string  `json:"payment_method" binding:"required,oneof=pix card billet"`
+}
+
+type ListBids struct {
+	Page     int    `form:"page" binding:"required,min=1"`
+	PageSize int    `form:"page_size" binding:"required,min=1,max=100"`
+	Status   string `form:"status" binding:"omitempty,oneof=open filled cancelled expired"`
+}
+
+type OrderBookDepth struct {
+	Submarket   string `form:"submarket" binding:"required,oneof=SE_CO S NE N"`
+	EnergyType  string `form:"energy_type" binding:"required"`
+	PeriodStart string `form:"period_start" binding:"required"`
+	PeriodEnd   string `form:"period_end" binding:"required"`
+}
@@ -0,0 +1,18 @@
+package requests
+
+import "time"
+
+// NegotiationProposal expires after two days unless expires_at is given.
+type NegotiationProposal struct {
+	PricePerMwh float64    `json:"price_per_mwh" binding:"required,gt=0"`
+	QuantityMwh float64    `json:"quantity_mwh" binding:"required,gt=0"`
+	PeriodStart string     `json:"period_start" binding:"required"`
+	PeriodEnd   string     `json:"period_end" binding:"required"`
+	Message     string     `json:"message" binding:"omitempty,max=1000"`
+	ExpiresAt   *time.Time `json:"expires_at" binding:"omitempty"`
+}
+
+type OpenNegotiation struct {
+	NegotiationProposal
+	PaymentMethod string `json:"payment_method" binding:"required,oneof=pix card billet"`
+}
@@ -1,5 +1,7 @@
 package requests
 
+import "time"
+
 type CreateOffer struct {
 	PricePerMwh float64 `json:"price_per_mwh" binding:"required"`
 	QuantityMwh float64 `json:"quantity_mwh" binding:"required"`
@@ -7,6 +9,32 @@ type CreateOffer struct {
 	PeriodEnd   string  `json:"period_end" binding:"required"`
 	Description string  `json:"description" binding:"required"`
 	EnergyType  string  `json:"energy_type" binding:"required"`
+
+	// Lot size of purchases, a remainder below the minimum closes the offer
+	MinQuantityMwh  *float64 `json:"min_quantity_mwh" binding:"omitempty,gt=0"`
+	QuantityStepMwh *float64 `json:"quantity_step_mwh" binding:"omitempty,gt=0"`
+
+	// Monthly volumes or an hourly shape, summing to quantity_mwh
+	DeliveryProfile string          `json:"delivery_profile" binding:"omitempty,oneof=flat monthly hourly"`
+	DeliveryBlocks  []DeliveryBlock `json:"delivery_blocks" binding:"omitempty,max=120,dive"`
+
+	// Keeps the offer hidden until then
+	PublishAt *time.Time `json:"publish_at" binding:"omitempty"`
+
+	// Restricts the offer to these agents, by CNPJ or CCEE code, and to the
+	// buyers of these submarkets
+	AllowedAgents     []string `json:"allowed_agents" binding:"omitempty,max=100,dive,required"`
+	AllowedSubmarkets []string `json:"allowed_submarkets" binding:"omitempty,dive,oneof=SE_CO S NE N"`
+
+	// Volume discounts, each tier priced below the previous one
+	PriceTiers []OfferPriceTier `json:"price_tiers" binding:"omitempty,max=10,dive"`
+
+	// Set to sell the offer by auction, price_per_mwh is then the opening price
+	AuctionType        string     `json:"auction_type" binding:"omitempty,oneof=english dutch sealed"`
+	AuctionStartsAt    *time.Time `json:"auction_starts_at" binding:"required_with=AuctionType"`
+	AuctionEndsAt      *time.Time `json:"auction_ends_at" binding:"required_with=AuctionType"`
+	ReservePricePerMwh *float64   `json:"reserve_price_per_mwh" binding:"omitempty,gt=0"`
+	BidIncrementPerMwh *float64   `json:"bid_increment_per_mwh" binding:"omitempty,gt=0"`
 }
 
 type ListOffers struct {
@@ -25,4 +53,58 @@ type UpdateOffer struct {
 	PeriodEnd   string  `json:"period_end" binding:"required"`
 	Description string  `json:"description" binding:"required"`
 	EnergyType  string  `json:"energy_type" binding:"required"`
+
+	// Lot size of purchases, left out they are kept and zero removes them
+	MinQuantityMwh  *float64 `json:"min_quantity_mwh" binding:"omitempty,gte=0"`
+	QuantityStepMwh *float64 `json:"quantity_step_mwh" binding:"omitempty,gte=0"`
+
+	// Replace the delivery profile when given, left out they are kept and
+	// must still sum to quantity_mwh
+	DeliveryProfile *string          `json:"delivery_profile" binding:"omitempty,oneof=flat monthly hourly"`
+	DeliveryBlocks  *[]DeliveryBlock `json:"delivery_blocks" binding:"omitempty,max=120,dive"`
+
+	// Reschedules an offer that wasn't published yet
+	PublishAt *time.Time `json:"publish_at" binding:"omitempty"`
+
+	// Replace the audience when given, left out they are kept. Both empty
+	// make the offer public.
+	AllowedAgents     *[]string `json:"allowed_agents" binding:"omitempty,max=100,dive,required"`
+	AllowedSubmarkets *[]string `json:"allowed_submarkets" binding:"omitempty,dive,oneof=SE_CO S NE N"`
+
+	// Replace the volume discounts when given, left out they are kept
+	PriceTiers *[]OfferPriceTier `json:"price_tiers" binding:"omitempty,max=10,dive"`
+}
+
+type OfferPriceTier struct {
+	MinQuantityMwh float64 `json:"min_quantity_mwh" binding:"required,gt=0"`
+	PricePerMwh    float64 `json:"price_per_mwh" binding:"required,gt=0"`
+}
+
+// DeliveryBlock slots are months as "2006-01" or hours of the day from "00"
+// to "23".
+type DeliveryBlock struct {
+	Slot        string  `json:"slot" binding:"required"`
+	QuantityMwh float64 `json:"quantity_mwh" binding:"required,gt=0"`
+}
+
+// ImportOffers takes the CSV or XLSX as the multipart file "file". The
+// format defaults to the extension of the file.
+type ImportOffers struct {
+	DryRun bool   `form:"dry_run"`
+	Format string `form:"format" binding:"omitempty,oneof=csv xlsx"`
+}
+
+type ExportOffers struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv xlsx"`
+}
+
+type QuoteOffer struct {
+	QuantityMwh float64 `form:"quantity_mwh" binding:"required,gt=0"`
+}
+
+// PlaceAuctionBid takes the current price of dutch auctions, price_per_mwh
+// is only read for english and sealed ones.
+type PlaceAuctionBid struct {
+	PricePerMwh   float64 `json:"price_per_mwh" binding:"omitempty,gt=0"`
+	PaymentMethod string  `json:"payment_method" binding:"required,oneof=pix card billet"`
 }
@@ -0,0 +1,23 @@
+package requests
+
+type SaveOfferTemplate struct {
+	Name        string  `json:"name" binding:"required,max=100"`
+	PricePerMwh float64 `json:"price_per_mwh" binding:"required,gt=0"`
+	QuantityMwh float64 `json:"quantity_mwh" binding:"required,gt=0"`
+	Description string  `json:"description" binding:"required"`
+	EnergyType  string  `json:"energy_type" binding:"required"`
+
+	// Publishes an offer every month on this day, covering the whole month
+	// period_offset_months ahead, one by default
+	PublishDay         *int `json:"publish_day" binding:"omitempty,min=1,max=28"`
+	PeriodOffsetMonths int  `json:"period_offset_months" binding:"omitempty,min=1,max=12"`
+}
+
+type PublishOfferTemplate struct {
+	PeriodStart string `json:"period_start" binding:"required"`
+	PeriodEnd   string `json:"period_end" binding:"required"`
+}
+
+type PreviewOfferTemplate struct {
+	Count int `form:"count" binding:"omitempty,min=1,max=12"`
+}
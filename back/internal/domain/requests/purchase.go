@@ -1,8 +1,11 @@
 package requests
 
 type CreatePurchase struct {
-	QuantityMwh   float64 `json:"quantity_mwh" binding:"required,gt=0"`
+	QuantityMwh   float64 `json:"quantity_mwh" binding:"required_without=TakeAll,omitempty,gt=0"`
 	PaymentMethod string  `json:"payment_method" binding:"required,oneof=pix card billet"`
+
+	// Buys whatever is left of the offer, quantity_mwh is then ignored
+	TakeAll bool `json:"take_all"`
 }
 
 type ListPurchase struct {
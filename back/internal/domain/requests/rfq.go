@@ -0,0 +1,33 @@
+package requests
+
+type CreateRfq struct {
+	QuantityMwh    float64 `json:"quantity_mwh" binding:"required,gt=0"`
+	MaxPricePerMwh float64 `json:"max_price_per_mwh" binding:"required,gt=0"`
+	PeriodStart    string  `json:"period_start" binding:"required"`
+	PeriodEnd      string  `json:"period_end" binding:"required"`
+	Description    string  `json:"description" binding:"required"`
+	EnergyType     string  `json:"energy_type" binding:"required"`
+	Submarket      string  `json:"submarket" binding:"required,oneof=SE_CO S NE N"`
+}
+
+type ListRfqs struct {
+	Page       int    `form:"page" binding:"required,min=1"`
+	PageSize   int    `form:"page_size" binding:"required,min=1,max=100"`
+	Submarket  string `form:"submarket" binding:"omitempty"`
+	EnergyType string `form:"energy_type" binding:"omitempty"`
+}
+
+type ListOwnRfqs struct {
+	Page     int    `form:"page" binding:"required,min=1"`
+	PageSize int    `form:"page_size" binding:"required,min=1,max=100"`
+	Status   string `form:"status" binding:"omitempty,oneof=open awarded cancelled expired"`
+}
+
+type CreateRfqQuote struct {
+	PricePerMwh float64 `json:"price_per_mwh" binding:"required,gt=0"`
+	Message     string  `json:"message" binding:"omitempty,max=1000"`
+}
+
+type AcceptRfqQuote struct {
+	PaymentMethod string `json:"payment_method" binding:"required,oneof=pix card billet"`
+}
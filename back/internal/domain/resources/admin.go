@@ -0,0 +1,35 @@
+package resources
+
+type AdminUser struct {
+	Uuid             string  `json:"uuid"`
+	Name             string  `json:"name"`
+	Email            string  `json:"email"`
+	EmailVerified    bool    `json:"email_verified"`
+	UserType         string  `json:"user_type"`
+	Role             string  `json:"role"`
+	AgentCnpj        string  `json:"agent_cnpj"`
+	CompanyName      string  `json:"company_name"`
+	SuspendedAt      *string `json:"suspended_at"`
+	SuspensionReason string  `json:"suspension_reason,omitempty"`
+	CreatedAt        string  `json:"created_at"`
+}
+
+type AdminAgent struct {
+	Cnpj          string `json:"cnpj"`
+	CompanyName   string `json:"company_name"`
+	CceeCode      string `json:"ccee_code"`
+	SubmarketName string `json:"submarket_name"`
+	MembersCount  int64  `json:"members_count"`
+	CreatedAt     string `json:"created_at"`
+}
+
+type AdminAction struct {
+	Uuid       string `json:"uuid"`
+	Action     string `json:"action"`
+	TargetType string `json:"target_type"`
+	TargetUuid string `json:"target_uuid"`
+	Reason     string `json:"reason"`
+	AdminUuid  string `json:"admin_uuid"`
+	AdminName  string `json:"admin_name"`
+	CreatedAt  string `json:"created_at"`
+}
@@ -0,0 +1,18 @@
+package resources
+
+type AgentMember struct {
+	Uuid      string `json:"uuid"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	CreatedAt string `json:"created_at"`
+}
+
+type AgentInvitation struct {
+	Uuid          string `json:"uuid"`
+	Email         string `json:"email"`
+	Role          string `json:"role"`
+	InvitedByName string `json:"invited_by_name"`
+	ExpiresAt     string `json:"expires_at"`
+	CreatedAt     string `json:"created_at"`
+}
@@ -0,0 +1,16 @@
+package resources
+
+type ApiKey struct {
+	Uuid       string   `json:"uuid"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  *string  `json:"expires_at"`
+	LastUsedAt *string  `json:"last_used_at"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+type CreatedApiKey struct {
+	ApiKey
+	Key string `json:"key"`
+}
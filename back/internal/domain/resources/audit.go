@@ -0,0 +1,17 @@
+package resources
+
+import "encoding/json"
+
+type AuditEvent struct {
+	Uuid       string          `json:"uuid"`
+	Event      string          `json:"event"`
+	ActorUuid  *string         `json:"actor_uuid"`
+	ActorName  *string         `json:"actor_name"`
+	Ip         string          `json:"ip"`
+	UserAgent  string          `json:"user_agent"`
+	TargetType string          `json:"target_type"`
+	TargetUuid string          `json:"target_uuid"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	CreatedAt  string          `json:"created_at"`
+}
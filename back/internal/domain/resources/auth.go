@@ -1,11 +1,13 @@
 package resources
 
 type Me struct {
-	Name     string  `json:"name"`
-	Email    string  `json:"email"`
-	UserType string  `json:"user_type"`
-	Address  Address `json:"address"`
-	Agent    Agent   `json:"agent"`
+	Name          string  `json:"name"`
+	Email         string  `json:"email"`
+	EmailVerified bool    `json:"email_verified"`
+	UserType      string  `json:"user_type"`
+	Role          string  `json:"role"`
+	Address       Address `json:"address"`
+	Agent         Agent   `json:"agent"`
 }
 
 type Address struct {
@@ -27,6 +29,34 @@ type Agent struct {
 }
 
 type Login struct {
-	Token string `json:"token"`
-	User  Me     `json:"user"`
+	Token        string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	User         *Me    `json:"user,omitempty"`
+
+	// Set instead of the tokens when the password was right but a TOTP code
+	// is still needed, see /auth/login/2fa.
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
+
+	TwoFactorEnrollmentRequired bool `json:"two_factor_enrollment_required,omitempty"`
+}
+
+type Tokens struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type TwoFactorStatus struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+type TwoFactorEnrollment struct {
+	Secret     string `json:"secret"`
+	OtpauthUri string `json:"otpauth_uri"`
+}
+
+type TwoFactorRecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
 }
@@ -0,0 +1,37 @@
+package resources
+
+type Bid struct {
+	Uuid                 string  `json:"uuid"`
+	LimitPricePerMwh     float64 `json:"limit_price_per_mwh"`
+	QuantityMwh          float64 `json:"quantity_mwh"`
+	RemainingQuantityMwh float64 `json:"remaining_quantity_mwh"`
+	PeriodStart          string  `json:"period_start"`
+	PeriodEnd            string  `json:"period_end"`
+	Status               string  `json:"status"`
+	PaymentMethod        string  `json:"payment_method"`
+	EnergyType           string  `json:"energy_type"`
+	Submarket            string  `json:"submarket"`
+	BuyerUuid            string  `json:"buyer_uuid"`
+	CreatedAt            string  `json:"created_at"`
+}
+
+// PlacedBid lists the purchases the bid was filled with when it was placed.
+type PlacedBid struct {
+	Bid
+	Purchases []*Purchase `json:"purchases"`
+}
+
+type PriceLevel struct {
+	PricePerMwh float64 `json:"price_per_mwh"`
+	QuantityMwh float64 `json:"quantity_mwh"`
+	Orders      int64   `json:"orders"`
+}
+
+type OrderBookDepth struct {
+	Submarket   string        `json:"submarket"`
+	EnergyType  string        `json:"energy_type"`
+	PeriodStart string        `json:"period_start"`
+	PeriodEnd   string        `json:"period_end"`
+	Bids        []*PriceLevel `json:"bids"`
+	Asks        []*PriceLevel `json:"asks"`
+}
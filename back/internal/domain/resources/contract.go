@@ -27,6 +27,10 @@ type ContractOffer struct {
 	EnergyType            string    `json:"energy_type"`
 	Submarket             string    `json:"submarket"`
 	CreatedAt             time.Time `json:"created_at"`
+
+	// The contracted quantity delivered in each slot of the profile
+	DeliveryProfile string          `json:"delivery_profile"`
+	Deliveries      []DeliveryBlock `json:"deliveries"`
 }
 
 type ContractBuyer struct {
@@ -0,0 +1,27 @@
+package resources
+
+type Negotiation struct {
+	Uuid          string                 `json:"uuid"`
+	Status        string                 `json:"status"`
+	PaymentMethod string                 `json:"payment_method"`
+	OfferUuid     string                 `json:"offer_uuid"`
+	BuyerUuid     string                 `json:"buyer_uuid"`
+	BuyerName     string                 `json:"buyer_name"`
+	PurchaseUuid  *string                `json:"purchase_uuid"`
+	Proposals     []*NegotiationProposal `json:"proposals"`
+	CreatedAt     string                 `json:"created_at"`
+}
+
+type NegotiationProposal struct {
+	Uuid        string  `json:"uuid"`
+	PricePerMwh float64 `json:"price_per_mwh"`
+	QuantityMwh float64 `json:"quantity_mwh"`
+	PeriodStart string  `json:"period_start"`
+	PeriodEnd   string  `json:"period_end"`
+	Message     string  `json:"message"`
+	Status      string  `json:"status"`
+	ProposedBy  string  `json:"proposed_by"`
+	AuthorUuid  string  `json:"author_uuid"`
+	ExpiresAt   string  `json:"expires_at"`
+	CreatedAt   string  `json:"created_at"`
+}
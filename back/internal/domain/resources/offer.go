@@ -5,16 +5,82 @@ import (
 )
 
 type Offer struct {
-	Uuid                 string    `json:"uuid"`
-	PricePerMwh          float64   `json:"price_per_mwh"`
-	InitialQuantityMwh   float64   `json:"initial_quantity_mwh"`
-	RemainingQuantityMwh float64   `json:"remaining_quantity_mwh"`
-	Description          string    `json:"description"`
-	PeriodStart          string    `json:"period_start"`
-	PeriodEnd            string    `json:"period_end"`
-	Status               string    `json:"status"`
-	EnergyType           string    `json:"energy_type"`
-	Submarket            string    `json:"submarket"`
-	SellerUuid           string    `json:"seller_agent_uuid"`
-	CreatedAt            time.Time `json:"created_at"`
+	Uuid                 string     `json:"uuid"`
+	PricePerMwh          float64    `json:"price_per_mwh"`
+	InitialQuantityMwh   float64    `json:"initial_quantity_mwh"`
+	RemainingQuantityMwh float64    `json:"remaining_quantity_mwh"`
+	Description          string     `json:"description"`
+	PeriodStart          string     `json:"period_start"`
+	PeriodEnd            string     `json:"period_end"`
+	Status               string     `json:"status"`
+	PublishAt            *time.Time `json:"publish_at"`
+	EnergyType           string     `json:"energy_type"`
+	Submarket            string     `json:"submarket"`
+	SellerUuid           string     `json:"seller_agent_uuid"`
+	CreatedAt            time.Time  `json:"created_at"`
+	Visibility           string     `json:"visibility"`
+	MinQuantityMwh       *float64   `json:"min_quantity_mwh"`
+	QuantityStepMwh      *float64   `json:"quantity_step_mwh"`
+
+	// Only filled for the seller's company
+	AllowedAgents     []string `json:"allowed_agents,omitempty"`
+	AllowedSubmarkets []string `json:"allowed_submarkets,omitempty"`
+
+	PriceTiers []OfferPriceTier `json:"price_tiers"`
+
+	DeliveryProfile string          `json:"delivery_profile"`
+	DeliveryBlocks  []DeliveryBlock `json:"delivery_blocks"`
+
+	Auction *OfferAuction `json:"auction,omitempty"`
+}
+
+type DeliveryBlock struct {
+	Slot        string  `json:"slot"`
+	QuantityMwh float64 `json:"quantity_mwh"`
+}
+
+type OfferPriceTier struct {
+	MinQuantityMwh float64 `json:"min_quantity_mwh"`
+	PricePerMwh    float64 `json:"price_per_mwh"`
+}
+
+// OfferImport reports the rows of an import by their line in the file. On a
+// dry run nothing is created and Offers stays empty.
+type OfferImport struct {
+	DryRun bool               `json:"dry_run"`
+	Rows   int                `json:"rows"`
+	Valid  int                `json:"valid"`
+	Errors []OfferImportError `json:"errors"`
+	Offers []*Offer           `json:"offers"`
+}
+
+type OfferImportError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// OfferQuote is what buying the quantity would cost right now.
+type OfferQuote struct {
+	QuantityMwh float64 `json:"quantity_mwh"`
+	PricePerMwh float64 `json:"price_per_mwh"`
+	TotalPrice  float64 `json:"total_price"`
+}
+
+// OfferAuction leaves the reserve price out, bidders don't get to see it.
+type OfferAuction struct {
+	Type               string   `json:"type"`
+	StartsAt           string   `json:"starts_at"`
+	EndsAt             string   `json:"ends_at"`
+	BidIncrementPerMwh *float64 `json:"bid_increment_per_mwh"`
+	CurrentPricePerMwh *float64 `json:"current_price_per_mwh,omitempty"`
+}
+
+type AuctionBid struct {
+	Uuid          string    `json:"uuid"`
+	PricePerMwh   float64   `json:"price_per_mwh"`
+	PaymentMethod string    `json:"payment_method,omitempty"`
+	Status        string    `json:"status"`
+	BidderUuid    string    `json:"bidder_uuid,omitempty"`
+	CreatedAt     string    `json:"created_at"`
+	Purchase      *Purchase `json:"purchase,omitempty"`
 }
@@ -0,0 +1,25 @@
+package resources
+
+type OfferTemplate struct {
+	Uuid               string  `json:"uuid"`
+	Name               string  `json:"name"`
+	PricePerMwh        float64 `json:"price_per_mwh"`
+	QuantityMwh        float64 `json:"quantity_mwh"`
+	Description        string  `json:"description"`
+	EnergyType         string  `json:"energy_type"`
+	PublishDay         *int    `json:"publish_day"`
+	PeriodOffsetMonths int     `json:"period_offset_months"`
+	Status             string  `json:"status"`
+	NextPublishAt      *string `json:"next_publish_at"`
+	SellerUuid         string  `json:"seller_uuid"`
+	CreatedAt          string  `json:"created_at"`
+}
+
+// OfferTemplateRun is an offer a series will publish.
+type OfferTemplateRun struct {
+	PublishAt   string  `json:"publish_at"`
+	PeriodStart string  `json:"period_start"`
+	PeriodEnd   string  `json:"period_end"`
+	PricePerMwh float64 `json:"price_per_mwh"`
+	QuantityMwh float64 `json:"quantity_mwh"`
+}
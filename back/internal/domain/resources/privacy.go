@@ -0,0 +1,8 @@
+package resources
+
+type ExportProfile struct {
+	Me
+	Uuid             string `json:"uuid"`
+	TwoFactorEnabled bool   `json:"two_factor_enabled"`
+	CreatedAt        string `json:"created_at"`
+}
@@ -0,0 +1,26 @@
+package resources
+
+type Rfq struct {
+	Uuid           string  `json:"uuid"`
+	QuantityMwh    float64 `json:"quantity_mwh"`
+	MaxPricePerMwh float64 `json:"max_price_per_mwh"`
+	Description    string  `json:"description"`
+	PeriodStart    string  `json:"period_start"`
+	PeriodEnd      string  `json:"period_end"`
+	Status         string  `json:"status"`
+	EnergyType     string  `json:"energy_type"`
+	Submarket      string  `json:"submarket"`
+	BuyerUuid      string  `json:"buyer_uuid"`
+	PurchaseUuid   *string `json:"purchase_uuid"`
+	CreatedAt      string  `json:"created_at"`
+}
+
+type RfqQuote struct {
+	Uuid         string  `json:"uuid"`
+	PricePerMwh  float64 `json:"price_per_mwh"`
+	Message      string  `json:"message"`
+	Status       string  `json:"status"`
+	SupplierUuid string  `json:"supplier_uuid"`
+	SupplierName string  `json:"supplier_name"`
+	CreatedAt    string  `json:"created_at"`
+}
@@ -0,0 +1,10 @@
+package resources
+
+type Session struct {
+	Uuid       string `json:"uuid"`
+	Ip         string `json:"ip"`
+	UserAgent  string `json:"user_agent"`
+	Current    bool   `json:"current"`
+	LastSeenAt string `json:"last_seen_at"`
+	CreatedAt  string `json:"created_at"`
+}
@@ -2,6 +2,26 @@ package scopes
 
 import "gorm.io/gorm"
 
+// OfferVisibleToAgent keeps the public offers, the agent's own and the
+// restricted ones it is allowed to see, directly or by its submarket.
+func OfferVisibleToAgent(agentId uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(`(
+			offers.visibility = 'public'
+			OR offers.seller_id IN (SELECT id FROM users WHERE agent_id = @agent)
+			OR EXISTS (
+				SELECT 1 FROM offer_allowed_agents
+				WHERE offer_allowed_agents.offer_id = offers.id AND offer_allowed_agents.agent_id = @agent
+			)
+			OR EXISTS (
+				SELECT 1 FROM offer_allowed_submarkets
+				JOIN agents ON agents.submarket_id = offer_allowed_submarkets.submarket_id
+				WHERE offer_allowed_submarkets.offer_id = offers.id AND agents.id = @agent
+			)
+		)`, map[string]any{"agent": agentId})
+	}
+}
+
 func Paginate(db *gorm.DB, page, limit int) func(*gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
 		return db.Offset((page - 1) * limit).Limit(limit + 1)
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"ecoply/internal/config"
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/repository"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/utils"
+	"ecoply/internal/mail"
+	"ecoply/internal/mlog"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	passwordResetTokenTTL     = time.Hour
+	emailVerificationTokenTTL = 48 * time.Hour
+)
+
+type AccountService interface {
+	ForgotPassword(request *requests.ForgotPassword) *merr.ResponseError
+	ResetPassword(request *requests.ResetPassword) *merr.ResponseError
+	VerifyEmail(request *requests.VerifyEmail) *merr.ResponseError
+	SendEmailVerification(user *models.User) *merr.ResponseError
+}
+
+type accountService struct {
+	userRepo         repository.UserRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	throttleRepo     repository.LoginThrottleRepository
+	hasher           PasswordHasher
+	mailer           mail.Mailer
+	appName          string
+	frontendUrl      string
+	db               *gorm.DB
+}
+
+func NewAccountService(cfg *config.Config, db *gorm.DB) AccountService {
+	return &accountService{
+		userRepo:         repository.NewUserRepository(db),
+		refreshTokenRepo: repository.NewRefreshTokenRepository(db),
+		throttleRepo:     repository.NewLoginThrottleRepository(db),
+		hasher:           NewPasswordHasher(cfg),
+		mailer:           mail.New(cfg),
+		appName:          cfg.AppName,
+		frontendUrl:      cfg.AppFrontendUrl,
+		db:               db,
+	}
+}
+
+// ForgotPassword always succeeds for well formed requests, whether the email
+// exists or not, so it can't be used to discover registered accounts.
+func (s *accountService) ForgotPassword(request *requests.ForgotPassword) *merr.ResponseError {
+	user, err := s.userRepo.FindByEmail(request.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	token, err := createUserToken(s.db, user, models.UserTokenPurposePasswordReset, passwordResetTokenTTL)
+	if err != nil {
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	dispatchEmail(s.mailer, &mail.Message{
+		To:      user.Email,
+		Subject: fmt.Sprintf("%s - password reset", s.appName),
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nUse the link below to choose a new password. It expires in %s.\n\n%s\n\nIf you did not ask for it, ignore this email.\n",
+			user.Name, passwordResetTokenTTL, makeFrontendLink(s.frontendUrl, "/reset-password", token),
+		),
+	})
+
+	return nil
+}
+
+// ResetPassword also revokes every refresh token of the user, logging out
+// whoever may be holding the old credentials, and lifts a login lockout.
+func (s *accountService) ResetPassword(request *requests.ResetPassword) *merr.ResponseError {
+	var responseError *merr.ResponseError
+	var userUuid string
+	var userId uint
+
+	passwordHash, err := s.hasher.Hash(request.Password)
+	if err != nil {
+		mlog.Log("Failed to hash password: " + err.Error())
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		token, err := useUserToken(tx, request.Token, models.UserTokenPurposePasswordReset)
+		if err != nil {
+			if errors.Is(err, ErrInvalidUserToken) {
+				responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidUserToken)
+			}
+			return err
+		}
+
+		var userRepo = s.userRepo.WithTransaction(tx)
+
+		user, err := userRepo.FindById(token.UserId)
+		if err != nil {
+			return err
+		}
+
+		if err = userRepo.UpdatePassword(user, passwordHash); err != nil {
+			return err
+		}
+		userUuid = user.Uuid
+		userId = user.ID
+
+		var identifier string = loginThrottleIdentifier(models.LoginThrottleScopeAccount, user.Email, "")
+		if err = s.throttleRepo.WithTransaction(tx).Reset(models.LoginThrottleScopeAccount, identifier); err != nil {
+			return err
+		}
+
+		return s.refreshTokenRepo.WithTransaction(tx).RevokeAllFromUser(user.ID)
+	})
+
+	if responseError != nil {
+		return responseError
+	}
+
+	if err != nil {
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	forgetCachedUser(userUuid)
+	forgetCachedSessionsFromUser(userId)
+
+	return nil
+}
+
+func (s *accountService) VerifyEmail(request *requests.VerifyEmail) *merr.ResponseError {
+	var responseError *merr.ResponseError
+	var userUuid string
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		token, err := useUserToken(tx, request.Token, models.UserTokenPurposeEmailVerification)
+		if err != nil {
+			if errors.Is(err, ErrInvalidUserToken) {
+				responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidUserToken)
+			}
+			return err
+		}
+
+		var userRepo = s.userRepo.WithTransaction(tx)
+
+		user, err := userRepo.FindById(token.UserId)
+		if err != nil {
+			return err
+		}
+
+		// The link was sent to an address the user no longer uses
+		if user.Email != token.Email {
+			responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidUserToken)
+			return ErrInvalidUserToken
+		}
+
+		userUuid = user.Uuid
+		return userRepo.MarkEmailVerified(user)
+	})
+
+	if responseError != nil {
+		return responseError
+	}
+
+	if err != nil {
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	forgetCachedUser(userUuid)
+
+	return nil
+}
+
+func (s *accountService) SendEmailVerification(user *models.User) *merr.ResponseError {
+	if user.IsEmailVerified() {
+		return merr.NewResponseError(http.StatusUnprocessableEntity, ErrEmailAlreadyVerified)
+	}
+
+	token, err := createUserToken(s.db, user, models.UserTokenPurposeEmailVerification, emailVerificationTokenTTL)
+	if err != nil {
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	dispatchEmail(s.mailer, &mail.Message{
+		To:      user.Email,
+		Subject: fmt.Sprintf("%s - confirm your email", s.appName),
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nConfirm your email address by opening the link below. It expires in %s.\n\n%s\n",
+			user.Name, emailVerificationTokenTTL, makeFrontendLink(s.frontendUrl, "/verify-email", token),
+		),
+	})
+
+	return nil
+}
+
+// createUserToken invalidates any pending token with the same purpose before
+// creating the new one and returns the plain token to be handed to the user.
+func createUserToken(db *gorm.DB, user *models.User, purpose string, ttl time.Duration) (string, error) {
+	token, err := NewOpaqueToken()
+	if err != nil {
+		mlog.Log("Failed to generate user token: " + err.Error())
+		return "", err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var userTokenRepo = repository.NewUserTokenRepository(tx)
+
+		if err := userTokenRepo.InvalidateFromUser(user.ID, purpose); err != nil {
+			return err
+		}
+
+		return userTokenRepo.Create(&models.UserToken{
+			TokenHash: Hash256String(token),
+			Purpose:   purpose,
+			Email:     user.Email,
+			ExpiresAt: utils.NowInLocal().Add(ttl),
+			UserId:    user.ID,
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// useUserToken marks the token as used, failing with ErrInvalidUserToken if it
+// doesn't exist, expired or was already used.
+func useUserToken(tx *gorm.DB, plainToken string, purpose string) (*models.UserToken, error) {
+	var userTokenRepo = repository.NewUserTokenRepository(tx)
+
+	token, err := userTokenRepo.FindByHashForUpdate(Hash256String(plainToken), purpose)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidUserToken
+		}
+		return nil, err
+	}
+
+	if !token.IsUsable() {
+		return nil, ErrInvalidUserToken
+	}
+
+	if err = userTokenRepo.MarkUsed(token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func makeFrontendLink(frontendUrl string, path string, token string) string {
+	return frontendUrl + path + "?token=" + url.QueryEscape(token)
+}
+
+// dispatchEmail sends in background so the response time doesn't depend on
+// the mail server, nor reveal whether an email was sent at all.
+func dispatchEmail(mailer mail.Mailer, message *mail.Message) {
+	go func() {
+		if err := mailer.Send(message); err != nil {
+			mlog.Log(ErrFailedToSendEmail.Error() + ": " + err.Error())
+		}
+	}()
+}
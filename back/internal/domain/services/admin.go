@@ -0,0 +1,469 @@
+package services
+
+import (
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/repository"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/resources"
+	"ecoply/internal/domain/utils"
+	"errors"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AdminService holds the platform administration actions. Every action, and
+// every read of a purchase or contract, is recorded as a models.AdminAction
+// in the same transaction as the change itself.
+type AdminService interface {
+	ListUsers(request *requests.AdminSearch) (*utils.PaginationWrapper[*resources.AdminUser], *merr.ResponseError)
+	ListAgents(request *requests.AdminSearch) (*utils.PaginationWrapper[*resources.AdminAgent], *merr.ResponseError)
+	SuspendUser(admin *models.User, client *requests.ClientInfo, userUuid string, request *requests.AdminReason) *merr.ResponseError
+	UnsuspendUser(admin *models.User, client *requests.ClientInfo, userUuid string, request *requests.AdminReason) *merr.ResponseError
+	UnlockUser(admin *models.User, client *requests.ClientInfo, userUuid string, request *requests.AdminReason) *merr.ResponseError
+	ExpireOffer(admin *models.User, client *requests.ClientInfo, offerUuid string, request *requests.AdminReason) *merr.ResponseError
+	TakeDownOffer(admin *models.User, client *requests.ClientInfo, offerUuid string, request *requests.AdminReason) *merr.ResponseError
+	GetPurchase(admin *models.User, client *requests.ClientInfo, purchaseUuid string) (*resources.Purchase, *merr.ResponseError)
+	GetContract(admin *models.User, client *requests.ClientInfo, purchaseUuid string) (*resources.Contract, *merr.ResponseError)
+	CompletePurchase(admin *models.User, client *requests.ClientInfo, purchaseUuid string, request *requests.AdminReason) *merr.ResponseError
+	CancelPurchase(admin *models.User, client *requests.ClientInfo, purchaseUuid string, request *requests.AdminReason) *merr.ResponseError
+	ListActions(request *requests.AdminListActions) (*utils.PaginationWrapper[*resources.AdminAction], *merr.ResponseError)
+}
+
+type adminService struct {
+	userRepo         repository.UserRepository
+	userTypeRepo     repository.UserTypeRepository
+	agentRepo        repository.AgentRepository
+	offerRepo        repository.OfferRepository
+	purchaseRepo     repository.PurchaseRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	throttleRepo     repository.LoginThrottleRepository
+	adminActionRepo  repository.AdminActionRepository
+	contractService  ContractService
+	db               *gorm.DB
+}
+
+func NewAdminService(db *gorm.DB) AdminService {
+	return &adminService{
+		userRepo:         repository.NewUserRepository(db),
+		userTypeRepo:     repository.NewUserTypeRepository(db),
+		agentRepo:        repository.NewAgentRepository(db),
+		offerRepo:        repository.NewOfferRepository(db),
+		purchaseRepo:     repository.NewPurchaseRepository(db),
+		refreshTokenRepo: repository.NewRefreshTokenRepository(db),
+		throttleRepo:     repository.NewLoginThrottleRepository(db),
+		adminActionRepo:  repository.NewAdminActionRepository(db),
+		contractService:  NewContractService(db),
+		db:               db,
+	}
+}
+
+func (s *adminService) ListUsers(request *requests.AdminSearch) (*utils.PaginationWrapper[*resources.AdminUser], *merr.ResponseError) {
+	list, err := s.userRepo.Search(request.Search, request.Page, request.PageSize)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	var response utils.PaginationWrapper[*resources.AdminUser]
+
+	response.Page = list.Page
+	response.PageSize = list.PageSize
+	response.HasNext = list.HasNext
+	response.HasPrev = list.HasPrev
+	response.Data = make([]*resources.AdminUser, 0, len(list.Data))
+
+	for _, user := range list.Data {
+		var suspendedAt *string
+		if user.SuspendedAt != nil {
+			formatted := utils.TruncateDateToLocal(*user.SuspendedAt).Format(time.RFC3339)
+			suspendedAt = &formatted
+		}
+
+		response.Data = append(response.Data, &resources.AdminUser{
+			Uuid:             user.Uuid,
+			Name:             user.Name,
+			Email:            user.Email,
+			EmailVerified:    user.IsEmailVerified(),
+			UserType:         user.UserType.Type,
+			Role:             user.Role,
+			AgentCnpj:        user.Agent.Cnpj,
+			CompanyName:      user.Agent.CompanyName,
+			SuspendedAt:      suspendedAt,
+			SuspensionReason: user.SuspensionReason,
+			CreatedAt:        utils.TruncateDateToLocal(user.CreatedAt).Format(time.RFC3339),
+		})
+	}
+
+	return &response, nil
+}
+
+func (s *adminService) ListAgents(request *requests.AdminSearch) (*utils.PaginationWrapper[*resources.AdminAgent], *merr.ResponseError) {
+	list, err := s.agentRepo.Search(request.Search, request.Page, request.PageSize)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	var agentIds []uint = make([]uint, 0, len(list.Data))
+	for _, agent := range list.Data {
+		agentIds = append(agentIds, agent.ID)
+	}
+
+	members, err := s.agentRepo.CountMembers(agentIds)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	var response utils.PaginationWrapper[*resources.AdminAgent]
+
+	response.Page = list.Page
+	response.PageSize = list.PageSize
+	response.HasNext = list.HasNext
+	response.HasPrev = list.HasPrev
+	response.Data = make([]*resources.AdminAgent, 0, len(list.Data))
+
+	for _, agent := range list.Data {
+		response.Data = append(response.Data, &resources.AdminAgent{
+			Cnpj:          agent.Cnpj,
+			CompanyName:   agent.CompanyName,
+			CceeCode:      agent.CceeCode,
+			SubmarketName: agent.Submarket.Name,
+			MembersCount:  members[agent.ID],
+			CreatedAt:     utils.TruncateDateToLocal(agent.CreatedAt).Format(time.RFC3339),
+		})
+	}
+
+	return &response, nil
+}
+
+// SuspendUser blocks the user from logging in or using existing access
+// tokens, and revokes every refresh token.
+func (s *adminService) SuspendUser(admin *models.User, client *requests.ClientInfo, userUuid string, request *requests.AdminReason) *merr.ResponseError {
+	var userId uint
+
+	// Runs once the transaction is over
+	defer func() {
+		forgetCachedUser(userUuid)
+		forgetCachedSessionsFromUser(userId)
+	}()
+
+	return s.transaction(func(tx *gorm.DB) *merr.ResponseError {
+		var userRepo = s.userRepo.WithTransaction(tx)
+
+		user, responseError := s.findUser(userRepo, userUuid)
+		if responseError != nil {
+			return responseError
+		}
+
+		userType, err := s.userTypeRepo.WithTransaction(tx).FindById(user.UserTypeId)
+		if err != nil {
+			return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+		}
+
+		if userType.Type == models.UserTypeAdmin {
+			return merr.NewResponseError(http.StatusUnprocessableEntity, ErrCannotSuspendAdmin)
+		}
+
+		if user.IsSuspended() {
+			return merr.NewResponseError(http.StatusUnprocessableEntity, ErrUserAlreadySuspended)
+		}
+
+		if err = userRepo.Suspend(user, request.Reason); err != nil {
+			return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+		}
+
+		if err = s.refreshTokenRepo.WithTransaction(tx).RevokeAllFromUser(user.ID); err != nil {
+			return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+		}
+		userId = user.ID
+
+		return s.record(tx, admin, client, models.AdminActionUserSuspend, models.AdminTargetUser, user.Uuid, request.Reason)
+	})
+}
+
+func (s *adminService) UnsuspendUser(admin *models.User, client *requests.ClientInfo, userUuid string, request *requests.AdminReason) *merr.ResponseError {
+	// Runs once the transaction is over
+	defer forgetCachedUser(userUuid)
+
+	return s.transaction(func(tx *gorm.DB) *merr.ResponseError {
+		var userRepo = s.userRepo.WithTransaction(tx)
+
+		user, responseError := s.findUser(userRepo, userUuid)
+		if responseError != nil {
+			return responseError
+		}
+
+		if !user.IsSuspended() {
+			return merr.NewResponseError(http.StatusUnprocessableEntity, ErrUserIsNotSuspended)
+		}
+
+		if err := userRepo.Unsuspend(user); err != nil {
+			return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+		}
+
+		return s.record(tx, admin, client, models.AdminActionUserUnsuspend, models.AdminTargetUser, user.Uuid, request.Reason)
+	})
+}
+
+// UnlockUser lifts a login lockout of the user's email before it expires.
+func (s *adminService) UnlockUser(admin *models.User, client *requests.ClientInfo, userUuid string, request *requests.AdminReason) *merr.ResponseError {
+	return s.transaction(func(tx *gorm.DB) *merr.ResponseError {
+		user, responseError := s.findUser(s.userRepo.WithTransaction(tx), userUuid)
+		if responseError != nil {
+			return responseError
+		}
+
+		var identifier string = loginThrottleIdentifier(models.LoginThrottleScopeAccount, user.Email, "")
+		if err := s.throttleRepo.WithTransaction(tx).Reset(models.LoginThrottleScopeAccount, identifier); err != nil {
+			return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+		}
+
+		return s.record(tx, admin, client, models.AdminActionUserUnlock, models.AdminTargetUser, user.Uuid, request.Reason)
+	})
+}
+
+func (s *adminService) ExpireOffer(admin *models.User, client *requests.ClientInfo, offerUuid string, request *requests.AdminReason) *merr.ResponseError {
+	return s.transaction(func(tx *gorm.DB) *merr.ResponseError {
+		var offerRepo = s.offerRepo.WithTransaction(tx)
+
+		offer, responseError := s.findOffer(offerRepo, offerUuid)
+		if responseError != nil {
+			return responseError
+		}
+
+		if offer.IsFulfilled() || offer.TransitionTo(models.OfferStatusExpired) != nil {
+			return merr.NewResponseError(http.StatusUnprocessableEntity, ErrOfferCannotBeExpired)
+		}
+
+		if err := offerRepo.Update(offer); err != nil {
+			return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+		}
+
+		return s.record(tx, admin, client, models.AdminActionOfferExpire, models.AdminTargetOffer, offer.Uuid, request.Reason)
+	})
+}
+
+// TakeDownOffer removes the offer from the marketplace for good, purchases
+// already made are kept.
+func (s *adminService) TakeDownOffer(admin *models.User, client *requests.ClientInfo, offerUuid string, request *requests.AdminReason) *merr.ResponseError {
+	return s.transaction(func(tx *gorm.DB) *merr.ResponseError {
+		var offerRepo = s.offerRepo.WithTransaction(tx)
+
+		offer, responseError := s.findOffer(offerRepo, offerUuid)
+		if responseError != nil {
+			return responseError
+		}
+
+		if offer.TransitionTo(models.OfferStatusTakenDown) != nil {
+			return merr.NewResponseError(http.StatusUnprocessableEntity, ErrOfferAlreadyTakenDown)
+		}
+
+		if err := offerRepo.Update(offer); err != nil {
+			return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+		}
+
+		return s.record(tx, admin, client, models.AdminActionOfferTakeDown, models.AdminTargetOffer, offer.Uuid, request.Reason)
+	})
+}
+
+func (s *adminService) GetPurchase(admin *models.User, client *requests.ClientInfo, purchaseUuid string) (*resources.Purchase, *merr.ResponseError) {
+	purchase, responseError := s.findPurchase(s.purchaseRepo, purchaseUuid)
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	if responseError = s.record(s.db, admin, client, models.AdminActionPurchaseView, models.AdminTargetPurchase, purchase.Uuid, ""); responseError != nil {
+		return nil, responseError
+	}
+
+	return makePurchaseResourceFromModel(purchase), nil
+}
+
+func (s *adminService) GetContract(admin *models.User, client *requests.ClientInfo, purchaseUuid string) (*resources.Contract, *merr.ResponseError) {
+	contract, responseError := s.contractService.GetForAdmin(purchaseUuid)
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	if responseError = s.record(s.db, admin, client, models.AdminActionContractView, models.AdminTargetPurchase, purchaseUuid, ""); responseError != nil {
+		return nil, responseError
+	}
+
+	return contract, nil
+}
+
+// CompletePurchase settles a purchase whose payment got stuck waiting.
+func (s *adminService) CompletePurchase(admin *models.User, client *requests.ClientInfo, purchaseUuid string, request *requests.AdminReason) *merr.ResponseError {
+	return s.transaction(func(tx *gorm.DB) *merr.ResponseError {
+		var purchaseRepo = s.purchaseRepo.WithTransaction(tx)
+
+		purchase, responseError := s.findPurchase(purchaseRepo, purchaseUuid)
+		if responseError != nil {
+			return responseError
+		}
+
+		if !purchase.IsWaiting() {
+			return merr.NewResponseError(http.StatusUnprocessableEntity, ErrPurchaseIsNotWaiting)
+		}
+
+		purchase.Status = models.PurchaseStatusCompleted
+		if err := purchaseRepo.Update(purchase); err != nil {
+			return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+		}
+
+		return s.record(tx, admin, client, models.AdminActionPurchaseComplete, models.AdminTargetPurchase, purchase.Uuid, request.Reason)
+	})
+}
+
+// CancelPurchase cancels a purchase stuck waiting, regardless of the window
+// buyers have to cancel, and gives the quantity back to the offer.
+func (s *adminService) CancelPurchase(admin *models.User, client *requests.ClientInfo, purchaseUuid string, request *requests.AdminReason) *merr.ResponseError {
+	return s.transaction(func(tx *gorm.DB) *merr.ResponseError {
+		purchase, responseError := s.findPurchase(s.purchaseRepo.WithTransaction(tx), purchaseUuid)
+		if responseError != nil {
+			return responseError
+		}
+
+		if !purchase.IsWaiting() {
+			return merr.NewResponseError(http.StatusUnprocessableEntity, ErrPurchaseIsNotWaiting)
+		}
+
+		if err := cancelPurchase(tx, purchase); err != nil {
+			return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+		}
+
+		return s.record(tx, admin, client, models.AdminActionPurchaseCancel, models.AdminTargetPurchase, purchase.Uuid, request.Reason)
+	})
+}
+
+func (s *adminService) ListActions(request *requests.AdminListActions) (*utils.PaginationWrapper[*resources.AdminAction], *merr.ResponseError) {
+	list, err := s.adminActionRepo.List(request)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	var response utils.PaginationWrapper[*resources.AdminAction]
+
+	response.Page = list.Page
+	response.PageSize = list.PageSize
+	response.HasNext = list.HasNext
+	response.HasPrev = list.HasPrev
+	response.Data = make([]*resources.AdminAction, 0, len(list.Data))
+
+	for _, action := range list.Data {
+		response.Data = append(response.Data, &resources.AdminAction{
+			Uuid:       action.Uuid,
+			Action:     action.Action,
+			TargetType: action.TargetType,
+			TargetUuid: action.TargetUuid,
+			Reason:     action.Reason,
+			AdminUuid:  action.Admin.Uuid,
+			AdminName:  action.Admin.Name,
+			CreatedAt:  utils.TruncateDateToLocal(action.CreatedAt).Format(time.RFC3339),
+		})
+	}
+
+	return &response, nil
+}
+
+// transaction runs fn in a transaction that is rolled back whenever fn
+// returns an error response.
+func (s *adminService) transaction(fn func(tx *gorm.DB) *merr.ResponseError) *merr.ResponseError {
+	var responseError *merr.ResponseError
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if responseError = fn(tx); responseError != nil {
+			return responseError.Error
+		}
+		return nil
+	})
+
+	if responseError != nil {
+		return responseError
+	}
+
+	if err != nil {
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return nil
+}
+
+// record keeps the action in the admin trail and, with the client details,
+// in the audit events.
+func (s *adminService) record(
+	tx *gorm.DB,
+	admin *models.User,
+	client *requests.ClientInfo,
+	action string,
+	targetType string,
+	targetUuid string,
+	reason string,
+) *merr.ResponseError {
+	err := s.adminActionRepo.WithTransaction(tx).Create(&models.AdminAction{
+		Uuid:       NewUuidV7String(),
+		Action:     action,
+		TargetType: targetType,
+		TargetUuid: targetUuid,
+		Reason:     reason,
+		AdminId:    admin.ID,
+	})
+	if err != nil {
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	var after map[string]string
+	if reason != "" {
+		after = map[string]string{"reason": reason}
+	}
+
+	err = recordAuditEvent(tx, client, auditEntry{
+		Event:      models.AuditEventAdminPrefix + action,
+		Actor:      admin,
+		TargetType: targetType,
+		TargetUuid: targetUuid,
+		After:      after,
+	})
+	if err != nil {
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return nil
+}
+
+func (s *adminService) findUser(userRepo repository.UserRepository, userUuid string) (*models.User, *merr.ResponseError) {
+	user, err := userRepo.FindByUuid(userUuid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, merr.NewResponseError(http.StatusNotFound, ErrUserNotFound)
+		}
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return user, nil
+}
+
+func (s *adminService) findOffer(offerRepo repository.OfferRepository, offerUuid string) (*models.Offer, *merr.ResponseError) {
+	offer, err := offerRepo.GetByUuid(offerUuid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, merr.NewResponseError(http.StatusNotFound, ErrOfferNotFound)
+		}
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return offer, nil
+}
+
+func (s *adminService) findPurchase(purchaseRepo repository.PurchaseRepository, purchaseUuid string) (*models.Purchase, *merr.ResponseError) {
+	purchase, err := purchaseRepo.FindByUuid(purchaseUuid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, merr.NewResponseError(http.StatusNotFound, ErrPurchaseNotFound)
+		}
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return purchase, nil
+}
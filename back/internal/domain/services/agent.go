@@ -0,0 +1,251 @@
+package services
+
+import (
+	"ecoply/internal/config"
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/repository"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/resources"
+	"ecoply/internal/domain/utils"
+	"ecoply/internal/mail"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const agentInvitationTTL = 7 * 24 * time.Hour
+
+type AgentService interface {
+	Members(user *models.User) ([]*resources.AgentMember, *merr.ResponseError)
+	UpdateMemberRole(user *models.User, memberUuid string, request *requests.UpdateMemberRole) *merr.ResponseError
+	Invitations(user *models.User) ([]*resources.AgentInvitation, *merr.ResponseError)
+	Invite(user *models.User, request *requests.InviteMember) (*resources.AgentInvitation, *merr.ResponseError)
+	RevokeInvitation(user *models.User, invitationUuid string) *merr.ResponseError
+}
+
+type agentService struct {
+	userRepo       repository.UserRepository
+	agentRepo      repository.AgentRepository
+	invitationRepo repository.AgentInvitationRepository
+	userTypeRepo   repository.UserTypeRepository
+	mailer         mail.Mailer
+	appName        string
+	frontendUrl    string
+	db             *gorm.DB
+}
+
+func NewAgentService(cfg *config.Config, db *gorm.DB) AgentService {
+	return &agentService{
+		userRepo:       repository.NewUserRepository(db),
+		agentRepo:      repository.NewAgentRepository(db),
+		invitationRepo: repository.NewAgentInvitationRepository(db),
+		userTypeRepo:   repository.NewUserTypeRepository(db),
+		mailer:         mail.New(cfg),
+		appName:        cfg.AppName,
+		frontendUrl:    cfg.AppFrontendUrl,
+		db:             db,
+	}
+}
+
+func (s *agentService) Members(user *models.User) ([]*resources.AgentMember, *merr.ResponseError) {
+	members, err := s.userRepo.ListByAgentId(user.AgentId)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	response := make([]*resources.AgentMember, 0, len(members))
+	for _, member := range members {
+		response = append(response, &resources.AgentMember{
+			Uuid:      member.Uuid,
+			Name:      member.Name,
+			Email:     member.Email,
+			Role:      member.Role,
+			CreatedAt: utils.TruncateDateToLocal(member.CreatedAt).Format(time.RFC3339),
+		})
+	}
+
+	return response, nil
+}
+
+// UpdateMemberRole refuses to demote the last owner, otherwise nobody could
+// manage the company anymore.
+func (s *agentService) UpdateMemberRole(user *models.User, memberUuid string, request *requests.UpdateMemberRole) *merr.ResponseError {
+	if !user.IsAgentOwner() {
+		return merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	var responseError *merr.ResponseError
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var userRepo = s.userRepo.WithTransaction(tx)
+
+		// Serializes concurrent role changes inside the same company
+		if _, err := s.agentRepo.WithTransaction(tx).FindByIdForUpdate(user.AgentId); err != nil {
+			return err
+		}
+
+		member, err := userRepo.FindByUuid(memberUuid)
+		if err != nil || !member.IsSameAgent(user) {
+			if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+				responseError = merr.NewResponseError(http.StatusNotFound, ErrMemberNotFound)
+				return ErrMemberNotFound
+			}
+			return err
+		}
+
+		if member.IsAgentOwner() && request.Role != models.UserRoleOwner {
+			owners, err := userRepo.CountByAgentIdAndRole(user.AgentId, models.UserRoleOwner)
+			if err != nil {
+				return err
+			}
+
+			if owners <= 1 {
+				responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrAgentMustHaveOwner)
+				return ErrAgentMustHaveOwner
+			}
+		}
+
+		return userRepo.UpdateRole(member, request.Role)
+	})
+
+	if responseError != nil {
+		return responseError
+	}
+
+	if err != nil {
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	forgetCachedUser(memberUuid)
+
+	return nil
+}
+
+func (s *agentService) Invitations(user *models.User) ([]*resources.AgentInvitation, *merr.ResponseError) {
+	if !user.IsAgentOwner() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	invitations, err := s.invitationRepo.ListPendingFromAgent(user.AgentId)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	response := make([]*resources.AgentInvitation, 0, len(invitations))
+	for _, invitation := range invitations {
+		response = append(response, makeAgentInvitationResourceFromModel(invitation))
+	}
+
+	return response, nil
+}
+
+// Invite sends a link that lets the recipient create an account inside the
+// inviter's company, see AuthService.AcceptInvitation. Invitees get the user
+// type of the inviter, so administrators can't invite.
+func (s *agentService) Invite(user *models.User, request *requests.InviteMember) (*resources.AgentInvitation, *merr.ResponseError) {
+	if !user.IsAgentOwner() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	userType, err := s.userTypeRepo.FindById(user.UserTypeId)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if userType.Type == models.UserTypeAdmin {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrAdminCannotInvite)
+	}
+
+	var email string = request.Email
+
+	if _, err := s.userRepo.FindByEmail(email); err == nil {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrUserAlreadyExists)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	agent, err := s.agentRepo.FindById(user.AgentId)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	token, err := NewOpaqueToken()
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	var invitation *models.AgentInvitation = &models.AgentInvitation{
+		Uuid:        NewUuidV7String(),
+		Email:       email,
+		Role:        request.Role,
+		TokenHash:   Hash256String(token),
+		ExpiresAt:   utils.NowInLocal().Add(agentInvitationTTL),
+		AgentId:     user.AgentId,
+		InvitedById: user.ID,
+		InvitedBy:   *user,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var invitationRepo = s.invitationRepo.WithTransaction(tx)
+
+		if err := invitationRepo.RevokePendingFromEmail(user.AgentId, email); err != nil {
+			return err
+		}
+
+		return invitationRepo.Create(invitation)
+	})
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	dispatchEmail(s.mailer, &mail.Message{
+		To:      email,
+		Subject: fmt.Sprintf("%s - invitation to %s", s.appName, agent.CompanyName),
+		Body: fmt.Sprintf(
+			"Hello,\n\n%s invited you to join %s on %s as %s. Use the link below to create your account. It expires in %s.\n\n%s\n",
+			user.Name, agent.CompanyName, s.appName, request.Role, agentInvitationTTL,
+			makeFrontendLink(s.frontendUrl, "/accept-invitation", token),
+		),
+	})
+
+	return makeAgentInvitationResourceFromModel(invitation), nil
+}
+
+func (s *agentService) RevokeInvitation(user *models.User, invitationUuid string) *merr.ResponseError {
+	if !user.IsAgentOwner() {
+		return merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	invitation, err := s.invitationRepo.FindByUuid(user.AgentId, invitationUuid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return merr.NewResponseError(http.StatusNotFound, ErrInvitationNotFound)
+		}
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if !invitation.IsPending() {
+		return merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidInvitation)
+	}
+
+	if err = s.invitationRepo.Revoke(invitation); err != nil {
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return nil
+}
+
+func makeAgentInvitationResourceFromModel(invitation *models.AgentInvitation) *resources.AgentInvitation {
+	return &resources.AgentInvitation{
+		Uuid:          invitation.Uuid,
+		Email:         invitation.Email,
+		Role:          invitation.Role,
+		InvitedByName: invitation.InvitedBy.Name,
+		ExpiresAt:     utils.TruncateDateToLocal(invitation.ExpiresAt).Format(time.RFC3339),
+		CreatedAt:     utils.TruncateDateToLocal(invitation.CreatedAt).Format(time.RFC3339),
+	}
+}
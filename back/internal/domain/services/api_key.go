@@ -0,0 +1,200 @@
+package services
+
+import (
+	"crypto/rand"
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/repository"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/resources"
+	"ecoply/internal/domain/utils"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ApiKeyPrefix starts every key, it is how JwtAuthMiddleware tells keys
+// apart from JWTs.
+const ApiKeyPrefix = "ecoply_"
+
+// Last use is saved at most once per interval to avoid a write per request
+const apiKeyLastUsedInterval = time.Minute
+
+type ApiKeyService interface {
+	List(user *models.User) ([]*resources.ApiKey, *merr.ResponseError)
+	Create(user *models.User, request *requests.CreateApiKey) (*resources.CreatedApiKey, *merr.ResponseError)
+	Revoke(user *models.User, apiKeyUuid string) *merr.ResponseError
+	Authenticate(key string) (*models.User, *models.ApiKey, *merr.ResponseError)
+}
+
+type apiKeyService struct {
+	apiKeyRepo repository.ApiKeyRepository
+	userRepo   repository.UserRepository
+}
+
+func NewApiKeyService(db *gorm.DB) ApiKeyService {
+	return &apiKeyService{
+		apiKeyRepo: repository.NewApiKeyRepository(db),
+		userRepo:   repository.NewUserRepository(db),
+	}
+}
+
+func (s *apiKeyService) List(user *models.User) ([]*resources.ApiKey, *merr.ResponseError) {
+	apiKeys, err := s.apiKeyRepo.ListActiveFromUser(user.ID)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	response := make([]*resources.ApiKey, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		response = append(response, makeApiKeyResourceFromModel(apiKey))
+	}
+
+	return response, nil
+}
+
+// Create returns the key in clear, it is the only time it can be seen.
+func (s *apiKeyService) Create(user *models.User, request *requests.CreateApiKey) (*resources.CreatedApiKey, *merr.ResponseError) {
+	prefix, err := newApiKeyPrefix()
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	secret, err := NewOpaqueToken()
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	var key string = prefix + "_" + secret
+	var scopes []string = slices.Compact(slices.Sorted(slices.Values(request.Scopes)))
+
+	var apiKey *models.ApiKey = &models.ApiKey{
+		Uuid:    NewUuidV7String(),
+		Name:    request.Name,
+		Prefix:  prefix,
+		KeyHash: Hash256String(key),
+		Scopes:  strings.Join(scopes, ","),
+		UserId:  user.ID,
+	}
+
+	if request.ExpiresInDays != nil {
+		var expiresAt time.Time = utils.NowInLocal().AddDate(0, 0, *request.ExpiresInDays)
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	if err = s.apiKeyRepo.Create(apiKey); err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return &resources.CreatedApiKey{
+		ApiKey: *makeApiKeyResourceFromModel(apiKey),
+		Key:    key,
+	}, nil
+}
+
+func (s *apiKeyService) Revoke(user *models.User, apiKeyUuid string) *merr.ResponseError {
+	apiKey, err := s.apiKeyRepo.FindByUuid(user.ID, apiKeyUuid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return merr.NewResponseError(http.StatusNotFound, ErrApiKeyNotFound)
+		}
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if apiKey.RevokedAt != nil {
+		return nil
+	}
+
+	if err = s.apiKeyRepo.Revoke(apiKey); err != nil {
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	forgetCachedApiKey(apiKey.KeyHash)
+	return nil
+}
+
+// Authenticate resolves a key sent as bearer token to its user. Suspension
+// and the other user checks are left to the caller, as for JWTs. The key and
+// its user are read from cachedApiKeys until its use is due to be recorded
+// again.
+func (s *apiKeyService) Authenticate(key string) (*models.User, *models.ApiKey, *merr.ResponseError) {
+	var keyHash string = Hash256String(key)
+	var now time.Time = utils.NowInLocal()
+
+	if cached, ok := cachedApiKeys.get(keyHash); ok && cached.apiKey.IsUsable() &&
+		cached.apiKey.LastUsedAt != nil && now.Sub(*cached.apiKey.LastUsedAt) < apiKeyLastUsedInterval {
+		return &cached.user, &cached.apiKey, nil
+	}
+
+	apiKey, err := s.apiKeyRepo.FindByHash(keyHash)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, merr.NewResponseError(http.StatusUnauthorized, ErrInvalidApiKey)
+		}
+		return nil, nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if !apiKey.IsUsable() {
+		return nil, nil, merr.NewResponseError(http.StatusUnauthorized, ErrInvalidApiKey)
+	}
+
+	user, err := s.userRepo.FindById(apiKey.UserId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, merr.NewResponseError(http.StatusUnauthorized, ErrInvalidApiKey)
+		}
+		return nil, nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	// Needed to resolve the permissions of the request
+	if err = s.userRepo.PreloadUserType(user); err != nil {
+		return nil, nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyLastUsedInterval {
+		// Failing to record the use must not block the request
+		_ = s.apiKeyRepo.TouchLastUsed(apiKey, now)
+	}
+
+	cachedApiKeys.put(keyHash, &cachedApiKey{apiKey: *apiKey, user: *user})
+	return user, apiKey, nil
+}
+
+func IsApiKey(token string) bool {
+	return strings.HasPrefix(token, ApiKeyPrefix)
+}
+
+func newApiKeyPrefix() (string, error) {
+	bytes := make([]byte, 4)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return ApiKeyPrefix + hex.EncodeToString(bytes), nil
+}
+
+func makeApiKeyResourceFromModel(apiKey *models.ApiKey) *resources.ApiKey {
+	var resource *resources.ApiKey = &resources.ApiKey{
+		Uuid:      apiKey.Uuid,
+		Name:      apiKey.Name,
+		Prefix:    apiKey.Prefix,
+		Scopes:    apiKey.ScopeList(),
+		CreatedAt: utils.TruncateDateToLocal(apiKey.CreatedAt).Format(time.RFC3339),
+	}
+
+	if apiKey.ExpiresAt != nil {
+		var expiresAt string = utils.TruncateDateToLocal(*apiKey.ExpiresAt).Format(time.RFC3339)
+		resource.ExpiresAt = &expiresAt
+	}
+
+	if apiKey.LastUsedAt != nil {
+		var lastUsedAt string = utils.TruncateDateToLocal(*apiKey.LastUsedAt).Format(time.RFC3339)
+		resource.LastUsedAt = &lastUsedAt
+	}
+
+	return resource
+}
@@ -0,0 +1,349 @@
+package services
+
+import (
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/repository"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/resources"
+	"ecoply/internal/domain/utils"
+	"ecoply/internal/mlog"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// The smallest raise of an english auction without a bid increment
+const minAuctionBidIncrementPerMwh float64 = 0.01
+
+type AuctionService interface {
+	Bid(user *models.User, offerUuid string, request *requests.PlaceAuctionBid) (*resources.AuctionBid, *merr.ResponseError)
+	Bids(user *models.User, offerUuid string) ([]*resources.AuctionBid, *merr.ResponseError)
+	CloseEndedAuctions() error
+}
+
+type auctionService struct {
+	offerRepo       repository.OfferRepository
+	auctionBidRepo  repository.AuctionBidRepository
+	purchaseService *purchaseService
+	db              *gorm.DB
+}
+
+func NewAuctionService(db *gorm.DB) AuctionService {
+	return &auctionService{
+		offerRepo:       repository.NewOfferRepository(db),
+		auctionBidRepo:  repository.NewAuctionBidRepository(db),
+		purchaseService: newPurchaseService(db),
+		db:              db,
+	}
+}
+
+// Bid places a bid on a running auction. English bids must beat the highest
+// one by the bid increment, sealed bids are limited to one per company and
+// on a dutch auction the bid takes the current price and wins right away.
+func (s *auctionService) Bid(user *models.User, offerUuid string, request *requests.PlaceAuctionBid) (*resources.AuctionBid, *merr.ResponseError) {
+	var responseError *merr.ResponseError
+	var bid *models.AuctionBid
+	var purchase *models.Purchase
+
+	if !user.CanTrade() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	if !user.IsEmailVerified() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrEmailNotVerified)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var auctionBidRepo = s.auctionBidRepo.WithTransaction(tx)
+
+		offer, err := s.offerRepo.WithTransaction(tx).FindByUuidForUpdate(offerUuid)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				responseError = merr.NewResponseError(http.StatusNotFound, ErrOfferNotFound)
+			}
+			return err
+		}
+
+		if responseError = checkOfferVisibility(s.offerRepo.WithTransaction(tx), offer, user); responseError != nil {
+			return responseError.Error
+		}
+
+		if !offer.IsAuction() {
+			responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrOfferIsNotAuction)
+			return ErrOfferIsNotAuction
+		}
+
+		if offer.IsOwner(user) {
+			responseError = merr.NewResponseError(http.StatusForbidden, ErrCannotBidOnOwnAuction)
+			return ErrCannotBidOnOwnAuction
+		}
+
+		if !offer.IsFresh() || !offer.IsAuctionRunning() {
+			responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrAuctionIsNotRunning)
+			return ErrAuctionIsNotRunning
+		}
+
+		bid = &models.AuctionBid{
+			Uuid:          NewUuidV7String(),
+			PricePerMwh:   request.PricePerMwh,
+			PaymentMethod: request.PaymentMethod,
+			Status:        models.AuctionBidStatusActive,
+			OfferId:       offer.ID,
+			BidderId:      user.ID,
+		}
+
+		switch offer.AuctionType {
+		case models.OfferAuctionDutch:
+			bid.PricePerMwh = offer.DutchPriceAt(utils.NowInLocal())
+
+			if err = auctionBidRepo.Create(bid); err != nil {
+				return err
+			}
+
+			purchase, err = awardAuction(tx, offer, bid)
+			return err
+
+		case models.OfferAuctionEnglish:
+			highest, err := auctionBidRepo.FindHighestActive(offer.ID)
+			if err != nil {
+				return err
+			}
+
+			var minPrice float64 = offer.PricePerMwh
+			if highest != nil {
+				var increment float64 = minAuctionBidIncrementPerMwh
+				if offer.BidIncrementPerMwh != nil {
+					increment = *offer.BidIncrementPerMwh
+				}
+				minPrice = highest.PricePerMwh + increment
+			}
+
+			if request.PricePerMwh < minPrice {
+				responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrAuctionBidTooLow)
+				return ErrAuctionBidTooLow
+			}
+
+			if err = auctionBidRepo.Create(bid); err != nil {
+				return err
+			}
+
+			return auctionBidRepo.OutbidActive(offer.ID, bid.ID)
+
+		default:
+			if request.PricePerMwh < offer.PricePerMwh {
+				responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrAuctionBidTooLow)
+				return ErrAuctionBidTooLow
+			}
+
+			placed, err := auctionBidRepo.HasActiveFromAgent(offer.ID, user.AgentId)
+			if err != nil {
+				return err
+			}
+
+			if placed {
+				responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrAuctionBidAlreadyPlaced)
+				return ErrAuctionBidAlreadyPlaced
+			}
+
+			return auctionBidRepo.Create(bid)
+		}
+	})
+
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	bid.Bidder = *user
+
+	var response *resources.AuctionBid = makeAuctionBidResourceFromModel(bid, true)
+
+	if purchase != nil {
+		s.purchaseService.dispatchPurchasePaymentProcessor(user, purchase)
+		response.Purchase = makePurchaseResourceFromModel(purchase)
+	}
+
+	return response, nil
+}
+
+// Bids shows english auctions to anyone, without the bidders. Sealed bids
+// stay hidden until the auction ends, then the seller sees them all; until
+// then each company only sees its own.
+func (s *auctionService) Bids(user *models.User, offerUuid string) ([]*resources.AuctionBid, *merr.ResponseError) {
+	offer, err := s.offerRepo.GetByUuid(offerUuid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, merr.NewResponseError(http.StatusNotFound, ErrOfferNotFound)
+		}
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if responseError := checkOfferVisibility(s.offerRepo, offer, user); responseError != nil {
+		return nil, responseError
+	}
+
+	if !offer.IsAuction() {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrOfferIsNotAuction)
+	}
+
+	var isOwner bool = offer.IsOwner(user)
+	var bids []*models.AuctionBid
+
+	if offer.AuctionType != models.OfferAuctionSealed || (isOwner && !offer.IsFresh()) {
+		bids, err = s.auctionBidRepo.ListFromOffer(offer.ID)
+	} else {
+		bids, err = s.auctionBidRepo.ListFromOfferAndAgent(offer.ID, user.AgentId)
+	}
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	response := make([]*resources.AuctionBid, 0, len(bids))
+	for _, bid := range bids {
+		var showBidder bool = isOwner || bid.Bidder.IsSameAgent(user)
+		response = append(response, makeAuctionBidResourceFromModel(bid, showBidder))
+	}
+
+	return response, nil
+}
+
+// CloseEndedAuctions awards each ended auction to its best bid at or above
+// the reserve price, the others expire. Auctions are closed one transaction
+// each so a failure doesn't hold the rest back.
+func (s *auctionService) CloseEndedAuctions() error {
+	ids, err := s.offerRepo.ListEndedAuctionIds()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		var purchase *models.Purchase
+
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			purchase, err = closeAuction(tx, id)
+			return err
+		})
+		if err != nil {
+			mlog.Log("Failed to close auction " + strconv.FormatUint(uint64(id), 10) + ": " + err.Error())
+			continue
+		}
+
+		if purchase != nil {
+			s.purchaseService.dispatchPurchasePaymentProcessor(&purchase.Buyer, purchase)
+		}
+	}
+
+	return nil
+}
+
+func closeAuction(tx *gorm.DB, offerId uint) (*models.Purchase, error) {
+	var offerRepo = repository.NewOfferRepository(tx)
+	var auctionBidRepo = repository.NewAuctionBidRepository(tx)
+
+	offer, err := offerRepo.FindByIdForUpdate(offerId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !offer.IsFresh() || offer.IsAuctionRunning() {
+		return nil, nil
+	}
+
+	bids, err := auctionBidRepo.ListActiveForUpdate(offer.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, bid := range bids {
+		if bid.Bidder.IsSuspended() || bid.Bidder.IsAnonymized() {
+			continue
+		}
+
+		if offer.ReservePricePerMwh != nil && bid.PricePerMwh < *offer.ReservePricePerMwh {
+			break
+		}
+
+		return awardAuction(tx, offer, bid)
+	}
+
+	if err = offer.TransitionTo(models.OfferStatusExpired); err != nil {
+		return nil, err
+	}
+
+	if err = offerRepo.Update(offer); err != nil {
+		return nil, err
+	}
+
+	return nil, auctionBidRepo.LoseOpenFromOffer(offer.ID)
+}
+
+// awardAuction sells the whole offer to the bid and closes the other bids.
+func awardAuction(tx *gorm.DB, offer *models.Offer, bid *models.AuctionBid) (*models.Purchase, error) {
+	var auctionBidRepo = repository.NewAuctionBidRepository(tx)
+
+	var purchase *models.Purchase = &models.Purchase{
+		Uuid:          NewUuidV7String(),
+		OfferId:       offer.ID,
+		PricePerMwh:   bid.PricePerMwh,
+		PaymentMethod: bid.PaymentMethod,
+		Status:        models.PurchaseStatusWaiting,
+		BuyerId:       bid.BidderId,
+		QuantityMwh:   offer.RemainingQuantityMwh,
+	}
+
+	if err := repository.NewPurchaseRepository(tx).Create(purchase); err != nil {
+		return nil, err
+	}
+
+	if err := allocatePurchaseDeliveries(tx, purchase); err != nil {
+		return nil, err
+	}
+
+	if err := offer.Sell(purchase.QuantityMwh); err != nil {
+		return nil, err
+	}
+
+	if err := repository.NewOfferRepository(tx).Update(offer); err != nil {
+		return nil, err
+	}
+
+	bid.Status = models.AuctionBidStatusWon
+	bid.PurchaseId = &purchase.ID
+	if err := auctionBidRepo.Update(bid); err != nil {
+		return nil, err
+	}
+
+	if err := auctionBidRepo.LoseOpenFromOffer(offer.ID); err != nil {
+		return nil, err
+	}
+
+	if err := preloadPurchaseResource(tx, purchase); err != nil {
+		return nil, err
+	}
+
+	return purchase, nil
+}
+
+func makeAuctionBidResourceFromModel(bid *models.AuctionBid, showBidder bool) *resources.AuctionBid {
+	var response *resources.AuctionBid = &resources.AuctionBid{
+		Uuid:        bid.Uuid,
+		PricePerMwh: bid.PricePerMwh,
+		Status:      bid.Status,
+		CreatedAt:   utils.TruncateDateToLocal(bid.CreatedAt).Format(time.RFC3339),
+	}
+
+	if showBidder {
+		response.PaymentMethod = bid.PaymentMethod
+		response.BidderUuid = bid.Bidder.Uuid
+	}
+
+	return response
+}
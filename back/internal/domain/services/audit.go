@@ -0,0 +1,137 @@
+package services
+
+import (
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/repository"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/resources"
+	"ecoply/internal/domain/utils"
+	"ecoply/internal/mlog"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditService reads the audit trail, events are written by the services
+// that perform the actions through recordAuditEvent.
+type AuditService interface {
+	Activity(user *models.User, request *requests.ListActivity) (*utils.PaginationWrapper[*resources.AuditEvent], *merr.ResponseError)
+	List(request *requests.AdminListAuditEvents) (*utils.PaginationWrapper[*resources.AuditEvent], *merr.ResponseError)
+}
+
+type auditService struct {
+	auditEventRepo repository.AuditEventRepository
+}
+
+func NewAuditService(db *gorm.DB) AuditService {
+	return &auditService{
+		auditEventRepo: repository.NewAuditEventRepository(db),
+	}
+}
+
+func (s *auditService) Activity(user *models.User, request *requests.ListActivity) (*utils.PaginationWrapper[*resources.AuditEvent], *merr.ResponseError) {
+	list, err := s.auditEventRepo.ListFromUser(user, request.Page, request.PageSize)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return makeAuditEventPage(list), nil
+}
+
+func (s *auditService) List(request *requests.AdminListAuditEvents) (*utils.PaginationWrapper[*resources.AuditEvent], *merr.ResponseError) {
+	list, err := s.auditEventRepo.List(request)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return makeAuditEventPage(list), nil
+}
+
+type auditEntry struct {
+	Event      string
+	Actor      *models.User
+	TargetType string
+	TargetUuid string
+	Before     any
+	After      any
+}
+
+// recordAuditEvent must be given the transaction of the change it records,
+// when there is one, so the event is only kept if the change is.
+func recordAuditEvent(db *gorm.DB, client *requests.ClientInfo, entry auditEntry) error {
+	var event *models.AuditEvent = &models.AuditEvent{
+		Uuid:       NewUuidV7String(),
+		Event:      entry.Event,
+		TargetType: entry.TargetType,
+		TargetUuid: entry.TargetUuid,
+		Before:     marshalAuditSnapshot(entry.Before),
+		After:      marshalAuditSnapshot(entry.After),
+	}
+
+	if entry.Actor != nil {
+		event.ActorId = &entry.Actor.ID
+	}
+
+	if client != nil {
+		event.Ip = client.Ip
+		event.UserAgent = client.UserAgent
+	}
+
+	return repository.NewAuditEventRepository(db).Create(event)
+}
+
+func marshalAuditSnapshot(snapshot any) string {
+	if snapshot == nil {
+		return ""
+	}
+
+	encoded, err := json.Marshal(snapshot)
+	if err != nil {
+		mlog.Log("Failed to marshal audit snapshot: " + err.Error())
+		return ""
+	}
+
+	return string(encoded)
+}
+
+func makeAuditEventPage(list *utils.PaginationWrapper[*models.AuditEvent]) *utils.PaginationWrapper[*resources.AuditEvent] {
+	var response utils.PaginationWrapper[*resources.AuditEvent]
+
+	response.Page = list.Page
+	response.PageSize = list.PageSize
+	response.HasNext = list.HasNext
+	response.HasPrev = list.HasPrev
+	response.Data = make([]*resources.AuditEvent, 0, len(list.Data))
+
+	for _, event := range list.Data {
+		var resource *resources.AuditEvent = &resources.AuditEvent{
+			Uuid:       event.Uuid,
+			Event:      event.Event,
+			Ip:         event.Ip,
+			UserAgent:  event.UserAgent,
+			TargetType: event.TargetType,
+			TargetUuid: event.TargetUuid,
+			CreatedAt:  utils.TruncateDateToLocal(event.CreatedAt).Format(time.RFC3339),
+		}
+
+		if event.Actor != nil {
+			resource.ActorUuid = &event.Actor.Uuid
+			resource.ActorName = &event.Actor.Name
+		}
+
+		if event.Before != "" {
+			resource.Before = json.RawMessage(event.Before)
+		}
+
+		if event.After != "" {
+			resource.After = json.RawMessage(event.After)
+		}
+
+		response.Data = append(response.Data, resource)
+	}
+
+	return &response
+}
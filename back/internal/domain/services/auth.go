@@ -7,45 +7,90 @@ import (
 	"ecoply/internal/domain/repository"
 	"ecoply/internal/domain/requests"
 	"ecoply/internal/domain/resources"
+	"ecoply/internal/domain/utils"
+	"ecoply/internal/mail"
+	"ecoply/internal/mlog"
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+const twoFactorChallengeTTL = 5 * time.Minute
+
 type AuthService interface {
-	Login(request *requests.Login) (*resources.Login, *merr.ResponseError)
-	SignUp(request *requests.SignUp) (*resources.Login, *merr.ResponseError)
+	Login(request *requests.Login, client *requests.ClientInfo) (*resources.Login, *merr.ResponseError)
+	SignUp(request *requests.SignUp, client *requests.ClientInfo) (*resources.Login, *merr.ResponseError)
+	AcceptInvitation(request *requests.AcceptInvitation, client *requests.ClientInfo) (*resources.Login, *merr.ResponseError)
 	Me(userUuid string) (*resources.Me, *merr.ResponseError)
 	Availability(request *requests.Availability) (bool, *merr.ResponseError)
-	RefreshToken(oldToken string) (string, *merr.ResponseError)
+	RefreshToken(request *requests.RefreshToken, client *requests.ClientInfo) (*resources.Tokens, *merr.ResponseError)
+	Logout(request *requests.RefreshToken, client *requests.ClientInfo) *merr.ResponseError
+	LogoutAll(user *models.User, client *requests.ClientInfo) *merr.ResponseError
+	LoginTwoFactor(request *requests.LoginTwoFactor, client *requests.ClientInfo) (*resources.Login, *merr.ResponseError)
 }
 
 type authService struct {
-	userRepo      repository.UserRepository
-	agentRepo     repository.AgentRepository
-	addressRepo   repository.AddressRepository
-	userTypeRepo  repository.UserTypeRepository
-	submarketRepo repository.SubmarketRepository
-	jwtService    JwtService
-	db            *gorm.DB
+	userRepo         repository.UserRepository
+	agentRepo        repository.AgentRepository
+	addressRepo      repository.AddressRepository
+	userTypeRepo     repository.UserTypeRepository
+	submarketRepo    repository.SubmarketRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	sessionRepo      repository.SessionRepository
+	accountService   AccountService
+	twoFactorService TwoFactorService
+	throttleService  LoginThrottleService
+	jwtService       JwtService
+	hasher           PasswordHasher
+	mailer           mail.Mailer
+	appName          string
+	refreshTokenTTL  time.Duration
+	auditHashKey     []byte
+	db               *gorm.DB
+
+	// Verified against when the email is unknown, so both failures take
+	// the same time
+	dummyPasswordHash string
 }
 
-func NewAuthService(cfg *config.Config, db *gorm.DB) AuthService {
+func NewAuthService(cfg *config.Config, db *gorm.DB, jwtService JwtService) AuthService {
+	var hasher PasswordHasher = NewPasswordHasher(cfg)
+
+	dummyPasswordHash, err := hasher.Hash(NewUuidV7String())
+	if err != nil {
+		mlog.Log("Failed to hash dummy password: " + err.Error())
+	}
+
 	return &authService{
-		userRepo:      repository.NewUserRepository(db),
-		agentRepo:     repository.NewAgentRepository(db),
-		addressRepo:   repository.NewAddressRepository(db),
-		userTypeRepo:  repository.NewUserTypeRepository(db),
-		submarketRepo: repository.NewSubmarketRepository(db),
-		jwtService:    NewJwtService(cfg),
-		db:            db,
+		userRepo:         repository.NewUserRepository(db),
+		agentRepo:        repository.NewAgentRepository(db),
+		addressRepo:      repository.NewAddressRepository(db),
+		userTypeRepo:     repository.NewUserTypeRepository(db),
+		submarketRepo:    repository.NewSubmarketRepository(db),
+		refreshTokenRepo: repository.NewRefreshTokenRepository(db),
+		sessionRepo:      repository.NewSessionRepository(db),
+		accountService:   NewAccountService(cfg, db),
+		twoFactorService: NewTwoFactorService(cfg, db),
+		throttleService:  NewLoginThrottleService(db),
+		jwtService:       jwtService,
+		hasher:           hasher,
+		mailer:           mail.New(cfg),
+		appName:          cfg.AppName,
+		refreshTokenTTL:  cfg.RefreshTokenTTL,
+		auditHashKey:     []byte(cfg.AuditHashKey),
+		db:               db,
+
+		dummyPasswordHash: dummyPasswordHash,
 	}
 }
 
-func (s *authService) makeMeResource(user *models.User) (*resources.Me, *merr.ResponseError) {
+func makeMeResource(db *gorm.DB, user *models.User) (*resources.Me, *merr.ResponseError) {
 	var err error
-	if err = s.db.Preload("Agent").
+	if err = db.Preload("Agent").
 		Preload("Agent.Address").
 		Preload("Agent.Address.Street").
 		Preload("Agent.Address.Street.Neighborhood").
@@ -61,9 +106,11 @@ func (s *authService) makeMeResource(user *models.User) (*resources.Me, *merr.Re
 	var agent models.Agent = user.Agent
 
 	var me resources.Me = resources.Me{
-		Name:     user.Name,
-		Email:    user.Email,
-		UserType: user.UserType.Type,
+		Name:          user.Name,
+		Email:         user.Email,
+		EmailVerified: user.IsEmailVerified(),
+		UserType:      user.UserType.Type,
+		Role:          user.Role,
 		Address: resources.Address{
 			Cep:          address.Cep,
 			Street:       address.Street.Street,
@@ -85,49 +132,189 @@ func (s *authService) makeMeResource(user *models.User) (*resources.Me, *merr.Re
 	return &me, nil
 }
 
-func (s *authService) Login(request *requests.Login) (*resources.Login, *merr.ResponseError) {
+// Login answers every bad email or password with the same error. Failures are
+// throttled per email and per ip, see LoginThrottleService.
+func (s *authService) Login(request *requests.Login, client *requests.ClientInfo) (*resources.Login, *merr.ResponseError) {
 	var err error
 	var errResponse *merr.ResponseError
 
 	var user *models.User
-	var token string
+	var tokens *resources.Tokens
 	var meResource *resources.Me
 	var response *resources.Login
 
+	if errResponse = s.throttleService.Check(request.Email, client.Ip); errResponse != nil {
+		return nil, errResponse
+	}
+
 	user, err = s.userRepo.FindByEmail(request.Email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	var passwordHash string = s.dummyPasswordHash
+	if user != nil {
+		passwordHash = user.Password
+	}
+
+	matches, err := s.hasher.Verify(request.Password, passwordHash)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidCredentials)
-		}
+		mlog.Log("Failed to verify password: " + err.Error())
 		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
 	}
 
-	if user.Password != Hash256String(request.Password) {
-		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrIncorrectPassword)
+	// Unknown email and wrong password must be told apart by nobody
+	if user == nil || !matches {
+		return nil, s.loginFailed(request.Email, client, user)
 	}
 
-	token, errResponse = s.generateJwtToken(user)
+	if errResponse = s.throttleService.Unlock(request.Email); errResponse != nil {
+		return nil, errResponse
+	}
+
+	if user.IsSuspended() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrUserSuspended)
+	}
+
+	s.rehashPasswordIfNeeded(user, request.Password)
+
+	twoFactorEnabled, errResponse := s.twoFactorService.IsEnabled(user)
+	if errResponse != nil {
+		return nil, errResponse
+	}
+
+	// No tokens until the second factor is checked, the challenge only
+	// proves the password was right and is good for a single attempt.
+	if twoFactorEnabled {
+		challenge, err := createUserToken(s.db, user, models.UserTokenPurposeTwoFactorLogin, twoFactorChallengeTTL)
+		if err != nil {
+			return nil, merr.NewResponseError(http.StatusInternalServerError, ErrFailedToGenerateToken)
+		}
+
+		return &resources.Login{
+			TwoFactorRequired: true,
+			ChallengeToken:    challenge,
+		}, nil
+	}
+
+	tokens, errResponse = s.issueTokens(user, client)
 	if errResponse != nil {
 		return nil, errResponse
 	}
 
-	meResource, errResponse = s.makeMeResource(user)
+	meResource, errResponse = makeMeResource(s.db, user)
 	if errResponse != nil {
 		return nil, errResponse
 	}
 
+	s.recordAuthEvent(client, models.AuditEventLogin, user, user)
+
 	response = &resources.Login{
-		Token: token,
-		User:  *meResource,
+		Token:                       tokens.Token,
+		RefreshToken:                tokens.RefreshToken,
+		User:                        meResource,
+		TwoFactorEnrollmentRequired: s.twoFactorService.IsRequired(user),
 	}
 
 	return response, nil
 }
 
-func (s *authService) SignUp(request *requests.SignUp) (*resources.Login, *merr.ResponseError) {
+func (s *authService) loginFailed(email string, client *requests.ClientInfo, user *models.User) *merr.ResponseError {
+	throttle, errResponse := s.throttleService.RegisterFailure(email, client.Ip)
+	if errResponse != nil {
+		return errResponse
+	}
+
+	// Only a keyed hash of the email is kept, enough to correlate attempts
+	// on the same address without storing it
+	var emailHash string = HmacSha256String(s.auditHashKey, strings.ToLower(strings.TrimSpace(email)))
+	var entry auditEntry = auditEntry{
+		Event: models.AuditEventLoginFailed,
+		After: map[string]string{"email_hash": emailHash},
+	}
+	if user != nil {
+		entry.TargetType = models.AuditTargetUser
+		entry.TargetUuid = user.Uuid
+	}
+	if err := recordAuditEvent(s.db, client, entry); err != nil {
+		mlog.Log("Failed to record failed login: " + err.Error())
+	}
+
+	if user != nil && isFirstLoginLockout(throttle) {
+		dispatchEmail(s.mailer, &mail.Message{
+			To:      user.Email,
+			Subject: fmt.Sprintf("%s - too many failed logins", s.appName),
+			Body: fmt.Sprintf(
+				"Hello %s,\n\nThere were too many failed attempts to log into your account, new attempts will be delayed for a while. If it wasn't you, reset your password, it also unlocks the account.\n",
+				user.Name,
+			),
+		})
+	}
+
+	return merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidCredentials)
+}
+
+func (s *authService) LoginTwoFactor(request *requests.LoginTwoFactor, client *requests.ClientInfo) (*resources.Login, *merr.ResponseError) {
+	var responseError *merr.ResponseError
+	var user *models.User
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		token, err := useUserToken(tx, request.ChallengeToken, models.UserTokenPurposeTwoFactorLogin)
+		if err != nil {
+			if errors.Is(err, ErrInvalidUserToken) {
+				responseError = merr.NewResponseError(http.StatusUnauthorized, ErrInvalidUserToken)
+			}
+			return err
+		}
+
+		user, err = s.userRepo.WithTransaction(tx).FindById(token.UserId)
+		if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+			responseError = merr.NewResponseError(http.StatusUnauthorized, ErrInvalidUserToken)
+		}
+		return err
+	})
+
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	valid, responseError := s.twoFactorService.Verify(user, request.Code)
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	if !valid {
+		s.recordAuthEvent(client, models.AuditEventLoginTwoFactorFail, nil, user)
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidTwoFactorCode)
+	}
+
+	tokens, responseError := s.issueTokens(user, client)
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	meResource, responseError := makeMeResource(s.db, user)
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	s.recordAuthEvent(client, models.AuditEventLoginTwoFactor, user, user)
+
+	return &resources.Login{
+		Token:        tokens.Token,
+		RefreshToken: tokens.RefreshToken,
+		User:         meResource,
+	}, nil
+}
+
+func (s *authService) SignUp(request *requests.SignUp, client *requests.ClientInfo) (*resources.Login, *merr.ResponseError) {
 	var errResponse *merr.ResponseError
 	var user *models.User
-	var token string
+	var tokens *resources.Tokens
 	var meResource *resources.Me
 	var response *resources.Login
 
@@ -136,24 +323,137 @@ func (s *authService) SignUp(request *requests.SignUp) (*resources.Login, *merr.
 		return nil, errResponse
 	}
 
-	token, errResponse = s.generateJwtToken(user)
+	s.recordAuthEvent(client, models.AuditEventSignUp, user, user)
+
+	// The account is usable without verification, the user can ask for a
+	// new link later if this one fails.
+	if errResponse = s.accountService.SendEmailVerification(user); errResponse != nil {
+		mlog.Log("Failed to send email verification: " + errResponse.Message)
+	}
+
+	tokens, errResponse = s.issueTokens(user, client)
 	if errResponse != nil {
 		return nil, errResponse
 	}
 
-	meResource, errResponse = s.makeMeResource(user)
+	meResource, errResponse = makeMeResource(s.db, user)
 	if errResponse != nil {
 		return nil, errResponse
 	}
 
 	response = &resources.Login{
-		Token: token,
-		User:  *meResource,
+		Token:        tokens.Token,
+		RefreshToken: tokens.RefreshToken,
+		User:         meResource,
 	}
 
 	return response, nil
 }
 
+// AcceptInvitation creates the invited user inside the existing Agent, with
+// the role chosen by the inviter and the inviter's user type. Invitations of
+// administrators are refused, they would create other administrators. The
+// email is considered verified since the link was delivered to it.
+func (s *authService) AcceptInvitation(request *requests.AcceptInvitation, client *requests.ClientInfo) (*resources.Login, *merr.ResponseError) {
+	var responseError *merr.ResponseError
+	var user *models.User
+
+	passwordHash, err := s.hasher.Hash(request.Password)
+	if err != nil {
+		mlog.Log("Failed to hash password: " + err.Error())
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var invitationRepo = repository.NewAgentInvitationRepository(tx)
+		var userRepo = s.userRepo.WithTransaction(tx)
+
+		invitation, err := invitationRepo.FindByHashForUpdate(Hash256String(request.Token))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidInvitation)
+			}
+			return err
+		}
+
+		if !invitation.IsPending() {
+			responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidInvitation)
+			return ErrInvalidInvitation
+		}
+
+		inviter, err := userRepo.FindById(invitation.InvitedById)
+		if err != nil {
+			return err
+		}
+
+		inviterType, err := s.userTypeRepo.WithTransaction(tx).FindById(inviter.UserTypeId)
+		if err != nil {
+			return err
+		}
+
+		if inviterType.Type == models.UserTypeAdmin {
+			responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidInvitation)
+			return ErrInvalidInvitation
+		}
+
+		user, err = userRepo.Create(repository.UserCreateParams{
+			Uuid:     NewUuidV7String(),
+			Name:     request.Name,
+			Email:    invitation.Email,
+			Password: passwordHash,
+			Role:     invitation.Role,
+			UserType: &models.UserType{ID: inviter.UserTypeId},
+			Agent:    &models.Agent{Model: gorm.Model{ID: invitation.AgentId}},
+		})
+		if err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrUserAlreadyExists)
+			}
+			return err
+		}
+
+		if err = userRepo.MarkEmailVerified(user); err != nil {
+			return err
+		}
+
+		if err = invitationRepo.MarkAccepted(invitation); err != nil {
+			return err
+		}
+
+		return recordAuditEvent(tx, client, auditEntry{
+			Event:      models.AuditEventInvitationAccepted,
+			Actor:      user,
+			TargetType: models.AuditTargetUser,
+			TargetUuid: user.Uuid,
+			After:      map[string]string{"role": invitation.Role, "invited_by": inviter.Uuid},
+		})
+	})
+
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	tokens, responseError := s.issueTokens(user, client)
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	meResource, responseError := makeMeResource(s.db, user)
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	return &resources.Login{
+		Token:        tokens.Token,
+		RefreshToken: tokens.RefreshToken,
+		User:         meResource,
+	}, nil
+}
+
 func (s *authService) Me(userUuid string) (*resources.Me, *merr.ResponseError) {
 	var response *resources.Me
 	var err error
@@ -168,7 +468,7 @@ func (s *authService) Me(userUuid string) (*resources.Me, *merr.ResponseError) {
 		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
 	}
 
-	response, errResponse = s.makeMeResource(user)
+	response, errResponse = makeMeResource(s.db, user)
 	if errResponse != nil {
 		return nil, errResponse
 	}
@@ -195,22 +495,312 @@ func (s *authService) Availability(request *requests.Availability) (bool, *merr.
 	}
 }
 
-func (s *authService) RefreshToken(oldToken string) (string, *merr.ResponseError) {
-	newToken, err := s.jwtService.RefreshToken(oldToken)
+// RefreshToken rotates the presented refresh token: it is marked as used and
+// a new one from the same family is returned alongside a new access token.
+// Presenting a token that was already used or revoked means it leaked, so the
+// whole family is revoked and the legitimate holder has to log in again.
+func (s *authService) RefreshToken(request *requests.RefreshToken, client *requests.ClientInfo) (*resources.Tokens, *merr.ResponseError) {
+	var responseError *merr.ResponseError
+	var user *models.User
+	var refreshToken string
+	var sessionId string
+	var revokedFamily string
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var refreshTokenRepo = s.refreshTokenRepo.WithTransaction(tx)
+
+		stored, err := refreshTokenRepo.FindByHashForUpdate(Hash256String(request.RefreshToken))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				responseError = merr.NewResponseError(http.StatusUnauthorized, ErrInvalidRefreshToken)
+			}
+			return err
+		}
+
+		if stored.IsUsed() || stored.IsRevoked() {
+			mlog.Log("Refresh token reuse detected, revoking family " + stored.FamilyId)
+			responseError = merr.NewResponseError(http.StatusUnauthorized, ErrRefreshTokenReused)
+
+			if err = refreshTokenRepo.RevokeFamily(stored.FamilyId); err != nil {
+				return err
+			}
+			revokedFamily = stored.FamilyId
+
+			owner, err := s.userRepo.WithTransaction(tx).FindById(stored.UserId)
+			if err != nil {
+				return err
+			}
+
+			return recordAuditEvent(tx, client, auditEntry{
+				Event:      models.AuditEventTokenReused,
+				TargetType: models.AuditTargetUser,
+				TargetUuid: owner.Uuid,
+				After:      map[string]string{"family_id": stored.FamilyId},
+			})
+		}
+
+		if stored.IsExpired() {
+			responseError = merr.NewResponseError(http.StatusUnauthorized, ErrInvalidRefreshToken)
+			return nil
+		}
+
+		if err = refreshTokenRepo.MarkUsed(stored); err != nil {
+			return err
+		}
+
+		user, err = s.userRepo.WithTransaction(tx).FindById(stored.UserId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				responseError = merr.NewResponseError(http.StatusUnauthorized, ErrInvalidRefreshToken)
+			}
+			return err
+		}
+
+		refreshToken, err = s.createRefreshToken(refreshTokenRepo, user, stored.FamilyId)
+		if err != nil {
+			return err
+		}
+
+		sessionId = stored.FamilyId
+		if err = s.saveSession(tx, user, client, sessionId); err != nil {
+			return err
+		}
+
+		return recordAuditEvent(tx, client, auditEntry{
+			Event:      models.AuditEventTokenRefreshed,
+			Actor:      user,
+			TargetType: models.AuditTargetUser,
+			TargetUuid: user.Uuid,
+		})
+	})
+
+	if revokedFamily != "" {
+		forgetCachedSession(revokedFamily)
+	}
+
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	token, responseError := s.generateJwtToken(user, sessionId)
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	return &resources.Tokens{
+		Token:        token,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+func (s *authService) Logout(request *requests.RefreshToken, client *requests.ClientInfo) *merr.ResponseError {
+	var responseError *merr.ResponseError
+	var revokedFamily string
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var refreshTokenRepo = s.refreshTokenRepo.WithTransaction(tx)
+
+		stored, err := refreshTokenRepo.FindByHashForUpdate(Hash256String(request.RefreshToken))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				responseError = merr.NewResponseError(http.StatusUnauthorized, ErrInvalidRefreshToken)
+			}
+			return err
+		}
+
+		if err = refreshTokenRepo.RevokeFamily(stored.FamilyId); err != nil {
+			return err
+		}
+		revokedFamily = stored.FamilyId
+
+		user, err := s.userRepo.WithTransaction(tx).FindById(stored.UserId)
+		if err != nil {
+			return err
+		}
+
+		return recordAuditEvent(tx, client, auditEntry{
+			Event:      models.AuditEventLogout,
+			Actor:      user,
+			TargetType: models.AuditTargetUser,
+			TargetUuid: user.Uuid,
+		})
+	})
+
+	if revokedFamily != "" {
+		forgetCachedSession(revokedFamily)
+	}
+
+	if responseError != nil {
+		return responseError
+	}
+
+	if err != nil {
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return nil
+}
+
+func (s *authService) LogoutAll(user *models.User, client *requests.ClientInfo) *merr.ResponseError {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.refreshTokenRepo.WithTransaction(tx).RevokeAllFromUser(user.ID); err != nil {
+			return err
+		}
+
+		return recordAuditEvent(tx, client, auditEntry{
+			Event:      models.AuditEventLogoutAll,
+			Actor:      user,
+			TargetType: models.AuditTargetUser,
+			TargetUuid: user.Uuid,
+		})
+	})
 	if err != nil {
-		return "", merr.NewResponseError(http.StatusInternalServerError, ErrFailedToGenerateToken)
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	forgetCachedSessionsFromUser(user.ID)
+	return nil
+}
+
+// recordAuthEvent records events that come after the tokens were issued, a
+// failure is only logged since the user is already authenticated.
+func (s *authService) recordAuthEvent(client *requests.ClientInfo, event string, actor *models.User, target *models.User) {
+	err := recordAuditEvent(s.db, client, auditEntry{
+		Event:      event,
+		Actor:      actor,
+		TargetType: models.AuditTargetUser,
+		TargetUuid: target.Uuid,
+	})
+	if err != nil {
+		mlog.Log("Failed to record " + event + ": " + err.Error())
+	}
+}
+
+// issueTokens starts a new session for the user, used on login and sign up.
+// The session uuid is the family of its refresh tokens.
+func (s *authService) issueTokens(user *models.User, client *requests.ClientInfo) (*resources.Tokens, *merr.ResponseError) {
+	var sessionId string = NewUuidV7String()
+
+	token, errResponse := s.generateJwtToken(user, sessionId)
+	if errResponse != nil {
+		return nil, errResponse
+	}
+
+	var refreshToken string
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.saveSession(tx, user, client, sessionId); err != nil {
+			return err
+		}
+
+		var err error
+		refreshToken, err = s.createRefreshToken(s.refreshTokenRepo.WithTransaction(tx), user, sessionId)
+		return err
+	})
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrFailedToGenerateToken)
+	}
+
+	return &resources.Tokens{
+		Token:        token,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// saveSession starts the session or, on refresh, extends it along with its
+// refresh tokens. Families issued before sessions existed get one on their
+// next refresh.
+func (s *authService) saveSession(tx *gorm.DB, user *models.User, client *requests.ClientInfo, sessionId string) error {
+	var sessionRepo = s.sessionRepo.WithTransaction(tx)
+	var now time.Time = utils.NowInLocal()
+
+	session, err := sessionRepo.FindByUuid(sessionId)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		session = &models.Session{Uuid: sessionId, UserId: user.ID}
+	}
+
+	if client != nil {
+		session.Ip = client.Ip
+		session.UserAgent = client.UserAgent
 	}
-	return newToken, nil
+
+	session.LastSeenAt = now
+	session.ExpiresAt = now.Add(s.refreshTokenTTL)
+
+	if session.ID == 0 {
+		return sessionRepo.Create(session)
+	}
+	return sessionRepo.Touch(session)
 }
 
-func (s *authService) generateJwtToken(user *models.User) (string, *merr.ResponseError) {
-	token, err := s.jwtService.GenerateToken(user.Uuid, user.Email, user.UserType.Type)
+func (s *authService) createRefreshToken(repo repository.RefreshTokenRepository, user *models.User, familyId string) (string, error) {
+	token, err := NewOpaqueToken()
+	if err != nil {
+		mlog.Log("Failed to generate refresh token: " + err.Error())
+		return "", err
+	}
+
+	err = repo.Create(&models.RefreshToken{
+		Uuid:      NewUuidV7String(),
+		TokenHash: Hash256String(token),
+		FamilyId:  familyId,
+		ExpiresAt: utils.NowInLocal().Add(s.refreshTokenTTL),
+		UserId:    user.ID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (s *authService) generateJwtToken(user *models.User, sessionId string) (string, *merr.ResponseError) {
+	if user.IsSuspended() {
+		return "", merr.NewResponseError(http.StatusForbidden, ErrUserSuspended)
+	}
+
+	if user.UserType.ID == 0 {
+		if err := s.userRepo.PreloadUserType(user); err != nil {
+			return "", merr.NewResponseError(http.StatusInternalServerError, ErrFailedToGenerateToken)
+		}
+	}
+
+	token, err := s.jwtService.GenerateToken(user.Uuid, user.Email, user.UserType.Type, sessionId)
 	if err != nil {
 		return "", merr.NewResponseError(http.StatusInternalServerError, ErrFailedToGenerateToken)
 	}
 	return token, nil
 }
 
+// rehashPasswordIfNeeded upgrades the stored hash to the configured algorithm
+// and parameters after a successful login. Failures are only logged, the
+// user can still log in with the old hash.
+func (s *authService) rehashPasswordIfNeeded(user *models.User, password string) {
+	if !s.hasher.NeedsRehash(user.Password) {
+		return
+	}
+
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		mlog.Log("Failed to rehash password: " + err.Error())
+		return
+	}
+
+	if err = s.userRepo.UpdatePassword(user, hash); err != nil {
+		return
+	}
+
+	user.Password = hash
+	forgetCachedUser(user.Uuid)
+}
+
 func (s *authService) isAvailable(value string, findFunc func(string) (any, error)) (bool, *merr.ResponseError) {
 	var exists any
 	var err error
@@ -234,6 +824,12 @@ func (s *authService) createUser(request *requests.SignUp) (*models.User, *merr.
 	var responseError *merr.ResponseError
 	var user *models.User
 
+	passwordHash, err := s.hasher.Hash(request.Password)
+	if err != nil {
+		mlog.Log("Failed to hash password: " + err.Error())
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
 	s.db.Transaction(func(tx *gorm.DB) error {
 		var addressRepo = repository.NewAddressRepository(tx)
 		addressModel, err := addressRepo.Create(repository.AddressCreateParams{
@@ -295,7 +891,8 @@ func (s *authService) createUser(request *requests.SignUp) (*models.User, *merr.
 			Uuid:     NewUuidV7String(),
 			Name:     request.Name,
 			Email:    request.Email,
-			Password: Hash256String(request.Password),
+			Password: passwordHash,
+			Role:     models.UserRoleOwner,
 			UserType: userTypeModel,
 			Agent:    agentModel,
 		})
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"ecoply/internal/domain/models"
+	"sync"
+	"time"
+)
+
+const (
+	// Bounds how long a change made by another instance of the API may go
+	// unnoticed, changes made by this one forget the entries right away.
+	authCacheTTL = 30 * time.Second
+
+	authCacheMaxEntries = 10_000
+)
+
+// authCache keeps what the auth middlewares resolve on every request, so
+// most requests don't hit the database. Services that change a user, revoke
+// a session or revoke an api key must forget the entry once the change is
+// committed.
+type authCache[V any] struct {
+	mu      sync.RWMutex
+	entries map[string]authCacheEntry[V]
+}
+
+type authCacheEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+func newAuthCache[V any]() *authCache[V] {
+	return &authCache[V]{entries: make(map[string]authCacheEntry[V])}
+}
+
+// Users by uuid
+var cachedUsers = newAuthCache[models.User]()
+
+// Sessions by uuid, only active ones are cached
+var cachedSessions = newAuthCache[models.Session]()
+
+// Api keys by the hash of the key, with the user they act as
+var cachedApiKeys = newAuthCache[cachedApiKey]()
+
+type cachedApiKey struct {
+	apiKey models.ApiKey
+	user   models.User
+}
+
+// get returns a copy, handlers are free to change what they are given.
+func (c *authCache[V]) get(key string) (*V, bool) {
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+
+	var value V = entry.value
+	return &value, true
+}
+
+func (c *authCache[V]) put(key string, value *V) {
+	var now time.Time = time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= authCacheMaxEntries {
+		for key, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, key)
+			}
+		}
+
+		if len(c.entries) >= authCacheMaxEntries {
+			clear(c.entries)
+		}
+	}
+
+	c.entries[key] = authCacheEntry[V]{
+		value:     *value,
+		expiresAt: now.Add(authCacheTTL),
+	}
+}
+
+func (c *authCache[V]) forget(key string) {
+	c.mu.Lock()
+	delete(c.entries, key)
+	c.mu.Unlock()
+}
+
+func (c *authCache[V]) forgetWhere(match func(value *V) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, entry := range c.entries {
+		if match(&entry.value) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// forgetCachedUser also forgets the api keys of the user, they carry a copy
+// of it.
+func forgetCachedUser(uuid string) {
+	cachedUsers.forget(uuid)
+	cachedApiKeys.forgetWhere(func(entry *cachedApiKey) bool {
+		return entry.user.Uuid == uuid
+	})
+}
+
+func forgetCachedSession(uuid string) {
+	cachedSessions.forget(uuid)
+}
+
+func forgetCachedSessionsFromUser(userId uint) {
+	cachedSessions.forgetWhere(func(session *models.Session) bool {
+		return session.UserId == userId
+	})
+}
+
+func forgetCachedApiKey(keyHash string) {
+	cachedApiKeys.forget(keyHash)
+}
+
+func forgetCachedApiKeysFromUser(userId uint) {
+	cachedApiKeys.forgetWhere(func(entry *cachedApiKey) bool {
+		return entry.apiKey.UserId == userId
+	})
+}
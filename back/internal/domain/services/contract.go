@@ -14,6 +14,7 @@ import (
 
 type ContractService interface {
 	Get(user *models.User, purchaseUuid string) (*resources.Contract, *merr.ResponseError)
+	GetForAdmin(purchaseUuid string) (*resources.Contract, *merr.ResponseError)
 }
 
 type contractService struct {
@@ -33,6 +34,16 @@ func NewContractService(db *gorm.DB) ContractService {
 }
 
 func (s *contractService) Get(user *models.User, purchaseUuid string) (*resources.Contract, *merr.ResponseError) {
+	return s.get(user, purchaseUuid)
+}
+
+// GetForAdmin skips the membership checks, the caller is responsible for
+// making sure only administrators reach it.
+func (s *contractService) GetForAdmin(purchaseUuid string) (*resources.Contract, *merr.ResponseError) {
+	return s.get(nil, purchaseUuid)
+}
+
+func (s *contractService) get(user *models.User, purchaseUuid string) (*resources.Contract, *merr.ResponseError) {
 	var purchase *models.Purchase
 	var offer *models.Offer
 	var buyer *models.User
@@ -72,8 +83,14 @@ func (s *contractService) Get(user *models.User, purchaseUuid string) (*resource
 		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
 	}
 
-	if !purchase.IsOwner(user) && !offer.IsOwner(user) {
-		return nil, merr.NewResponseError(http.StatusForbidden, ErrUserIsNotContractMember)
+	if user != nil {
+		if !purchase.IsOwner(user) && !offer.IsOwner(user) {
+			return nil, merr.NewResponseError(http.StatusForbidden, ErrUserIsNotContractMember)
+		}
+
+		if !user.CanViewFinancials() {
+			return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+		}
 	}
 
 	if err = s.db.Preload("Agent").Preload("Agent.Submarket").First(supplier).Error; err != nil {
@@ -86,6 +103,13 @@ func (s *contractService) Get(user *models.User, purchaseUuid string) (*resource
 		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
 	}
 
+	deliveries, err := s.purchaseRepo.Deliveries(purchase.ID)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	periodStart, periodEnd := purchase.SupplyPeriod(offer)
+
 	var resource *resources.Contract = &resources.Contract{
 		Supplier: resources.ContractSupplier{
 			Uuid:          supplier.Uuid,
@@ -107,11 +131,13 @@ func (s *contractService) Get(user *models.User, purchaseUuid string) (*resource
 			InitialQuantityMwh:    offer.InitialQuantityMwh,
 			ContractedQuantityMwh: purchase.QuantityMwh,
 			Description:           offer.Description,
-			PeriodStart:           offer.PeriodStart.Format(time.DateOnly),
-			PeriodEnd:             offer.PeriodEnd.Format(time.DateOnly),
+			PeriodStart:           periodStart.Format(time.DateOnly),
+			PeriodEnd:             periodEnd.Format(time.DateOnly),
 			EnergyType:            offer.EnergyType.Type,
 			Submarket:             offer.Submarket.Name,
 			CreatedAt:             offer.CreatedAt,
+			DeliveryProfile:       offer.DeliveryProfile,
+			Deliveries:            makePurchaseDeliveryResources(deliveries),
 		},
 	}
 
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/repository"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/resources"
+	"ecoply/internal/matching"
+	"math"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const deliveryMonthLayout string = "2006-01"
+
+// validateDeliveryProfile requires monthly blocks to fall in the months of
+// the period and hourly ones to be hours of the day, each slot once, adding
+// up to the offer quantity. Flat offers take no blocks.
+func validateDeliveryProfile(profile string, blocks []requests.DeliveryBlock, quantity float64, periodStart time.Time, periodEnd time.Time) error {
+	if profile == "" || profile == models.DeliveryProfileFlat {
+		if len(blocks) > 0 {
+			return ErrInvalidDeliveryProfile
+		}
+		return nil
+	}
+
+	if len(blocks) == 0 {
+		return ErrInvalidDeliveryProfile
+	}
+
+	var firstMonth time.Time = time.Date(periodStart.Year(), periodStart.Month(), 1, 0, 0, 0, 0, time.UTC)
+	var lastMonth time.Time = time.Date(periodEnd.Year(), periodEnd.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	var seen map[string]bool = make(map[string]bool, len(blocks))
+	var total int64
+
+	for _, block := range blocks {
+		if seen[block.Slot] {
+			return ErrInvalidDeliveryProfile
+		}
+		seen[block.Slot] = true
+
+		switch profile {
+		case models.DeliveryProfileMonthly:
+			month, err := time.Parse(deliveryMonthLayout, block.Slot)
+			if err != nil || month.Before(firstMonth) || month.After(lastMonth) {
+				return ErrInvalidDeliveryProfile
+			}
+		case models.DeliveryProfileHourly:
+			hour, err := strconv.Atoi(block.Slot)
+			if err != nil || len(block.Slot) != 2 || hour < 0 || hour > 23 {
+				return ErrInvalidDeliveryProfile
+			}
+		}
+
+		total += matching.QuantityToUnits(block.QuantityMwh)
+	}
+
+	if total != matching.QuantityToUnits(quantity) {
+		return ErrInvalidDeliveryProfile
+	}
+
+	return nil
+}
+
+// allocatePurchaseDeliveries splits the purchase over the delivery profile
+// of its offer in proportion to each block, only the months within its
+// negotiated period when it has one. Rounding leftovers go to the largest
+// block so the split adds up to the purchased quantity.
+func allocatePurchaseDeliveries(tx *gorm.DB, purchase *models.Purchase) error {
+	blocks, err := repository.NewOfferRepository(tx).DeliveryBlocks(purchase.OfferId)
+	if err != nil {
+		return err
+	}
+
+	if purchase.PeriodStart != nil && purchase.PeriodEnd != nil {
+		blocks = deliveryBlocksWithin(blocks, *purchase.PeriodStart, *purchase.PeriodEnd)
+	}
+
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	var units []int64 = make([]int64, len(blocks))
+	var total int64
+	for i, block := range blocks {
+		units[i] = matching.QuantityToUnits(block.QuantityMwh)
+		total += units[i]
+	}
+
+	var quantity int64 = matching.QuantityToUnits(purchase.QuantityMwh)
+	var shares []int64 = make([]int64, len(blocks))
+	var allocated int64
+	var largest int
+
+	for i := range blocks {
+		shares[i] = int64(math.Floor(float64(quantity) * float64(units[i]) / float64(total)))
+		allocated += shares[i]
+
+		if units[i] > units[largest] {
+			largest = i
+		}
+	}
+
+	shares[largest] += quantity - allocated
+
+	var deliveries []models.PurchaseDelivery = make([]models.PurchaseDelivery, len(blocks))
+	for i, block := range blocks {
+		deliveries[i] = models.PurchaseDelivery{
+			Slot:        block.Slot,
+			QuantityMwh: matching.QuantityFromUnits(shares[i]),
+			PurchaseId:  purchase.ID,
+		}
+	}
+
+	if err = repository.NewPurchaseRepository(tx).CreateDeliveries(deliveries); err != nil {
+		return err
+	}
+
+	purchase.Deliveries = deliveries
+	return nil
+}
+
+// deliveryBlocksWithin keeps the monthly blocks of the months the period
+// touches. Hourly blocks apply to every day and are all kept.
+func deliveryBlocksWithin(blocks []models.OfferDeliveryBlock, periodStart time.Time, periodEnd time.Time) []models.OfferDeliveryBlock {
+	var firstMonth time.Time = time.Date(periodStart.Year(), periodStart.Month(), 1, 0, 0, 0, 0, time.UTC)
+	var lastMonth time.Time = time.Date(periodEnd.Year(), periodEnd.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	var result []models.OfferDeliveryBlock
+	for _, block := range blocks {
+		month, err := time.Parse(deliveryMonthLayout, block.Slot)
+		if err != nil || (!month.Before(firstMonth) && !month.After(lastMonth)) {
+			result = append(result, block)
+		}
+	}
+	return result
+}
+
+func makeOfferDeliveryBlocksFromRequest(blocks []requests.DeliveryBlock) []models.OfferDeliveryBlock {
+	var result []models.OfferDeliveryBlock = make([]models.OfferDeliveryBlock, len(blocks))
+	for i, block := range blocks {
+		result[i] = models.OfferDeliveryBlock{
+			Slot:        block.Slot,
+			QuantityMwh: block.QuantityMwh,
+		}
+	}
+	return result
+}
+
+func makeOfferDeliveryBlockResources(blocks []models.OfferDeliveryBlock) []resources.DeliveryBlock {
+	var response []resources.DeliveryBlock = make([]resources.DeliveryBlock, len(blocks))
+	for i, block := range blocks {
+		response[i] = resources.DeliveryBlock{
+			Slot:        block.Slot,
+			QuantityMwh: block.QuantityMwh,
+		}
+	}
+	return response
+}
+
+func makePurchaseDeliveryResources(deliveries []models.PurchaseDelivery) []resources.DeliveryBlock {
+	var response []resources.DeliveryBlock = make([]resources.DeliveryBlock, len(deliveries))
+	for i, delivery := range deliveries {
+		response[i] = resources.DeliveryBlock{
+			Slot:        delivery.Slot,
+			QuantityMwh: delivery.QuantityMwh,
+		}
+	}
+	return response
+}
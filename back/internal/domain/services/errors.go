@@ -10,6 +10,11 @@ var (
 	ErrUserNotFound           = errors.New("user not found")
 	ErrUserEmailAlreadyExists = errors.New("email already exists")
 	ErrUserCreationFailed     = errors.New("failed to create user")
+	ErrUserSuspended          = errors.New("user is suspended")
+	ErrCurrentPasswordNeeded  = errors.New("current password is required to change the email")
+	ErrSamePassword           = errors.New("new password must be different from the current one")
+	ErrLastOwnerCannotLeave   = errors.New("transfer the owner role before deleting the account")
+	ErrFailedToExportData     = errors.New("failed to export data")
 
 	// Submarket
 	ErrInvalidSubmarket = errors.New("invalid submarket")
@@ -19,18 +24,53 @@ var (
 
 	// Agent
 	ErrAgentAlreadyExists = errors.New("agent already exists")
+	ErrInsufficientRole   = errors.New("user role does not allow this action")
+	ErrMemberNotFound     = errors.New("member not found")
+	ErrInvitationNotFound = errors.New("invitation not found")
+	ErrInvalidInvitation  = errors.New("invalid or expired invitation")
+	ErrAgentMustHaveOwner = errors.New("agent must keep at least one owner")
+	ErrAdminCannotInvite  = errors.New("administrators can't invite members")
 
 	// User
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrIncorrectPassword  = errors.New("incorrect password")
 	ErrUserAlreadyExists  = errors.New("user already exists")
 
+	// Account
+	ErrInvalidUserToken     = errors.New("invalid or expired token")
+	ErrEmailNotVerified     = errors.New("email is not verified")
+	ErrEmailAlreadyVerified = errors.New("email is already verified")
+	ErrFailedToSendEmail    = errors.New("failed to send email")
+
+	// Two factor
+	ErrTwoFactorAlreadyEnabled     = errors.New("two factor authentication is already enabled")
+	ErrTwoFactorNotEnabled         = errors.New("two factor authentication is not enabled")
+	ErrTwoFactorNotEnrolling       = errors.New("two factor enrollment was not started")
+	ErrTwoFactorMandatory          = errors.New("two factor authentication is mandatory for this user type")
+	ErrTwoFactorEnrollmentRequired = errors.New("two factor authentication must be enabled to use this resource")
+	ErrInvalidTwoFactorCode        = errors.New("invalid two factor code")
+
+	// API key
+	ErrApiKeyNotFound = errors.New("api key not found")
+	ErrInvalidApiKey  = errors.New("invalid, expired or revoked api key")
+
+	// Login throttle
+	ErrTooManyLoginAttempts = errors.New("too many failed login attempts, try again later")
+
 	// Availability
 	ErrInvalidAvailabilityType = errors.New("invalid availability type")
 
 	// JWT
 	ErrFailedToGenerateToken = errors.New("failed to generate token")
 
+	// Refresh token
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused, all sessions of this login were revoked")
+
+	// Session
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionRevoked  = errors.New("session was revoked or has expired")
+
 	// EnergyType
 	ErrInvalidEnergyType = errors.New("invalid energy type")
 
@@ -47,12 +87,73 @@ var (
 	ErrCannotPurchaseOwnOffer    = errors.New("cannot purchase own offer")
 	ErrCannotUpdateOffer         = errors.New("offer can't be updated")
 	ErrOfferHasEnded             = errors.New("offer has ended")
+	ErrAllowedAgentNotFound      = errors.New("allowed agent not found")
+	ErrInvalidPriceTiers         = errors.New("price tiers must have growing quantities and falling prices below the offer price")
+	ErrInvalidLotSize            = errors.New("minimum and step quantities must be within the offer quantity")
+	ErrQuantityNotValidLot       = errors.New("quantity is below the minimum lot or not a multiple of the step")
+	ErrMissingDescription        = errors.New("description is required")
+	ErrInvalidImportFile         = errors.New("file must be a CSV with a header and at least one row")
+	ErrTooManyImportRows         = errors.New("too many rows, import at most 500 offers at once")
+	ErrInvalidDeliveryProfile    = errors.New("delivery blocks must be unique slots of the profile within the period and sum to the offer quantity")
+	ErrInvalidPublishAt          = errors.New("publish time must be in the future and within the offer period, auctions can't be scheduled")
+	ErrOfferIsPaused             = errors.New("offer is paused")
+	ErrOfferCannotBePaused       = errors.New("offer can't be paused")
+	ErrOfferIsNotPaused          = errors.New("offer is not paused")
+
+	// Offer template
+	ErrOfferTemplateNotFound      = errors.New("offer template not found")
+	ErrOfferTemplateNotRecurring  = errors.New("offer template has no recurrence")
+	ErrOfferTemplateAlreadyPaused = errors.New("offer template is already paused")
+	ErrOfferTemplateIsNotPaused   = errors.New("offer template is not paused")
 
 	// Purchase
 	ErrUserIsNotThePurchaseOwner = errors.New("user is not the purchase owner")
 	ErrPurchaseNotFound          = errors.New("purchase not found")
 	ErrPurchaseCannotBeCancelled = errors.New("purchase can not be cancelled")
 
+	// Rfq
+	ErrRfqNotFound           = errors.New("rfq not found")
+	ErrRfqIsNotOpen          = errors.New("rfq is no longer open")
+	ErrUserIsNotTheRfqOwner  = errors.New("user is not the rfq owner")
+	ErrCannotQuoteOwnRfq     = errors.New("cannot quote own rfq")
+	ErrQuoteAboveMaxPrice    = errors.New("quote price is above the rfq max price")
+	ErrQuoteAlreadySubmitted = errors.New("company already has a pending quote for this rfq")
+	ErrRfqQuoteNotFound      = errors.New("rfq quote not found")
+	ErrRfqQuoteIsNotPending  = errors.New("rfq quote is no longer pending")
+
+	// Negotiation
+	ErrNegotiationNotFound           = errors.New("negotiation not found")
+	ErrNegotiationIsNotOpen          = errors.New("negotiation is no longer open")
+	ErrNegotiationAlreadyOpen        = errors.New("company already has an open negotiation for this offer")
+	ErrCannotNegotiateOwnOffer       = errors.New("cannot negotiate own offer")
+	ErrUserIsNotNegotiationMember    = errors.New("user is not a member of the negotiation")
+	ErrProposalAwaitsOtherParty      = errors.New("the pending proposal is waiting for the other party")
+	ErrInvalidProposalExpiry         = errors.New("proposal expiry must be in the future")
+	ErrProposalPeriodOutsideOffer    = errors.New("proposal period must be within the offer period")
+	ErrProposalPeriodWithoutDelivery = errors.New("offer delivers nothing in the proposal period")
+
+	// Auction
+	ErrInvalidAuction          = errors.New("invalid auction settings")
+	ErrOfferIsAuction          = errors.New("offer is sold by auction")
+	ErrOfferIsNotAuction       = errors.New("offer is not an auction")
+	ErrAuctionIsNotRunning     = errors.New("auction is not running")
+	ErrCannotBidOnOwnAuction   = errors.New("cannot bid on own auction")
+	ErrAuctionBidTooLow        = errors.New("bid is below the minimum accepted price")
+	ErrAuctionBidAlreadyPlaced = errors.New("company already has a bid on this sealed auction")
+
+	// Order book
+	ErrBidNotFound          = errors.New("bid not found")
+	ErrBidIsNotOpen         = errors.New("bid is no longer open")
+	ErrUserIsNotTheBidOwner = errors.New("user is not the bid owner")
+
+	// Admin
+	ErrUserAlreadySuspended  = errors.New("user is already suspended")
+	ErrUserIsNotSuspended    = errors.New("user is not suspended")
+	ErrCannotSuspendAdmin    = errors.New("admins can't be suspended")
+	ErrOfferCannotBeExpired  = errors.New("offer can't be expired")
+	ErrOfferAlreadyTakenDown = errors.New("offer was already taken down")
+	ErrPurchaseIsNotWaiting  = errors.New("purchase is not waiting for payment")
+
 	// Contract
 	ErrUserIsNotContractMember = errors.New("user is not a member of the contract")
 	ErrPurchaseIsNotCompleted  = errors.New("purchase is not completed")
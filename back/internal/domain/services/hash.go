@@ -1,11 +1,16 @@
 package services
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
 	"ecoply/internal/mlog"
+	"encoding/base64"
 	"fmt"
 )
 
+// Hash256String must not be used for passwords, see PasswordHasher. It is
+// fine for high entropy secrets such as the ones from NewOpaqueToken.
 func Hash256String(input string) string {
 	hash := sha256.New()
 	_, err := hash.Write([]byte(input))
@@ -15,3 +20,20 @@ func Hash256String(input string) string {
 	}
 	return fmt.Sprintf("%x", hash.Sum(nil))
 }
+
+// HmacSha256String hashes low entropy values, such as emails, that must not
+// be recoverable by hashing guesses without the key.
+func HmacSha256String(key []byte, input string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(input))
+	return fmt.Sprintf("%x", mac.Sum(nil))
+}
+
+// NewOpaqueToken returns a random url-safe token with 256 bits of entropy.
+func NewOpaqueToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
@@ -2,7 +2,10 @@ package services
 
 import (
 	"ecoply/internal/config"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/resources"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -11,41 +14,66 @@ import (
 var (
 	ErrInvalidSigningMethod = errors.New("invalid signing method")
 	ErrInvalidToken         = errors.New("invalid token")
+	ErrFailedToLoadJwtKeys  = errors.New("failed to load jwt keys")
 )
 
 type Claims struct {
 	UserUuid  string `json:"user_uuid"`
 	UserEmail string `json:"user_email"`
 	UserType  string `json:"user_type"`
+	SessionId string `json:"sid,omitempty"`
+
+	Permissions []string `json:"permissions"`
 	jwt.RegisteredClaims
 }
 
 type JwtService interface {
-	GenerateToken(userUuid string, email string, userType string) (string, error)
-	RefreshToken(oldToken string) (string, error)
+	GenerateToken(userUuid string, email string, userType string, sessionId string) (string, error)
 	ValidateToken(tokenString string) (*Claims, error)
+	Jwks() *resources.Jwks
 }
 
 type jwtService struct {
-	signingKey []byte
-	issuer     string
+	keyring *Keyring
+	issuer  string
+	ttl     time.Duration
 }
 
-func NewJwtService(cfg *config.Config) JwtService {
-	return &jwtService{
-		signingKey: []byte(cfg.JWTSigningKey),
-		issuer:     cfg.AppName,
+// NewJwtService signs with the active key of JWT_KEYS_DIR when it is set,
+// otherwise it falls back to HS256 with JWT_SIGNING_KEY. The keyring is read
+// once, build a single service at startup and share it.
+func NewJwtService(cfg *config.Config) (JwtService, error) {
+	var keyring *Keyring
+
+	if cfg.JWTKeysDir == "" {
+		keyring = NewHmacKeyring([]byte(cfg.JWTSigningKey))
+	} else {
+		var err error
+		keyring, err = LoadKeyring(cfg.JWTKeysDir, cfg.JWTActiveKeyId)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrFailedToLoadJwtKeys, err)
+		}
 	}
+
+	return &jwtService{
+		keyring: keyring,
+		issuer:  cfg.AppName,
+		ttl:     cfg.JWTAccessTokenTTL,
+	}, nil
 }
 
-func (j *jwtService) GenerateToken(userUuid string, email string, userType string) (string, error) {
+func (j *jwtService) GenerateToken(userUuid string, email string, userType string, sessionId string) (string, error) {
 	now := time.Now()
-	expirationTime := now.Add(24 * time.Hour)
+	expirationTime := now.Add(j.ttl)
 
 	claims := &Claims{
 		UserUuid:  userUuid,
 		UserEmail: email,
 		UserType:  userType,
+		SessionId: sessionId,
+
+		Permissions: models.PermissionsForUserType(userType),
+
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -55,9 +83,12 @@ func (j *jwtService) GenerateToken(userUuid string, email string, userType strin
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	var key *JwtKey = j.keyring.Active()
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.Id
 
-	tokenString, err := token.SignedString(j.signingKey)
+	tokenString, err := token.SignedString(key.PrivateKey)
 	if err != nil {
 		return "", err
 	}
@@ -67,10 +98,22 @@ func (j *jwtService) GenerateToken(userUuid string, email string, userType strin
 
 func (j *jwtService) ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			// Tokens issued before key ids existed
+			kid = legacyHmacKeyId
+		}
+
+		key, err := j.keyring.Find(kid)
+		if err != nil {
+			return nil, err
+		}
+
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, ErrInvalidSigningMethod
 		}
-		return j.signingKey, nil
+
+		return key.PublicKey, nil
 	})
 
 	if err != nil {
@@ -84,20 +127,6 @@ func (j *jwtService) ValidateToken(tokenString string) (*Claims, error) {
 	return nil, ErrInvalidToken
 }
 
-func (j *jwtService) RefreshToken(oldToken string) (string, error) {
-	claims, err := j.ValidateToken(oldToken)
-	if err != nil {
-		return "", err
-	}
-
-	if time.Until(claims.ExpiresAt.Time) > 5*time.Minute {
-		return oldToken, nil
-	}
-
-	newToken, err := j.GenerateToken(claims.UserUuid, claims.UserEmail, claims.UserType)
-	if err != nil {
-		return "", err
-	}
-
-	return newToken, nil
+func (j *jwtService) Jwks() *resources.Jwks {
+	return j.keyring.Jwks()
 }
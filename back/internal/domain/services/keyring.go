@@ -0,0 +1,192 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"ecoply/internal/domain/resources"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const legacyHmacKeyId = "hs256"
+
+var (
+	ErrJwtKeysDirEmpty      = errors.New("jwt keys directory has no keys")
+	ErrJwtActiveKeyNotFound = errors.New("jwt active key not found in keys directory")
+	ErrJwtActiveKeyNoSigner = errors.New("jwt active key has no private key")
+	ErrJwtUnsupportedKey    = errors.New("unsupported jwt key type")
+	ErrJwtInvalidPem        = errors.New("invalid jwt key pem")
+	ErrJwtUnknownKeyId      = errors.New("unknown jwt key id")
+)
+
+// JwtKey is one entry of the keyring. Retired keys are kept only to verify
+// tokens issued before a rotation, they may have no private key at all.
+type JwtKey struct {
+	Id         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.PrivateKey
+	PublicKey  crypto.PublicKey
+}
+
+type Keyring struct {
+	active *JwtKey
+	keys   map[string]*JwtKey
+}
+
+func NewHmacKeyring(secret []byte) *Keyring {
+	var key *JwtKey = &JwtKey{
+		Id:         legacyHmacKeyId,
+		Method:     jwt.SigningMethodHS256,
+		PrivateKey: secret,
+		PublicKey:  secret,
+	}
+
+	return &Keyring{
+		active: key,
+		keys:   map[string]*JwtKey{key.Id: key},
+	}
+}
+
+// LoadKeyring reads every "<kid>.pem" file of dir. A file may hold a PKCS#1
+// or PKCS#8 private key (RSA or Ed25519) or a PKIX public key, the latter
+// being enough for retired keys. The key named by activeKeyId signs new
+// tokens, every other key is verification only.
+func LoadKeyring(dir string, activeKeyId string) (*Keyring, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, ErrJwtKeysDirEmpty
+	}
+
+	var keyring *Keyring = &Keyring{keys: make(map[string]*JwtKey, len(files))}
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var id string = strings.TrimSuffix(filepath.Base(file), ".pem")
+		key, err := parseJwtKey(id, content)
+		if err != nil {
+			return nil, errors.Join(err, errors.New(file))
+		}
+
+		keyring.keys[id] = key
+	}
+
+	active, ok := keyring.keys[activeKeyId]
+	if !ok {
+		return nil, ErrJwtActiveKeyNotFound
+	}
+
+	if active.PrivateKey == nil {
+		return nil, ErrJwtActiveKeyNoSigner
+	}
+
+	keyring.active = active
+
+	return keyring, nil
+}
+
+func (k *Keyring) Active() *JwtKey {
+	return k.active
+}
+
+func (k *Keyring) Find(id string) (*JwtKey, error) {
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, ErrJwtUnknownKeyId
+	}
+	return key, nil
+}
+
+// Jwks lists the public part of every asymmetric key, active and retired,
+// so verifiers keep accepting tokens signed before a rotation.
+func (k *Keyring) Jwks() *resources.Jwks {
+	var ids []string = make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var jwks resources.Jwks = resources.Jwks{Keys: make([]resources.Jwk, 0, len(ids))}
+
+	for _, id := range ids {
+		var key *JwtKey = k.keys[id]
+
+		switch publicKey := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwks.Keys = append(jwks.Keys, resources.Jwk{
+				Kty: "RSA",
+				Kid: key.Id,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks.Keys = append(jwks.Keys, resources.Jwk{
+				Kty: "OKP",
+				Kid: key.Id,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(publicKey),
+			})
+		}
+	}
+
+	return &jwks
+}
+
+func parseJwtKey(id string, content []byte) (*JwtKey, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, ErrJwtInvalidPem
+	}
+
+	var parsed any
+	var err error
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, ErrJwtUnsupportedKey
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &JwtKey{Id: id, Method: jwt.SigningMethodRS256, PrivateKey: key, PublicKey: &key.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &JwtKey{Id: id, Method: jwt.SigningMethodRS256, PublicKey: key}, nil
+	case ed25519.PrivateKey:
+		return &JwtKey{Id: id, Method: jwt.SigningMethodEdDSA, PrivateKey: key, PublicKey: key.Public()}, nil
+	case ed25519.PublicKey:
+		return &JwtKey{Id: id, Method: jwt.SigningMethodEdDSA, PublicKey: key}, nil
+	default:
+		return nil, ErrJwtUnsupportedKey
+	}
+}
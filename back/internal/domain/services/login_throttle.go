@@ -0,0 +1,157 @@
+package services
+
+import (
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/repository"
+	"ecoply/internal/domain/utils"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// Failures allowed before the backoff starts. An IP is given more room
+	// since many users may share it behind a NAT.
+	loginAccountFreeAttempts = 5
+	loginIpFreeAttempts      = 20
+
+	loginBackoffBase = 30 * time.Second
+	loginMaxLockout  = time.Hour
+
+	// Failures older than this are forgotten
+	loginFailureWindow = 24 * time.Hour
+)
+
+// LoginThrottleService tracks failed logins per email and per IP. Each
+// failure past the free attempts doubles the time the email or IP is locked
+// out, up to loginMaxLockout.
+type LoginThrottleService interface {
+	Check(email string, ip string) *merr.ResponseError
+	RegisterFailure(email string, ip string) (*models.LoginThrottle, *merr.ResponseError)
+	Unlock(email string) *merr.ResponseError
+}
+
+type loginThrottleService struct {
+	loginThrottleRepo repository.LoginThrottleRepository
+	db                *gorm.DB
+}
+
+func NewLoginThrottleService(db *gorm.DB) LoginThrottleService {
+	return &loginThrottleService{
+		loginThrottleRepo: repository.NewLoginThrottleRepository(db),
+		db:                db,
+	}
+}
+
+func (s *loginThrottleService) Check(email string, ip string) *merr.ResponseError {
+	for _, scope := range []string{models.LoginThrottleScopeAccount, models.LoginThrottleScopeIp} {
+		var identifier string = loginThrottleIdentifier(scope, email, ip)
+
+		throttle, err := s.loginThrottleRepo.Find(scope, identifier)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+		}
+
+		if throttle.IsLocked() {
+			return merr.NewResponseError(http.StatusTooManyRequests, ErrTooManyLoginAttempts)
+		}
+	}
+
+	return nil
+}
+
+// RegisterFailure returns the account throttle so the caller can tell when
+// the email has just been locked out.
+func (s *loginThrottleService) RegisterFailure(email string, ip string) (*models.LoginThrottle, *merr.ResponseError) {
+	var accountThrottle *models.LoginThrottle
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var loginThrottleRepo = s.loginThrottleRepo.WithTransaction(tx)
+		var now time.Time = utils.NowInLocal()
+
+		for _, scope := range []string{models.LoginThrottleScopeAccount, models.LoginThrottleScopeIp} {
+			throttle, err := loginThrottleRepo.FindOrCreateForUpdate(scope, loginThrottleIdentifier(scope, email, ip))
+			if err != nil {
+				return err
+			}
+
+			if throttle.LastFailureAt != nil && now.Sub(*throttle.LastFailureAt) > loginFailureWindow {
+				throttle.Failures = 0
+			}
+
+			throttle.Failures++
+			throttle.LastFailureAt = &now
+			throttle.LockedUntil = nil
+
+			if lockout := loginLockoutDuration(scope, throttle.Failures); lockout > 0 {
+				var lockedUntil time.Time = now.Add(lockout)
+				throttle.LockedUntil = &lockedUntil
+			}
+
+			if err = loginThrottleRepo.Save(throttle); err != nil {
+				return err
+			}
+
+			if scope == models.LoginThrottleScopeAccount {
+				accountThrottle = throttle
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return accountThrottle, nil
+}
+
+// Unlock clears the failures of an email, after a password reset or by an
+// administrator. IP throttles are left to expire by themselves.
+func (s *loginThrottleService) Unlock(email string) *merr.ResponseError {
+	var identifier string = loginThrottleIdentifier(models.LoginThrottleScopeAccount, email, "")
+
+	if err := s.loginThrottleRepo.Reset(models.LoginThrottleScopeAccount, identifier); err != nil {
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return nil
+}
+
+func loginThrottleIdentifier(scope string, email string, ip string) string {
+	if scope == models.LoginThrottleScopeIp {
+		return ip
+	}
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func loginLockoutDuration(scope string, failures int) time.Duration {
+	var freeAttempts int = loginAccountFreeAttempts
+	if scope == models.LoginThrottleScopeIp {
+		freeAttempts = loginIpFreeAttempts
+	}
+
+	if failures < freeAttempts {
+		return 0
+	}
+
+	var lockout time.Duration = loginBackoffBase
+	for i := freeAttempts; i < failures && lockout < loginMaxLockout; i++ {
+		lockout *= 2
+	}
+
+	return min(lockout, loginMaxLockout)
+}
+
+// isFirstLoginLockout tells whether the failure that produced throttle is the
+// one that started the lockout.
+func isFirstLoginLockout(throttle *models.LoginThrottle) bool {
+	return throttle != nil && throttle.Failures == loginAccountFreeAttempts
+}
@@ -0,0 +1,586 @@
+package services
+
+import (
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/repository"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/resources"
+	"ecoply/internal/domain/utils"
+	"errors"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// How long a proposal waits for an answer when it doesn't say
+const defaultProposalTtl time.Duration = time.Hour * 48
+
+type NegotiationService interface {
+	Open(user *models.User, offerUuid string, request *requests.OpenNegotiation) (*resources.Negotiation, *merr.ResponseError)
+	List(user *models.User, offerUuid string) ([]*resources.Negotiation, *merr.ResponseError)
+	GetByUuid(user *models.User, uuid string) (*resources.Negotiation, *merr.ResponseError)
+	Counter(user *models.User, uuid string, request *requests.NegotiationProposal) (*resources.Negotiation, *merr.ResponseError)
+	Accept(user *models.User, client *requests.ClientInfo, uuid string) (*resources.Purchase, *merr.ResponseError)
+	Reject(user *models.User, uuid string) *merr.ResponseError
+	Cancel(user *models.User, uuid string) *merr.ResponseError
+	UpdateExpiredNegotiations() error
+}
+
+type negotiationService struct {
+	negotiationRepo repository.NegotiationRepository
+	offerRepo       repository.OfferRepository
+	purchaseRepo    repository.PurchaseRepository
+	purchaseService *purchaseService
+	db              *gorm.DB
+}
+
+func NewNegotiationService(db *gorm.DB) NegotiationService {
+	return &negotiationService{
+		negotiationRepo: repository.NewNegotiationRepository(db),
+		offerRepo:       repository.NewOfferRepository(db),
+		purchaseRepo:    repository.NewPurchaseRepository(db),
+		purchaseService: newPurchaseService(db),
+		db:              db,
+	}
+}
+
+// Open starts a negotiation on the offer with the buyer's first proposal. A
+// company may only have one open negotiation per offer.
+func (s *negotiationService) Open(user *models.User, offerUuid string, request *requests.OpenNegotiation) (*resources.Negotiation, *merr.ResponseError) {
+	var responseError *merr.ResponseError
+	var negotiation *models.Negotiation
+
+	if !user.CanTrade() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	if !user.IsEmailVerified() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrEmailNotVerified)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var negotiationRepo = s.negotiationRepo.WithTransaction(tx)
+
+		offer, err := s.offerRepo.WithTransaction(tx).FindByUuidForUpdate(offerUuid)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				responseError = merr.NewResponseError(http.StatusNotFound, ErrOfferNotFound)
+			}
+			return err
+		}
+
+		if responseError = checkOfferVisibility(s.offerRepo.WithTransaction(tx), offer, user); responseError != nil {
+			return responseError.Error
+		}
+
+		if offer.IsOwner(user) {
+			responseError = merr.NewResponseError(http.StatusForbidden, ErrCannotNegotiateOwnOffer)
+			return ErrCannotNegotiateOwnOffer
+		}
+
+		proposal, responseErr := newNegotiationProposal(offer, user, false, &request.NegotiationProposal)
+		if responseErr != nil {
+			responseError = responseErr
+			return responseErr.Error
+		}
+
+		open, err := negotiationRepo.HasOpenFromAgent(offer.ID, user.AgentId)
+		if err != nil {
+			return err
+		}
+
+		if open {
+			responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrNegotiationAlreadyOpen)
+			return ErrNegotiationAlreadyOpen
+		}
+
+		negotiation = &models.Negotiation{
+			Uuid:          NewUuidV7String(),
+			Status:        models.NegotiationStatusOpen,
+			PaymentMethod: request.PaymentMethod,
+			OfferId:       offer.ID,
+			BuyerId:       user.ID,
+		}
+
+		if err = negotiationRepo.Create(negotiation); err != nil {
+			return err
+		}
+
+		proposal.NegotiationId = negotiation.ID
+
+		return negotiationRepo.CreateProposal(proposal)
+	})
+
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return s.GetByUuid(user, negotiation.Uuid)
+}
+
+// List shows every negotiation of the offer to the seller's company, and
+// only their own to buyers.
+func (s *negotiationService) List(user *models.User, offerUuid string) ([]*resources.Negotiation, *merr.ResponseError) {
+	offer, err := s.offerRepo.GetByUuid(offerUuid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, merr.NewResponseError(http.StatusNotFound, ErrOfferNotFound)
+		}
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	var negotiations []*models.Negotiation
+
+	if offer.IsOwner(user) {
+		negotiations, err = s.negotiationRepo.ListFromOffer(offer.ID)
+	} else {
+		negotiations, err = s.negotiationRepo.ListFromOfferAndAgent(offer.ID, user.AgentId)
+	}
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	response := make([]*resources.Negotiation, 0, len(negotiations))
+	for _, negotiation := range negotiations {
+		response = append(response, makeNegotiationResourceFromModel(negotiation))
+	}
+
+	return response, nil
+}
+
+func (s *negotiationService) GetByUuid(user *models.User, uuid string) (*resources.Negotiation, *merr.ResponseError) {
+	negotiation, err := s.negotiationRepo.FindByUuid(uuid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, merr.NewResponseError(http.StatusNotFound, ErrNegotiationNotFound)
+		}
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if !negotiation.IsBuyer(user) && !negotiation.Offer.IsOwner(user) {
+		return nil, merr.NewResponseError(http.StatusNotFound, ErrNegotiationNotFound)
+	}
+
+	return makeNegotiationResourceFromModel(negotiation), nil
+}
+
+// Counter answers the pending proposal with new terms, which now wait for
+// the other side.
+func (s *negotiationService) Counter(user *models.User, uuid string, request *requests.NegotiationProposal) (*resources.Negotiation, *merr.ResponseError) {
+	var responseError *merr.ResponseError
+
+	if !user.CanTrade() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var negotiationRepo = s.negotiationRepo.WithTransaction(tx)
+
+		negotiation, pending, responseErr := s.findProposalToAnswer(tx, user, uuid)
+		if responseErr != nil {
+			responseError = responseErr
+			return responseErr.Error
+		}
+
+		offer, err := s.offerRepo.WithTransaction(tx).FindByIdForUpdate(negotiation.OfferId)
+		if err != nil {
+			return err
+		}
+
+		proposal, responseErr := newNegotiationProposal(offer, user, !pending.FromSeller, request)
+		if responseErr != nil {
+			responseError = responseErr
+			return responseErr.Error
+		}
+
+		pending.Status = models.NegotiationProposalStatusCountered
+		if err = negotiationRepo.UpdateProposal(pending); err != nil {
+			return err
+		}
+
+		proposal.NegotiationId = negotiation.ID
+
+		return negotiationRepo.CreateProposal(proposal)
+	})
+
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return s.GetByUuid(user, uuid)
+}
+
+// Accept agrees to the pending proposal. In a single transaction the buyer's
+// purchase is made at the negotiated price and its quantity is taken out of
+// the offer.
+func (s *negotiationService) Accept(user *models.User, client *requests.ClientInfo, uuid string) (*resources.Purchase, *merr.ResponseError) {
+	var responseError *merr.ResponseError
+	var negotiation *models.Negotiation
+	var purchase *models.Purchase
+
+	if !user.CanTrade() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var negotiationRepo = s.negotiationRepo.WithTransaction(tx)
+		var offerRepo = s.offerRepo.WithTransaction(tx)
+
+		found, pending, responseErr := s.findProposalToAnswer(tx, user, uuid)
+		if responseErr != nil {
+			responseError = responseErr
+			return responseErr.Error
+		}
+		negotiation = found
+
+		if negotiation.Buyer.IsSuspended() || negotiation.Buyer.IsAnonymized() {
+			responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrNegotiationIsNotOpen)
+			return ErrNegotiationIsNotOpen
+		}
+
+		offer, err := offerRepo.FindByIdForUpdate(negotiation.OfferId)
+		if err != nil {
+			return err
+		}
+
+		if responseErr = checkOfferIsNegotiable(offer); responseErr != nil {
+			responseError = responseErr
+			return responseErr.Error
+		}
+
+		if offer.RemainingQuantityMwh < pending.QuantityMwh {
+			responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrInsufficientOfferQuantity)
+			return ErrInsufficientOfferQuantity
+		}
+
+		if err = offer.Sell(pending.QuantityMwh); err != nil {
+			return err
+		}
+
+		if err = offerRepo.Update(offer); err != nil {
+			return err
+		}
+
+		purchase = &models.Purchase{
+			Uuid:          NewUuidV7String(),
+			OfferId:       offer.ID,
+			PricePerMwh:   pending.PricePerMwh,
+			PaymentMethod: negotiation.PaymentMethod,
+			Status:        models.PurchaseStatusWaiting,
+			BuyerId:       negotiation.BuyerId,
+			QuantityMwh:   pending.QuantityMwh,
+			PeriodStart:   &pending.PeriodStart,
+			PeriodEnd:     &pending.PeriodEnd,
+		}
+
+		if err = s.purchaseRepo.WithTransaction(tx).Create(purchase); err != nil {
+			return err
+		}
+
+		if err = allocatePurchaseDeliveries(tx, purchase); err != nil {
+			return err
+		}
+
+		pending.Status = models.NegotiationProposalStatusAccepted
+		if err = negotiationRepo.UpdateProposal(pending); err != nil {
+			return err
+		}
+
+		negotiation.Status = models.NegotiationStatusAccepted
+		negotiation.PurchaseId = &purchase.ID
+		if err = negotiationRepo.Update(negotiation); err != nil {
+			return err
+		}
+
+		err = recordAuditEvent(tx, client, auditEntry{
+			Event:      models.AuditEventNegotiationAccepted,
+			Actor:      user,
+			TargetType: models.AuditTargetNegotiation,
+			TargetUuid: negotiation.Uuid,
+			After: map[string]any{
+				"proposal_uuid": pending.Uuid,
+				"purchase_uuid": purchase.Uuid,
+				"price_per_mwh": pending.PricePerMwh,
+				"quantity_mwh":  pending.QuantityMwh,
+				"period_start":  pending.PeriodStart.Format(time.DateOnly),
+				"period_end":    pending.PeriodEnd.Format(time.DateOnly),
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		return preloadPurchaseResource(tx, purchase)
+	})
+
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	s.purchaseService.dispatchPurchasePaymentProcessor(&negotiation.Buyer, purchase)
+
+	return makePurchaseResourceFromModel(purchase), nil
+}
+
+// Reject turns down the pending proposal, which ends the negotiation.
+func (s *negotiationService) Reject(user *models.User, uuid string) *merr.ResponseError {
+	var responseError *merr.ResponseError
+
+	if !user.CanTrade() {
+		return merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var negotiationRepo = s.negotiationRepo.WithTransaction(tx)
+
+		negotiation, pending, responseErr := s.findProposalToAnswer(tx, user, uuid)
+		if responseErr != nil {
+			responseError = responseErr
+			return responseErr.Error
+		}
+
+		pending.Status = models.NegotiationProposalStatusRejected
+		if err := negotiationRepo.UpdateProposal(pending); err != nil {
+			return err
+		}
+
+		negotiation.Status = models.NegotiationStatusRejected
+		return negotiationRepo.Update(negotiation)
+	})
+
+	if responseError != nil {
+		return responseError
+	}
+
+	if err != nil {
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return nil
+}
+
+// Cancel lets the buyer walk away from the negotiation at any turn.
+func (s *negotiationService) Cancel(user *models.User, uuid string) *merr.ResponseError {
+	var responseError *merr.ResponseError
+
+	if !user.CanTrade() {
+		return merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var negotiationRepo = s.negotiationRepo.WithTransaction(tx)
+
+		negotiation, err := negotiationRepo.FindByUuidForUpdate(uuid)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				responseError = merr.NewResponseError(http.StatusNotFound, ErrNegotiationNotFound)
+			}
+			return err
+		}
+
+		if !negotiation.IsBuyer(user) {
+			responseError = merr.NewResponseError(http.StatusForbidden, ErrUserIsNotNegotiationMember)
+			return ErrUserIsNotNegotiationMember
+		}
+
+		if !negotiation.IsOpen() {
+			responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrNegotiationIsNotOpen)
+			return ErrNegotiationIsNotOpen
+		}
+
+		pending, err := negotiationRepo.FindPendingProposal(negotiation.ID)
+		if err == nil {
+			pending.Status = models.NegotiationProposalStatusCancelled
+			if err = negotiationRepo.UpdateProposal(pending); err != nil {
+				return err
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		negotiation.Status = models.NegotiationStatusCancelled
+		return negotiationRepo.Update(negotiation)
+	})
+
+	if responseError != nil {
+		return responseError
+	}
+
+	if err != nil {
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return nil
+}
+
+func (s *negotiationService) UpdateExpiredNegotiations() error {
+	return s.negotiationRepo.UpdateExpiredNegotiations()
+}
+
+// findProposalToAnswer locks the negotiation and returns its pending
+// proposal when it is the user's side turn to answer it.
+func (s *negotiationService) findProposalToAnswer(
+	tx *gorm.DB,
+	user *models.User,
+	uuid string,
+) (*models.Negotiation, *models.NegotiationProposal, *merr.ResponseError) {
+	var negotiationRepo = s.negotiationRepo.WithTransaction(tx)
+
+	negotiation, err := negotiationRepo.FindByUuidForUpdate(uuid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, merr.NewResponseError(http.StatusNotFound, ErrNegotiationNotFound)
+		}
+		return nil, nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	var isSeller bool = negotiation.Offer.IsOwner(user)
+
+	if !isSeller && !negotiation.IsBuyer(user) {
+		return nil, nil, merr.NewResponseError(http.StatusForbidden, ErrUserIsNotNegotiationMember)
+	}
+
+	if !negotiation.IsOpen() {
+		return nil, nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrNegotiationIsNotOpen)
+	}
+
+	pending, err := negotiationRepo.FindPendingProposal(negotiation.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrNegotiationIsNotOpen)
+		}
+		return nil, nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if pending.IsExpired() {
+		return nil, nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrNegotiationIsNotOpen)
+	}
+
+	if pending.FromSeller == isSeller {
+		return nil, nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrProposalAwaitsOtherParty)
+	}
+
+	return negotiation, pending, nil
+}
+
+func checkOfferIsNegotiable(offer *models.Offer) *merr.ResponseError {
+	if offer.IsAuction() {
+		return merr.NewResponseError(http.StatusUnprocessableEntity, ErrOfferIsAuction)
+	}
+
+	return checkOfferIsOnSale(offer)
+}
+
+// newNegotiationProposal validates the terms against the offer: the
+// quantity must still be available and the period fall within the offer's,
+// covering some of its delivery blocks. The DeliveryBlocks must be loaded.
+func newNegotiationProposal(
+	offer *models.Offer,
+	author *models.User,
+	fromSeller bool,
+	request *requests.NegotiationProposal,
+) (*models.NegotiationProposal, *merr.ResponseError) {
+	if responseErr := checkOfferIsNegotiable(offer); responseErr != nil {
+		return nil, responseErr
+	}
+
+	if request.QuantityMwh > offer.RemainingQuantityMwh {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrInsufficientOfferQuantity)
+	}
+
+	periodStart, err := parseDate(request.PeriodStart)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidPeriod)
+	}
+
+	periodEnd, err := parseDate(request.PeriodEnd)
+	if err != nil || periodStart.After(periodEnd) {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidPeriod)
+	}
+
+	if periodStart.Before(utils.TruncateDateToLocalZeroHour(offer.PeriodStart)) ||
+		periodEnd.After(utils.TruncateDateToLocalZeroHour(offer.PeriodEnd)) {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrProposalPeriodOutsideOffer)
+	}
+
+	if offer.HasDeliveryProfile() && len(deliveryBlocksWithin(offer.DeliveryBlocks, periodStart, periodEnd)) == 0 {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrProposalPeriodWithoutDelivery)
+	}
+
+	var now time.Time = utils.NowInLocal()
+	var expiresAt time.Time = now.Add(defaultProposalTtl)
+
+	if request.ExpiresAt != nil {
+		if !request.ExpiresAt.After(now) {
+			return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidProposalExpiry)
+		}
+		expiresAt = *request.ExpiresAt
+	}
+
+	return &models.NegotiationProposal{
+		Uuid:        NewUuidV7String(),
+		PricePerMwh: request.PricePerMwh,
+		QuantityMwh: request.QuantityMwh,
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+		Message:     request.Message,
+		Status:      models.NegotiationProposalStatusPending,
+		FromSeller:  fromSeller,
+		ExpiresAt:   expiresAt,
+		AuthorId:    author.ID,
+	}, nil
+}
+
+func makeNegotiationResourceFromModel(negotiation *models.Negotiation) *resources.Negotiation {
+	var response *resources.Negotiation = &resources.Negotiation{
+		Uuid:          negotiation.Uuid,
+		Status:        negotiation.Status,
+		PaymentMethod: negotiation.PaymentMethod,
+		OfferUuid:     negotiation.Offer.Uuid,
+		BuyerUuid:     negotiation.Buyer.Uuid,
+		BuyerName:     negotiation.Buyer.Name,
+		Proposals:     make([]*resources.NegotiationProposal, 0, len(negotiation.Proposals)),
+		CreatedAt:     utils.TruncateDateToLocal(negotiation.CreatedAt).Format(time.RFC3339),
+	}
+
+	if negotiation.Purchase != nil {
+		response.PurchaseUuid = &negotiation.Purchase.Uuid
+	}
+
+	for _, proposal := range negotiation.Proposals {
+		var proposedBy string = "buyer"
+		if proposal.FromSeller {
+			proposedBy = "seller"
+		}
+
+		response.Proposals = append(response.Proposals, &resources.NegotiationProposal{
+			Uuid:        proposal.Uuid,
+			PricePerMwh: proposal.PricePerMwh,
+			QuantityMwh: proposal.QuantityMwh,
+			PeriodStart: proposal.PeriodStart.Format(time.DateOnly),
+			PeriodEnd:   proposal.PeriodEnd.Format(time.DateOnly),
+			Message:     proposal.Message,
+			Status:      proposal.Status,
+			ProposedBy:  proposedBy,
+			AuthorUuid:  proposal.Author.Uuid,
+			ExpiresAt:   utils.TruncateDateToLocal(proposal.ExpiresAt).Format(time.RFC3339),
+			CreatedAt:   utils.TruncateDateToLocal(proposal.CreatedAt).Format(time.RFC3339),
+		})
+	}
+
+	return response
+}
@@ -9,7 +9,10 @@ import (
 	"ecoply/internal/domain/utils"
 	"ecoply/internal/mlog"
 	"errors"
+	"io"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,38 +20,56 @@ import (
 )
 
 type OfferService interface {
-	GetByUuid(uuid string) (*resources.Offer, *merr.ResponseError)
-	BelongingToUser(userId uint) ([]*resources.Offer, *merr.ResponseError)
-	Create(user *models.User, request *requests.CreateOffer) (*resources.Offer, *merr.ResponseError)
-	Update(user *models.User, uuid string, request *requests.UpdateOffer) *merr.ResponseError
-	Delete(user *models.User, uuid string) *merr.ResponseError
+	GetByUuid(uuid string, user *models.User) (*resources.Offer, *merr.ResponseError)
+	BelongingToAgent(agentId uint) ([]*resources.Offer, *merr.ResponseError)
+	Create(user *models.User, client *requests.ClientInfo, request *requests.CreateOffer) (*resources.Offer, *merr.ResponseError)
+	Update(user *models.User, client *requests.ClientInfo, uuid string, request *requests.UpdateOffer) *merr.ResponseError
+	Delete(user *models.User, client *requests.ClientInfo, uuid string) *merr.ResponseError
 	List(params *requests.ListOffers, user *models.User) (*utils.PaginationWrapper[*resources.Offer], *merr.ResponseError)
 	Purchases(offerUuid string, request *requests.ListPurchasesFromOffer, user *models.User) ([]*resources.Purchase, *merr.ResponseError)
+	Quote(uuid string, user *models.User, request *requests.QuoteOffer) (*resources.OfferQuote, *merr.ResponseError)
+	Import(user *models.User, client *requests.ClientInfo, file io.Reader, request *requests.ImportOffers) (*resources.OfferImport, *merr.ResponseError)
+	Export(user *models.User, request *requests.ExportOffers) ([]byte, *merr.ResponseError)
+	Pause(user *models.User, client *requests.ClientInfo, uuid string) (*resources.Offer, *merr.ResponseError)
+	Resume(user *models.User, client *requests.ClientInfo, uuid string) (*resources.Offer, *merr.ResponseError)
 	UpdateExpiredOffers() error
+	PublishScheduledOffers() error
 }
 
 type offerService struct {
-	offerRepo      repository.OfferRepository
-	submarketRepo  repository.SubmarketRepository
-	userTypeRepo   repository.UserTypeRepository
-	energyTypeRepo repository.EnergyTypeRepository
-	db             *gorm.DB
+	offerRepo       repository.OfferRepository
+	agentRepo       repository.AgentRepository
+	submarketRepo   repository.SubmarketRepository
+	userTypeRepo    repository.UserTypeRepository
+	energyTypeRepo  repository.EnergyTypeRepository
+	purchaseService *purchaseService
+	db              *gorm.DB
 }
 
 func NewOfferService(db *gorm.DB) OfferService {
 	return &offerService{
-		offerRepo:      repository.NewOfferRepository(db),
-		submarketRepo:  repository.NewSubmarketRepository(db),
-		userTypeRepo:   repository.NewUserTypeRepository(db),
-		energyTypeRepo: repository.NewEnergyRepository(db),
-		db:             db,
+		offerRepo:       repository.NewOfferRepository(db),
+		agentRepo:       repository.NewAgentRepository(db),
+		submarketRepo:   repository.NewSubmarketRepository(db),
+		userTypeRepo:    repository.NewUserTypeRepository(db),
+		energyTypeRepo:  repository.NewEnergyRepository(db),
+		purchaseService: newPurchaseService(db),
+		db:              db,
 	}
 }
 
-func (s *offerService) Create(user *models.User, request *requests.CreateOffer) (*resources.Offer, *merr.ResponseError) {
+func (s *offerService) Create(user *models.User, client *requests.ClientInfo, request *requests.CreateOffer) (*resources.Offer, *merr.ResponseError) {
 	var energyType *models.EnergyType
 	var err error
 
+	if !user.CanTrade() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	if !user.IsEmailVerified() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrEmailNotVerified)
+	}
+
 	energyType, err = s.energyTypeRepo.GetByType(request.EnergyType)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidEnergyType)
@@ -60,16 +81,65 @@ func (s *offerService) Create(user *models.User, request *requests.CreateOffer)
 		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, err)
 	}
 
+	allowedAgents, allowedSubmarkets, responseError := s.resolveOfferAudience(request.AllowedAgents, request.AllowedSubmarkets)
+	if responseError != nil {
+		return nil, responseError
+	}
+
 	if err = s.db.Preload("Agent", func(db *gorm.DB) *gorm.DB { return db.Select("id, submarket_id") }).
 		Find(user).Error; err != nil {
 		mlog.Log("Failed to preload agent: " + err.Error())
 		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrInternal)
 	}
 
+	var offer *models.Offer = makeOfferFromRequest(user, energyType, request)
+
+	var fills []*models.Purchase
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if err = s.insertOffer(tx, user, client, offer); err != nil {
+			return err
+		}
+
+		if len(allowedAgents) > 0 || len(allowedSubmarkets) > 0 {
+			err = s.offerRepo.WithTransaction(tx).ReplaceAudience(offer, allowedAgents, allowedSubmarkets)
+			if err != nil {
+				return err
+			}
+		}
+
+		fills, err = matchOffer(tx, offer)
+		return err
+	})
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	dispatchOrderBookFills(s.purchaseService, fills)
+
+	if err := s.db.
+		Preload("EnergyType", func(db *gorm.DB) *gorm.DB { return db.Select("id", "type") }).
+		Preload("Submarket", func(db *gorm.DB) *gorm.DB { return db.Select("id", "name") }).
+		Preload("Seller", func(db *gorm.DB) *gorm.DB { return db.Select("id", "uuid") }).
+		Preload("AllowedAgents").
+		Preload("AllowedSubmarkets").
+		First(offer, offer.ID).Error; err != nil {
+		mlog.Log("Failed to preload offer relations: " + err.Error())
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return makeOfferResourceFromModel(offer), nil
+}
+
+// makeOfferFromRequest builds a fresh offer of the user's company, or a
+// scheduled one when it has a publish time. The agent must be loaded for its
+// submarket.
+func makeOfferFromRequest(user *models.User, energyType *models.EnergyType, request *requests.CreateOffer) *models.Offer {
 	parsedStartPeriod, _ := parseDate(request.PeriodStart)
 	parsedEndPeriod, _ := parseDate(request.PeriodEnd)
 
-	offer := &models.Offer{
+	var offer *models.Offer = &models.Offer{
 		Uuid:                 NewUuidV7String(),
 		PricePerMwh:          request.PricePerMwh,
 		InitialQuantityMwh:   request.QuantityMwh,
@@ -78,29 +148,57 @@ func (s *offerService) Create(user *models.User, request *requests.CreateOffer)
 		PeriodStart:          parsedStartPeriod,
 		PeriodEnd:            parsedEndPeriod,
 		Status:               models.OfferStatusFresh,
+		AuctionType:          models.OfferAuctionNone,
+		Visibility:           models.OfferVisibilityPublic,
 		EnergyTypeId:         energyType.ID,
 		SellerId:             user.ID,
 		SubmarketId:          user.Agent.SubmarketId,
+		PriceTiers:           makeOfferPriceTiersFromRequest(request.PriceTiers),
+		MinQuantityMwh:       request.MinQuantityMwh,
+		QuantityStepMwh:      request.QuantityStepMwh,
+		DeliveryProfile:      models.DeliveryProfileFlat,
+		DeliveryBlocks:       makeOfferDeliveryBlocksFromRequest(request.DeliveryBlocks),
 	}
 
-	offer, err = s.offerRepo.Create(offer)
-	if err != nil {
-		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	if request.DeliveryProfile != "" {
+		offer.DeliveryProfile = request.DeliveryProfile
 	}
 
-	if err := s.db.
-		Preload("EnergyType", func(db *gorm.DB) *gorm.DB { return db.Select("id", "type") }).
-		Preload("Submarket", func(db *gorm.DB) *gorm.DB { return db.Select("id", "name") }).
-		Preload("Seller", func(db *gorm.DB) *gorm.DB { return db.Select("id", "uuid") }).
-		First(offer, offer.ID).Error; err != nil {
-		mlog.Log("Failed to preload offer relations: " + err.Error())
-		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	if request.PublishAt != nil {
+		offer.Status = models.OfferStatusScheduled
+		offer.PublishAt = request.PublishAt
 	}
 
-	return makeOfferResourceFromModel(offer), nil
+	if request.AuctionType != "" {
+		offer.AuctionType = request.AuctionType
+		offer.AuctionStartsAt = request.AuctionStartsAt
+		offer.AuctionEndsAt = request.AuctionEndsAt
+		offer.ReservePricePerMwh = request.ReservePricePerMwh
+		offer.BidIncrementPerMwh = request.BidIncrementPerMwh
+	}
+
+	return offer
+}
+
+// insertOffer creates the offer and records it in the audit log, within the
+// transaction of the caller.
+func (s *offerService) insertOffer(tx *gorm.DB, user *models.User, client *requests.ClientInfo, offer *models.Offer) error {
+	if _, err := s.offerRepo.WithTransaction(tx).Create(offer); err != nil {
+		return err
+	}
+
+	offer.Seller = *user
+
+	return recordAuditEvent(tx, client, auditEntry{
+		Event:      models.AuditEventOfferCreated,
+		Actor:      user,
+		TargetType: models.AuditTargetOffer,
+		TargetUuid: offer.Uuid,
+		After:      makeOfferAuditSnapshot(offer),
+	})
 }
 
-func (s *offerService) Update(user *models.User, uuid string, request *requests.UpdateOffer) *merr.ResponseError {
+func (s *offerService) Update(user *models.User, client *requests.ClientInfo, uuid string, request *requests.UpdateOffer) *merr.ResponseError {
 	var energyType *models.EnergyType
 	var offer *models.Offer
 	var err error
@@ -112,14 +210,18 @@ func (s *offerService) Update(user *models.User, uuid string, request *requests.
 		return merr.NewResponseError(http.StatusNotFound, ErrOfferNotFound)
 	}
 
-	if offer.Status != models.OfferStatusFresh {
+	if !offer.IsEditable() {
 		return merr.NewResponseError(http.StatusUnprocessableEntity, ErrCannotUpdateOffer)
 	}
 
-	if offer.SellerId != user.ID {
+	if !offer.IsOwner(user) {
 		return merr.NewResponseError(http.StatusForbidden, ErrUserIsNotTheOfferOwner)
 	}
 
+	if !user.CanTrade() {
+		return merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
 	energyType, err = s.energyTypeRepo.GetByType(request.EnergyType)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidEnergyType)
@@ -132,9 +234,26 @@ func (s *offerService) Update(user *models.User, uuid string, request *requests.
 		return merr.NewResponseError(http.StatusUnprocessableEntity, err)
 	}
 
+	var replaceAudience bool = request.AllowedAgents != nil || request.AllowedSubmarkets != nil
+
+	var allowedAgents []*models.Agent
+	var allowedSubmarkets []*models.Submarket
+	if replaceAudience {
+		var responseError *merr.ResponseError
+		allowedAgents, allowedSubmarkets, responseError = s.updatedOfferAudience(offer, request)
+		if responseError != nil {
+			return responseError
+		}
+	}
+
+	var replaceDeliveryProfile bool = request.DeliveryProfile != nil || request.DeliveryBlocks != nil
+	deliveryProfile, deliveryBlocks := updatedOfferDeliveryProfile(offer, request)
+
 	periodStart, _ = parseDate(request.PeriodStart)
 	periodEnd, _ = parseDate(request.PeriodEnd)
 
+	var before map[string]any = makeOfferAuditSnapshot(offer)
+
 	offer.Description = request.Description
 	offer.EnergyTypeId = energyType.ID
 	offer.EnergyType = *energyType
@@ -143,16 +262,68 @@ func (s *offerService) Update(user *models.User, uuid string, request *requests.
 	offer.PricePerMwh = request.PricePerMwh
 	offer.PeriodStart = periodStart
 	offer.PeriodEnd = periodEnd
+	offer.MinQuantityMwh, offer.QuantityStepMwh = updatedOfferLotSize(offer, request)
+	offer.DeliveryProfile = models.DeliveryProfileFlat
+	if deliveryProfile != "" {
+		offer.DeliveryProfile = deliveryProfile
+	}
+	if request.PublishAt != nil {
+		offer.PublishAt = request.PublishAt
+	}
+
+	var fills []*models.Purchase
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.offerRepo.WithTransaction(tx).Update(offer); err != nil {
+			return err
+		}
+
+		if replaceAudience {
+			err := s.offerRepo.WithTransaction(tx).ReplaceAudience(offer, allowedAgents, allowedSubmarkets)
+			if err != nil {
+				return err
+			}
+		}
+
+		if request.PriceTiers != nil {
+			err := s.offerRepo.WithTransaction(tx).ReplacePriceTiers(offer, makeOfferPriceTiersFromRequest(*request.PriceTiers))
+			if err != nil {
+				return err
+			}
+		}
+
+		if replaceDeliveryProfile {
+			err := s.offerRepo.WithTransaction(tx).ReplaceDeliveryBlocks(offer, makeOfferDeliveryBlocksFromRequest(deliveryBlocks))
+			if err != nil {
+				return err
+			}
+		}
 
-	err = s.offerRepo.Update(offer)
+		err := recordAuditEvent(tx, client, auditEntry{
+			Event:      models.AuditEventOfferUpdated,
+			Actor:      user,
+			TargetType: models.AuditTargetOffer,
+			TargetUuid: offer.Uuid,
+			Before:     before,
+			After:      makeOfferAuditSnapshot(offer),
+		})
+		if err != nil {
+			return err
+		}
+
+		fills, err = matchOffer(tx, offer)
+		return err
+	})
 	if err != nil {
 		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
 	}
 
+	dispatchOrderBookFills(s.purchaseService, fills)
+
 	return nil
 }
 
-func (s *offerService) Delete(user *models.User, uuid string) *merr.ResponseError {
+func (s *offerService) Delete(user *models.User, client *requests.ClientInfo, uuid string) *merr.ResponseError {
 	var offer *models.Offer
 	var err error
 
@@ -161,15 +332,31 @@ func (s *offerService) Delete(user *models.User, uuid string) *merr.ResponseErro
 		return merr.NewResponseError(http.StatusNotFound, ErrOfferNotFound)
 	}
 
-	if offer.Status != models.OfferStatusFresh {
+	if !offer.IsEditable() {
 		return merr.NewResponseError(http.StatusUnprocessableEntity, ErrCannotDeleteOffer)
 	}
 
-	if offer.SellerId != user.ID {
+	if !offer.IsOwner(user) {
 		return merr.NewResponseError(http.StatusForbidden, ErrUserIsNotTheOfferOwner)
 	}
 
-	err = s.offerRepo.Delete(uuid)
+	if !user.CanTrade() {
+		return merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.offerRepo.WithTransaction(tx).Delete(uuid); err != nil {
+			return err
+		}
+
+		return recordAuditEvent(tx, client, auditEntry{
+			Event:      models.AuditEventOfferDeleted,
+			Actor:      user,
+			TargetType: models.AuditTargetOffer,
+			TargetUuid: offer.Uuid,
+			Before:     makeOfferAuditSnapshot(offer),
+		})
+	})
 	if err != nil {
 		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
 	}
@@ -177,19 +364,23 @@ func (s *offerService) Delete(user *models.User, uuid string) *merr.ResponseErro
 	return nil
 }
 
-func (s *offerService) GetByUuid(uuid string) (*resources.Offer, *merr.ResponseError) {
+func (s *offerService) GetByUuid(uuid string, user *models.User) (*resources.Offer, *merr.ResponseError) {
 	offer, err := s.offerRepo.GetByUuid(strings.ToLower(uuid))
 	if err != nil {
 		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrOfferNotFound)
 	}
 
+	if responseError := checkOfferVisibility(s.offerRepo, offer, user); responseError != nil {
+		return nil, responseError
+	}
+
 	response := makeOfferResourceFromModel(offer)
 
 	return response, nil
 }
 
-func (s *offerService) BelongingToUser(userId uint) ([]*resources.Offer, *merr.ResponseError) {
-	offers, err := s.offerRepo.GetBySellerId(userId)
+func (s *offerService) BelongingToAgent(agentId uint) ([]*resources.Offer, *merr.ResponseError) {
+	offers, err := s.offerRepo.GetByAgentId(agentId)
 	if err != nil {
 		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
 	}
@@ -202,6 +393,97 @@ func (s *offerService) BelongingToUser(userId uint) ([]*resources.Offer, *merr.R
 	return response, nil
 }
 
+// resolveOfferAudience looks up the agents, by CNPJ or CCEE code, and the
+// submarkets an offer is restricted to.
+func (s *offerService) resolveOfferAudience(agentCodes []string, submarketNames []string) ([]*models.Agent, []*models.Submarket, *merr.ResponseError) {
+	agents := make([]*models.Agent, 0, len(agentCodes))
+	for _, code := range agentCodes {
+		agent, err := s.agentRepo.FindByCnpjOrCceeCode(strings.TrimSpace(code))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrAllowedAgentNotFound)
+		} else if err != nil {
+			return nil, nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+		}
+		agents = append(agents, agent)
+	}
+
+	submarkets := make([]*models.Submarket, 0, len(submarketNames))
+	for _, name := range submarketNames {
+		submarket, err := s.submarketRepo.FindByName(name)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidSubmarket)
+		} else if err != nil {
+			return nil, nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+		}
+		submarkets = append(submarkets, submarket)
+	}
+
+	return agents, submarkets, nil
+}
+
+// updatedOfferAudience resolves the agents and submarkets of the request,
+// keeping the current ones of the offer for the half it leaves out.
+func (s *offerService) updatedOfferAudience(offer *models.Offer, request *requests.UpdateOffer) ([]*models.Agent, []*models.Submarket, *merr.ResponseError) {
+	var agentCodes []string
+	var submarketNames []string
+
+	if request.AllowedAgents != nil {
+		agentCodes = *request.AllowedAgents
+	}
+	if request.AllowedSubmarkets != nil {
+		submarketNames = *request.AllowedSubmarkets
+	}
+
+	agents, submarkets, responseError := s.resolveOfferAudience(agentCodes, submarketNames)
+	if responseError != nil {
+		return nil, nil, responseError
+	}
+
+	if request.AllowedAgents == nil {
+		if err := s.db.Model(offer).Association("AllowedAgents").Find(&agents); err != nil {
+			mlog.Log("Failed to load offer allowed agents: " + err.Error())
+			return nil, nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+		}
+	}
+
+	if request.AllowedSubmarkets == nil {
+		if err := s.db.Model(offer).Association("AllowedSubmarkets").Find(&submarkets); err != nil {
+			mlog.Log("Failed to load offer allowed submarkets: " + err.Error())
+			return nil, nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+		}
+	}
+
+	return agents, submarkets, nil
+}
+
+// checkOfferVisibility hides a restricted offer from the companies it wasn't
+// made for, and a scheduled one from everyone but its seller, as if it didn't
+// exist.
+func checkOfferVisibility(offerRepo repository.OfferRepository, offer *models.Offer, user *models.User) *merr.ResponseError {
+	if offer.IsOwner(user) {
+		return nil
+	}
+
+	if offer.IsScheduled() {
+		return merr.NewResponseError(http.StatusNotFound, ErrOfferNotFound)
+	}
+
+	if !offer.IsRestricted() {
+		return nil
+	}
+
+	visible, err := offerRepo.IsVisibleTo(offer.ID, user.AgentId)
+	if err != nil {
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if !visible {
+		return merr.NewResponseError(http.StatusNotFound, ErrOfferNotFound)
+	}
+
+	return nil
+}
+
 func parseDate(date string) (time.Time, error) {
 	layout := "2006-01-02"
 	return time.ParseInLocation(layout, date, time.Local)
@@ -261,8 +543,17 @@ func validateUpdatePeriodFromRequest(offer *models.Offer, request *requests.Upda
 	return nil
 }
 
+// The largest values the decimal(10,2) prices and decimal(10,3) quantities
+// of offers hold
+const (
+	maxOfferPricePerMwh float64 = 99999999.99
+	maxOfferQuantityMwh float64 = 9999999.999
+)
+
+// validatePrice also refuses NaN and infinities, which can come from parsed
+// files, and prices the column can't hold.
 func validatePrice(price float64) error {
-	if price <= 0 {
+	if math.IsNaN(price) || math.IsInf(price, 0) || price <= 0 || math.Round(price*100)/100 > maxOfferPricePerMwh {
 		return ErrInvalidPrice
 	}
 
@@ -270,7 +561,7 @@ func validatePrice(price float64) error {
 }
 
 func validateQuantity(quantity float64) error {
-	if quantity <= 0 {
+	if math.IsNaN(quantity) || math.IsInf(quantity, 0) || quantity <= 0 || math.Round(quantity*1000)/1000 > maxOfferQuantityMwh {
 		return ErrInvalidQuantity
 	}
 
@@ -290,6 +581,36 @@ func validateCreateRequest(request *requests.CreateOffer) error {
 		return err
 	}
 
+	if err := validatePriceTiers(request.PriceTiers, request.PricePerMwh, request.QuantityMwh, request.AuctionType != ""); err != nil {
+		return err
+	}
+
+	if err := validateLotSize(request.MinQuantityMwh, request.QuantityStepMwh, request.QuantityMwh, request.AuctionType != ""); err != nil {
+		return err
+	}
+
+	periodStart, _ := parseDate(request.PeriodStart)
+	periodEnd, _ := parseDate(request.PeriodEnd)
+
+	if err := validateDeliveryProfile(request.DeliveryProfile, request.DeliveryBlocks, request.QuantityMwh, periodStart, periodEnd); err != nil {
+		return err
+	}
+
+	if err := validatePublishAt(request.PublishAt, periodEnd, request.AuctionType != ""); err != nil {
+		return err
+	}
+
+	if request.AuctionType != "" {
+		return validateAuction(
+			request.AuctionType,
+			*request.AuctionStartsAt,
+			*request.AuctionEndsAt,
+			periodEnd,
+			request.PricePerMwh,
+			request.ReservePricePerMwh,
+		)
+	}
+
 	return nil
 }
 
@@ -306,9 +627,219 @@ func validateUpdateRequest(offer *models.Offer, request *requests.UpdateOffer) e
 		return err
 	}
 
+	// Tiers kept from the offer must still fit its new price and quantity
+	var tiers []requests.OfferPriceTier = mapSlice(offer.PriceTiers, func(tier models.OfferPriceTier) requests.OfferPriceTier {
+		return requests.OfferPriceTier{MinQuantityMwh: tier.MinQuantityMwh, PricePerMwh: tier.PricePerMwh}
+	})
+	if request.PriceTiers != nil {
+		tiers = *request.PriceTiers
+	}
+
+	if err := validatePriceTiers(tiers, request.PricePerMwh, request.QuantityMwh, offer.IsAuction()); err != nil {
+		return err
+	}
+
+	// Lot rules kept from the offer must still fit its new quantity
+	minQuantity, step := updatedOfferLotSize(offer, request)
+	if err := validateLotSize(minQuantity, step, request.QuantityMwh, offer.IsAuction()); err != nil {
+		return err
+	}
+
+	periodStart, _ := parseDate(request.PeriodStart)
+	periodEnd, _ := parseDate(request.PeriodEnd)
+
+	// As are the delivery blocks kept, to its new quantity and period
+	profile, blocks := updatedOfferDeliveryProfile(offer, request)
+	if err := validateDeliveryProfile(profile, blocks, request.QuantityMwh, periodStart, periodEnd); err != nil {
+		return err
+	}
+
+	if request.PublishAt != nil && !offer.IsScheduled() {
+		return ErrInvalidPublishAt
+	}
+
+	if err := validatePublishAt(request.PublishAt, periodEnd, offer.IsAuction()); err != nil {
+		return err
+	}
+
+	if offer.IsAuction() {
+		return validateAuction(
+			offer.AuctionType,
+			*offer.AuctionStartsAt,
+			*offer.AuctionEndsAt,
+			periodEnd,
+			request.PricePerMwh,
+			offer.ReservePricePerMwh,
+		)
+	}
+
+	return nil
+}
+
+// validatePublishAt requires the publication to come before the end of the
+// period. Auctions open at their own start and can't be scheduled.
+func validatePublishAt(publishAt *time.Time, periodEnd time.Time, isAuction bool) error {
+	if publishAt == nil {
+		return nil
+	}
+
+	if isAuction || !publishAt.After(utils.NowInLocal()) || !publishAt.Before(periodEnd.AddDate(0, 0, 1)) {
+		return ErrInvalidPublishAt
+	}
+
+	return nil
+}
+
+// validatePriceTiers requires each tier to start at a larger quantity than
+// the previous one, within the offer, and to be cheaper than it. Auctions
+// are sold whole and can't have tiers.
+func validatePriceTiers(tiers []requests.OfferPriceTier, price float64, quantity float64, isAuction bool) error {
+	if len(tiers) > 0 && isAuction {
+		return ErrInvalidPriceTiers
+	}
+
+	var lastQuantity float64 = 0
+	var lastPrice float64 = price
+
+	for _, tier := range tiers {
+		if tier.MinQuantityMwh <= lastQuantity || tier.MinQuantityMwh > quantity || tier.PricePerMwh >= lastPrice {
+			return ErrInvalidPriceTiers
+		}
+
+		lastQuantity = tier.MinQuantityMwh
+		lastPrice = tier.PricePerMwh
+	}
+
 	return nil
 }
 
+// updatedOfferDeliveryProfile is the delivery profile of the offer once the
+// request is applied: left out it is kept with its blocks, and blocks sent
+// alone keep the profile.
+func updatedOfferDeliveryProfile(offer *models.Offer, request *requests.UpdateOffer) (string, []requests.DeliveryBlock) {
+	var profile string = offer.DeliveryProfile
+	var blocks []requests.DeliveryBlock = mapSlice(offer.DeliveryBlocks, func(block models.OfferDeliveryBlock) requests.DeliveryBlock {
+		return requests.DeliveryBlock{Slot: block.Slot, QuantityMwh: block.QuantityMwh}
+	})
+
+	if request.DeliveryProfile != nil {
+		profile = *request.DeliveryProfile
+		blocks = nil
+	}
+
+	if request.DeliveryBlocks != nil {
+		blocks = *request.DeliveryBlocks
+	}
+
+	return profile, blocks
+}
+
+// updatedOfferLotSize is the lot size of the offer once the request is
+// applied: rules left out are kept and a zero removes the rule.
+func updatedOfferLotSize(offer *models.Offer, request *requests.UpdateOffer) (*float64, *float64) {
+	return updatedLotRule(offer.MinQuantityMwh, request.MinQuantityMwh), updatedLotRule(offer.QuantityStepMwh, request.QuantityStepMwh)
+}
+
+func updatedLotRule(stored *float64, requested *float64) *float64 {
+	if requested == nil {
+		return stored
+	}
+
+	if *requested == 0 {
+		return nil
+	}
+
+	return requested
+}
+
+// validateLotSize keeps the minimum and the step within the offer quantity.
+// Auctions are sold whole and take neither.
+func validateLotSize(minQuantity *float64, step *float64, quantity float64, isAuction bool) error {
+	if isAuction && (minQuantity != nil || step != nil) {
+		return ErrInvalidLotSize
+	}
+
+	if minQuantity != nil && (*minQuantity <= 0 || *minQuantity > quantity) {
+		return ErrInvalidLotSize
+	}
+
+	if step != nil && (*step <= 0 || *step > quantity) {
+		return ErrInvalidLotSize
+	}
+
+	return nil
+}
+
+func makeOfferPriceTiersFromRequest(tiers []requests.OfferPriceTier) []models.OfferPriceTier {
+	var result []models.OfferPriceTier = make([]models.OfferPriceTier, len(tiers))
+	for i, tier := range tiers {
+		result[i] = models.OfferPriceTier{
+			MinQuantityMwh: tier.MinQuantityMwh,
+			PricePerMwh:    tier.PricePerMwh,
+		}
+	}
+	return result
+}
+
+// validateAuction requires the auction to end in the future and no later
+// than the supply period. Dutch auctions need a reserve below the opening
+// price to drop to.
+func validateAuction(
+	auctionType string,
+	startsAt time.Time,
+	endsAt time.Time,
+	periodEnd time.Time,
+	openingPrice float64,
+	reservePrice *float64,
+) error {
+	if !startsAt.Before(endsAt) || !endsAt.After(utils.NowInLocal()) {
+		return ErrInvalidAuction
+	}
+
+	if !endsAt.Before(periodEnd.AddDate(0, 0, 1)) {
+		return ErrInvalidAuction
+	}
+
+	if auctionType == models.OfferAuctionDutch && (reservePrice == nil || *reservePrice >= openingPrice) {
+		return ErrInvalidAuction
+	}
+
+	return nil
+}
+
+// makeOfferAuditSnapshot keeps the fields a seller can change, for the
+// before and after of audit events.
+func makeOfferAuditSnapshot(offer *models.Offer) map[string]any {
+	return map[string]any{
+		"price_per_mwh":          offer.PricePerMwh,
+		"initial_quantity_mwh":   offer.InitialQuantityMwh,
+		"remaining_quantity_mwh": offer.RemainingQuantityMwh,
+		"description":            offer.Description,
+		"period_start":           offer.PeriodStart.Format(time.DateOnly),
+		"period_end":             offer.PeriodEnd.Format(time.DateOnly),
+		"energy_type_id":         offer.EnergyTypeId,
+		"status":                 offer.Status,
+		"auction_type":           offer.AuctionType,
+		"price_tiers":            makeOfferPriceTierResources(offer.PriceTiers),
+		"min_quantity_mwh":       offer.MinQuantityMwh,
+		"quantity_step_mwh":      offer.QuantityStepMwh,
+		"delivery_profile":       offer.DeliveryProfile,
+		"delivery_blocks":        makeOfferDeliveryBlockResources(offer.DeliveryBlocks),
+		"publish_at":             offer.PublishAt,
+	}
+}
+
+func makeOfferPriceTierResources(tiers []models.OfferPriceTier) []resources.OfferPriceTier {
+	var response []resources.OfferPriceTier = make([]resources.OfferPriceTier, len(tiers))
+	for i, tier := range tiers {
+		response[i] = resources.OfferPriceTier{
+			MinQuantityMwh: tier.MinQuantityMwh,
+			PricePerMwh:    tier.PricePerMwh,
+		}
+	}
+	return response
+}
+
 func makeOfferResourceFromModel(offer *models.Offer) *resources.Offer {
 	var createdAt time.Time = utils.TruncateDateToLocal(offer.CreatedAt)
 	var response resources.Offer = resources.Offer{
@@ -320,10 +851,39 @@ func makeOfferResourceFromModel(offer *models.Offer) *resources.Offer {
 		PeriodStart:          offer.PeriodStart.Format(time.DateOnly),
 		PeriodEnd:            offer.PeriodEnd.Format(time.DateOnly),
 		Status:               offer.Status,
+		PublishAt:            offer.PublishAt,
 		EnergyType:           offer.EnergyType.Type,
 		Submarket:            offer.Submarket.Name,
 		SellerUuid:           offer.Seller.Uuid,
 		CreatedAt:            createdAt,
+		Visibility:           offer.Visibility,
+		MinQuantityMwh:       offer.MinQuantityMwh,
+		QuantityStepMwh:      offer.QuantityStepMwh,
+		PriceTiers:           makeOfferPriceTierResources(offer.PriceTiers),
+		DeliveryProfile:      offer.DeliveryProfile,
+		DeliveryBlocks:       makeOfferDeliveryBlockResources(offer.DeliveryBlocks),
+	}
+
+	for _, agent := range offer.AllowedAgents {
+		response.AllowedAgents = append(response.AllowedAgents, agent.Cnpj)
+	}
+
+	for _, submarket := range offer.AllowedSubmarkets {
+		response.AllowedSubmarkets = append(response.AllowedSubmarkets, submarket.Name)
+	}
+
+	if offer.IsAuction() && offer.AuctionStartsAt != nil && offer.AuctionEndsAt != nil {
+		response.Auction = &resources.OfferAuction{
+			Type:               offer.AuctionType,
+			StartsAt:           utils.TruncateDateToLocal(*offer.AuctionStartsAt).Format(time.RFC3339),
+			EndsAt:             utils.TruncateDateToLocal(*offer.AuctionEndsAt).Format(time.RFC3339),
+			BidIncrementPerMwh: offer.BidIncrementPerMwh,
+		}
+
+		if offer.AuctionType == models.OfferAuctionDutch && offer.IsAuctionRunning() {
+			var currentPrice float64 = offer.DutchPriceAt(utils.NowInLocal())
+			response.Auction.CurrentPricePerMwh = &currentPrice
+		}
 	}
 
 	return &response
@@ -353,10 +913,199 @@ func (s *offerService) List(request *requests.ListOffers, user *models.User) (*u
 	return &response, nil
 }
 
+// Quote prices a purchase of the quantity with the tier it reaches, without
+// holding the quantity for the buyer.
+func (s *offerService) Quote(uuid string, user *models.User, request *requests.QuoteOffer) (*resources.OfferQuote, *merr.ResponseError) {
+	offer, err := s.offerRepo.GetByUuid(strings.ToLower(uuid))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, merr.NewResponseError(http.StatusNotFound, ErrOfferNotFound)
+	} else if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if responseError := checkOfferVisibility(s.offerRepo, offer, user); responseError != nil {
+		return nil, responseError
+	}
+
+	if offer.IsAuction() {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrOfferIsAuction)
+	}
+
+	if responseError := checkOfferIsOnSale(offer); responseError != nil {
+		return nil, responseError
+	}
+
+	if offer.RemainingQuantityMwh < request.QuantityMwh {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrInsufficientOfferQuantity)
+	}
+
+	if !offer.IsValidLot(request.QuantityMwh) {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrQuantityNotValidLot)
+	}
+
+	var pricePerMwh float64 = offer.PriceFor(request.QuantityMwh)
+
+	return &resources.OfferQuote{
+		QuantityMwh: request.QuantityMwh,
+		PricePerMwh: pricePerMwh,
+		TotalPrice:  math.Round(pricePerMwh*request.QuantityMwh*100) / 100,
+	}, nil
+}
+
+// checkOfferIsOnSale refuses new purchases of paused offers and of those
+// that ended.
+func checkOfferIsOnSale(offer *models.Offer) *merr.ResponseError {
+	if offer.IsPaused() {
+		return merr.NewResponseError(http.StatusUnprocessableEntity, ErrOfferIsPaused)
+	}
+
+	if !offer.IsOnSale() {
+		return merr.NewResponseError(http.StatusUnprocessableEntity, ErrOfferHasEnded)
+	}
+
+	return nil
+}
+
+// Pause takes the offer off the marketplace without touching its purchases,
+// until it is resumed. Auctions can't be paused.
+func (s *offerService) Pause(user *models.User, client *requests.ClientInfo, uuid string) (*resources.Offer, *merr.ResponseError) {
+	return s.changeOfferStatus(user, client, uuid, models.AuditEventOfferPaused, func(offer *models.Offer) *merr.ResponseError {
+		if offer.IsAuction() || offer.IsExpired() || offer.TransitionTo(models.OfferStatusPaused) != nil {
+			return merr.NewResponseError(http.StatusUnprocessableEntity, ErrOfferCannotBePaused)
+		}
+		return nil
+	})
+}
+
+// Resume puts a paused offer back on sale and matches it against the order
+// book again.
+func (s *offerService) Resume(user *models.User, client *requests.ClientInfo, uuid string) (*resources.Offer, *merr.ResponseError) {
+	return s.changeOfferStatus(user, client, uuid, models.AuditEventOfferResumed, func(offer *models.Offer) *merr.ResponseError {
+		if offer.IsExpired() || offer.Resume() != nil {
+			return merr.NewResponseError(http.StatusUnprocessableEntity, ErrOfferIsNotPaused)
+		}
+		return nil
+	})
+}
+
+// changeOfferStatus locks the seller's offer, applies the status change and
+// records it in the audit log.
+func (s *offerService) changeOfferStatus(user *models.User, client *requests.ClientInfo, uuid string, event string, change func(offer *models.Offer) *merr.ResponseError) (*resources.Offer, *merr.ResponseError) {
+	var offer *models.Offer
+	var fills []*models.Purchase
+	var responseError *merr.ResponseError
+
+	if !user.CanTrade() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var offerRepo = s.offerRepo.WithTransaction(tx)
+		var err error
+
+		offer, err = offerRepo.FindByUuidForUpdate(strings.ToLower(uuid))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			responseError = merr.NewResponseError(http.StatusNotFound, ErrOfferNotFound)
+			return err
+		} else if err != nil {
+			return err
+		}
+
+		if !offer.IsOwner(user) {
+			responseError = merr.NewResponseError(http.StatusForbidden, ErrUserIsNotTheOfferOwner)
+			return responseError.Error
+		}
+
+		var before map[string]any = makeOfferAuditSnapshot(offer)
+
+		if responseError = change(offer); responseError != nil {
+			return responseError.Error
+		}
+
+		if err = offerRepo.Update(offer); err != nil {
+			return err
+		}
+
+		err = recordAuditEvent(tx, client, auditEntry{
+			Event:      event,
+			Actor:      user,
+			TargetType: models.AuditTargetOffer,
+			TargetUuid: offer.Uuid,
+			Before:     before,
+			After:      makeOfferAuditSnapshot(offer),
+		})
+		if err != nil {
+			return err
+		}
+
+		fills, err = matchOffer(tx, offer)
+		return err
+	})
+	if responseError != nil {
+		return nil, responseError
+	}
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	dispatchOrderBookFills(s.purchaseService, fills)
+
+	offer, err = s.offerRepo.GetByUuid(offer.Uuid)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return makeOfferResourceFromModel(offer), nil
+}
+
 func (s *offerService) UpdateExpiredOffers() error {
 	return s.offerRepo.UpdateExpiredOffers()
 }
 
+// PublishScheduledOffers publishes the scheduled offers whose time has come
+// and matches them against the order book.
+func (s *offerService) PublishScheduledOffers() error {
+	ids, err := s.offerRepo.ListDueScheduledIds()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		var fills []*models.Purchase
+
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			offer, err := s.offerRepo.WithTransaction(tx).FindByIdForUpdate(id)
+			if err != nil {
+				return err
+			}
+
+			// Taken down before it was due
+			if !offer.IsScheduled() {
+				return nil
+			}
+
+			if err = offer.TransitionTo(models.OfferStatusFresh); err != nil {
+				return err
+			}
+
+			if err = s.offerRepo.WithTransaction(tx).Update(offer); err != nil {
+				return err
+			}
+
+			fills, err = matchOffer(tx, offer)
+			return err
+		})
+		if err != nil {
+			mlog.Log("Failed to publish scheduled offer " + strconv.FormatUint(uint64(id), 10) + ": " + err.Error())
+			continue
+		}
+
+		dispatchOrderBookFills(s.purchaseService, fills)
+	}
+
+	return nil
+}
+
 func (s *offerService) Purchases(offerUuid string, request *requests.ListPurchasesFromOffer, user *models.User) ([]*resources.Purchase, *merr.ResponseError) {
 	var offer *models.Offer
 	var err error
@@ -372,6 +1121,10 @@ func (s *offerService) Purchases(offerUuid string, request *requests.ListPurchas
 		return nil, merr.NewResponseError(http.StatusForbidden, ErrUserIsNotTheOfferOwner)
 	}
 
+	if !user.CanViewFinancials() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
 	purchases, err := s.offerRepo.Purchases(offerUuid, request)
 	if err != nil {
 		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
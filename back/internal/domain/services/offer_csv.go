@@ -0,0 +1,304 @@
+package services
+
+import (
+	"bytes"
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/resources"
+	"ecoply/internal/mlog"
+	"encoding/csv"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// The most rows a single import may hold
+const maxOfferImportRows int = 500
+
+const (
+	OfferFileFormatCsv  string = "csv"
+	OfferFileFormatXlsx string = "xlsx"
+)
+
+// offerCsvColumns are the columns of exported offers. Imports read the
+// writable ones by name and ignore the rest, so an export can be edited and
+// uploaded back.
+var offerCsvColumns = []string{
+	"uuid",
+	"price_per_mwh",
+	"quantity_mwh",
+	"remaining_quantity_mwh",
+	"period_start",
+	"period_end",
+	"description",
+	"energy_type",
+	"submarket",
+	"status",
+}
+
+var offerImportColumns = []string{
+	"price_per_mwh",
+	"quantity_mwh",
+	"period_start",
+	"period_end",
+	"description",
+	"energy_type",
+}
+
+// Import creates an offer per row of the CSV or XLSX file, with the rules of
+// Create. Rows that fail are reported with their line and the valid ones are
+// created together in one transaction, unless it is a dry run.
+func (s *offerService) Import(user *models.User, client *requests.ClientInfo, file io.Reader, request *requests.ImportOffers) (*resources.OfferImport, *merr.ResponseError) {
+	if !user.CanTrade() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	if !user.IsEmailVerified() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrEmailNotVerified)
+	}
+
+	var records [][]string
+	var err error
+	if request.Format == OfferFileFormatXlsx {
+		records, err = readXlsxRecords(file)
+	} else {
+		records, err = readCsvRecords(file)
+	}
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, err)
+	}
+
+	rows, err := mapOfferImportRows(records)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, err)
+	}
+
+	var response *resources.OfferImport = &resources.OfferImport{
+		DryRun: request.DryRun,
+		Rows:   len(rows),
+		Errors: make([]resources.OfferImportError, 0),
+		Offers: make([]*resources.Offer, 0),
+	}
+
+	var energyTypes map[string]*models.EnergyType = make(map[string]*models.EnergyType)
+	var valid []*requests.CreateOffer
+
+	for i, row := range rows {
+		// The header is line 1
+		var line int = i + 2
+
+		offerRequest, err := parseOfferImportRow(row)
+		if err == nil {
+			err = validateCreateRequest(offerRequest)
+		}
+
+		if err == nil && energyTypes[offerRequest.EnergyType] == nil {
+			energyType, findErr := s.energyTypeRepo.GetByType(offerRequest.EnergyType)
+			if errors.Is(findErr, gorm.ErrRecordNotFound) {
+				err = ErrInvalidEnergyType
+			} else if findErr != nil {
+				return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+			} else {
+				energyTypes[offerRequest.EnergyType] = energyType
+			}
+		}
+
+		if err != nil {
+			response.Errors = append(response.Errors, resources.OfferImportError{Line: line, Error: err.Error()})
+			continue
+		}
+
+		valid = append(valid, offerRequest)
+	}
+
+	response.Valid = len(valid)
+
+	if request.DryRun || len(valid) == 0 {
+		return response, nil
+	}
+
+	if err = s.db.Preload("Agent", func(db *gorm.DB) *gorm.DB { return db.Select("id, submarket_id") }).
+		Preload("Agent.Submarket").
+		Find(user).Error; err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	var offers []*models.Offer = make([]*models.Offer, 0, len(valid))
+	var fills []*models.Purchase
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, offerRequest := range valid {
+			var offer *models.Offer = makeOfferFromRequest(user, energyTypes[offerRequest.EnergyType], offerRequest)
+
+			if err := s.insertOffer(tx, user, client, offer); err != nil {
+				return err
+			}
+
+			offer.EnergyType = *energyTypes[offerRequest.EnergyType]
+			offer.Submarket = user.Agent.Submarket
+
+			offerFills, err := matchOffer(tx, offer)
+			if err != nil {
+				return err
+			}
+
+			offers = append(offers, offer)
+			fills = append(fills, offerFills...)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	dispatchOrderBookFills(s.purchaseService, fills)
+
+	for _, offer := range offers {
+		response.Offers = append(response.Offers, makeOfferResourceFromModel(offer))
+	}
+
+	return response, nil
+}
+
+// Export writes the offers of the user's company in the format Import reads.
+func (s *offerService) Export(user *models.User, request *requests.ExportOffers) ([]byte, *merr.ResponseError) {
+	offers, err := s.offerRepo.GetByAgentId(user.AgentId)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	var records [][]string = make([][]string, 0, len(offers)+1)
+	records = append(records, offerCsvColumns)
+
+	for _, offer := range offers {
+		records = append(records, []string{
+			offer.Uuid,
+			strconv.FormatFloat(offer.PricePerMwh, 'f', 2, 64),
+			strconv.FormatFloat(offer.InitialQuantityMwh, 'f', 3, 64),
+			strconv.FormatFloat(offer.RemainingQuantityMwh, 'f', 3, 64),
+			offer.PeriodStart.Format(time.DateOnly),
+			offer.PeriodEnd.Format(time.DateOnly),
+			offer.Description,
+			offer.EnergyType.Type,
+			offer.Submarket.Name,
+			offer.Status,
+		})
+	}
+
+	var content []byte
+	if request.Format == OfferFileFormatXlsx {
+		content, err = writeXlsxRecords(records)
+	} else {
+		content, err = writeCsvRecords(records)
+	}
+	if err != nil {
+		mlog.Log("Failed to export offers: " + err.Error())
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return content, nil
+}
+
+func readCsvRecords(file io.Reader) ([][]string, error) {
+	var reader *csv.Reader = csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	// Rows of another length are reported on their own line by Import
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, ErrInvalidImportFile
+	}
+
+	return records, nil
+}
+
+func writeCsvRecords(records [][]string) ([]byte, error) {
+	var buffer bytes.Buffer
+	var writer *csv.Writer = csv.NewWriter(&buffer)
+
+	if err := writer.WriteAll(records); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// mapOfferImportRows maps each record after the header by the column names
+// of the header, which must have every writable column.
+func mapOfferImportRows(records [][]string) ([]map[string]string, error) {
+	if len(records) == 0 {
+		return nil, ErrInvalidImportFile
+	}
+
+	var header []string = records[0]
+	records = records[1:]
+
+	var indexes map[string]int = make(map[string]int, len(header))
+	for i, column := range header {
+		indexes[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+
+	for _, column := range offerImportColumns {
+		if _, ok := indexes[column]; !ok {
+			return nil, ErrInvalidImportFile
+		}
+	}
+
+	if len(records) == 0 {
+		return nil, ErrInvalidImportFile
+	}
+
+	if len(records) > maxOfferImportRows {
+		return nil, ErrTooManyImportRows
+	}
+
+	var rows []map[string]string = make([]map[string]string, len(records))
+	for i, record := range records {
+		rows[i] = make(map[string]string, len(offerImportColumns))
+		for _, column := range offerImportColumns {
+			// Spreadsheets leave out the empty cells at the end of a row
+			if indexes[column] < len(record) {
+				rows[i][column] = strings.TrimSpace(record[indexes[column]])
+			}
+		}
+	}
+
+	return rows, nil
+}
+
+func parseOfferImportRow(row map[string]string) (*requests.CreateOffer, error) {
+	price, err := strconv.ParseFloat(row["price_per_mwh"], 64)
+	if err != nil {
+		return nil, ErrInvalidPrice
+	}
+
+	quantity, err := strconv.ParseFloat(row["quantity_mwh"], 64)
+	if err != nil {
+		return nil, ErrInvalidQuantity
+	}
+
+	if row["description"] == "" {
+		return nil, ErrMissingDescription
+	}
+
+	if row["energy_type"] == "" {
+		return nil, ErrInvalidEnergyType
+	}
+
+	return &requests.CreateOffer{
+		PricePerMwh: price,
+		QuantityMwh: quantity,
+		PeriodStart: row["period_start"],
+		PeriodEnd:   row["period_end"],
+		Description: row["description"],
+		EnergyType:  row["energy_type"],
+	}, nil
+}
@@ -0,0 +1,355 @@
+package services
+
+import (
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/repository"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/resources"
+	"ecoply/internal/domain/utils"
+	"ecoply/internal/mlog"
+	"errors"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// How many upcoming offers of a series are previewed by default
+const defaultOfferTemplatePreviewCount int = 3
+
+type OfferTemplateService interface {
+	Create(user *models.User, request *requests.SaveOfferTemplate) (*resources.OfferTemplate, *merr.ResponseError)
+	List(user *models.User) ([]*resources.OfferTemplate, *merr.ResponseError)
+	GetByUuid(user *models.User, uuid string) (*resources.OfferTemplate, *merr.ResponseError)
+	Update(user *models.User, uuid string, request *requests.SaveOfferTemplate) (*resources.OfferTemplate, *merr.ResponseError)
+	Delete(user *models.User, uuid string) *merr.ResponseError
+	Publish(user *models.User, client *requests.ClientInfo, uuid string, request *requests.PublishOfferTemplate) (*resources.Offer, *merr.ResponseError)
+	Preview(user *models.User, uuid string, request *requests.PreviewOfferTemplate) ([]*resources.OfferTemplateRun, *merr.ResponseError)
+	Pause(user *models.User, uuid string) (*resources.OfferTemplate, *merr.ResponseError)
+	Resume(user *models.User, uuid string) (*resources.OfferTemplate, *merr.ResponseError)
+	PublishDueOffers() error
+}
+
+type offerTemplateService struct {
+	templateRepo   repository.OfferTemplateRepository
+	energyTypeRepo repository.EnergyTypeRepository
+	offerService   OfferService
+	db             *gorm.DB
+}
+
+func NewOfferTemplateService(db *gorm.DB) OfferTemplateService {
+	return &offerTemplateService{
+		templateRepo:   repository.NewOfferTemplateRepository(db),
+		energyTypeRepo: repository.NewEnergyRepository(db),
+		offerService:   NewOfferService(db),
+		db:             db,
+	}
+}
+
+func (s *offerTemplateService) Create(user *models.User, request *requests.SaveOfferTemplate) (*resources.OfferTemplate, *merr.ResponseError) {
+	if !user.CanTrade() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	energyType, err := s.energyTypeRepo.GetByType(request.EnergyType)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidEnergyType)
+	} else if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	var template *models.OfferTemplate = &models.OfferTemplate{
+		Uuid:     NewUuidV7String(),
+		Status:   models.OfferTemplateStatusActive,
+		SellerId: user.ID,
+	}
+	applyOfferTemplateRequest(template, energyType, request)
+
+	if err = s.templateRepo.Create(template); err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	template.Seller = *user
+
+	return makeOfferTemplateResourceFromModel(template), nil
+}
+
+func (s *offerTemplateService) List(user *models.User) ([]*resources.OfferTemplate, *merr.ResponseError) {
+	templates, err := s.templateRepo.ListFromAgent(user.AgentId)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return mapSlice(templates, makeOfferTemplateResourceFromModel), nil
+}
+
+func (s *offerTemplateService) GetByUuid(user *models.User, uuid string) (*resources.OfferTemplate, *merr.ResponseError) {
+	template, responseError := s.findOwnTemplate(user, uuid)
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	return makeOfferTemplateResourceFromModel(template), nil
+}
+
+func (s *offerTemplateService) Update(user *models.User, uuid string, request *requests.SaveOfferTemplate) (*resources.OfferTemplate, *merr.ResponseError) {
+	if !user.CanTrade() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	template, responseError := s.findOwnTemplate(user, uuid)
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	energyType, err := s.energyTypeRepo.GetByType(request.EnergyType)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidEnergyType)
+	} else if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	applyOfferTemplateRequest(template, energyType, request)
+
+	if err = s.templateRepo.Update(template); err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return makeOfferTemplateResourceFromModel(template), nil
+}
+
+func (s *offerTemplateService) Delete(user *models.User, uuid string) *merr.ResponseError {
+	if !user.CanTrade() {
+		return merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	template, responseError := s.findOwnTemplate(user, uuid)
+	if responseError != nil {
+		return responseError
+	}
+
+	if err := s.templateRepo.Delete(template); err != nil {
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return nil
+}
+
+// Publish creates an offer from the template for the given period, as if it
+// was sent to POST /offers.
+func (s *offerTemplateService) Publish(user *models.User, client *requests.ClientInfo, uuid string, request *requests.PublishOfferTemplate) (*resources.Offer, *merr.ResponseError) {
+	template, responseError := s.findOwnTemplate(user, uuid)
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	return s.offerService.Create(user, client, makeCreateOfferFromTemplate(template, request.PeriodStart, request.PeriodEnd))
+}
+
+// Preview lists the next offers of the series from now on, whether it is
+// paused or not.
+func (s *offerTemplateService) Preview(user *models.User, uuid string, request *requests.PreviewOfferTemplate) ([]*resources.OfferTemplateRun, *merr.ResponseError) {
+	template, responseError := s.findOwnTemplate(user, uuid)
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	if !template.IsRecurring() {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrOfferTemplateNotRecurring)
+	}
+
+	var count int = request.Count
+	if count == 0 {
+		count = defaultOfferTemplatePreviewCount
+	}
+
+	var response []*resources.OfferTemplateRun = make([]*resources.OfferTemplateRun, 0, count)
+	var publishAt time.Time = utils.NowInLocal()
+
+	for range count {
+		publishAt = template.NextPublishAfter(publishAt)
+		periodStart, periodEnd := template.PeriodFor(publishAt)
+
+		response = append(response, &resources.OfferTemplateRun{
+			PublishAt:   publishAt.Format(time.RFC3339),
+			PeriodStart: periodStart.Format(time.DateOnly),
+			PeriodEnd:   periodEnd.Format(time.DateOnly),
+			PricePerMwh: template.PricePerMwh,
+			QuantityMwh: template.QuantityMwh,
+		})
+	}
+
+	return response, nil
+}
+
+func (s *offerTemplateService) Pause(user *models.User, uuid string) (*resources.OfferTemplate, *merr.ResponseError) {
+	if !user.CanTrade() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	template, responseError := s.findOwnTemplate(user, uuid)
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	if !template.IsRecurring() {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrOfferTemplateNotRecurring)
+	}
+
+	if template.IsPaused() {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrOfferTemplateAlreadyPaused)
+	}
+
+	template.Status = models.OfferTemplateStatusPaused
+	template.NextPublishAt = nil
+
+	if err := s.templateRepo.Update(template); err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return makeOfferTemplateResourceFromModel(template), nil
+}
+
+// Resume starts the series again from the next publish day, the runs missed
+// while it was paused are not published.
+func (s *offerTemplateService) Resume(user *models.User, uuid string) (*resources.OfferTemplate, *merr.ResponseError) {
+	if !user.CanTrade() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	template, responseError := s.findOwnTemplate(user, uuid)
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	if !template.IsPaused() {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrOfferTemplateIsNotPaused)
+	}
+
+	template.Status = models.OfferTemplateStatusActive
+	scheduleOfferTemplate(template)
+
+	if err := s.templateRepo.Update(template); err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return makeOfferTemplateResourceFromModel(template), nil
+}
+
+// PublishDueOffers publishes the offer of every series whose day has come.
+// Each run is claimed before publishing so it happens once, and a run that
+// fails is logged and skipped rather than retried every tick.
+func (s *offerTemplateService) PublishDueOffers() error {
+	var now time.Time = utils.NowInLocal()
+
+	templates, err := s.templateRepo.ListDue(now)
+	if err != nil {
+		return err
+	}
+
+	for _, template := range templates {
+		var publishAt time.Time = *template.NextPublishAt
+
+		claimed, err := s.templateRepo.ClaimRun(template, template.NextPublishAfter(now))
+		if err != nil || !claimed {
+			continue
+		}
+
+		if template.Seller.IsSuspended() || template.Seller.IsAnonymized() {
+			continue
+		}
+
+		periodStart, periodEnd := template.PeriodFor(publishAt)
+		var request *requests.CreateOffer = makeCreateOfferFromTemplate(
+			template,
+			periodStart.Format(time.DateOnly),
+			periodEnd.Format(time.DateOnly),
+		)
+
+		if _, responseError := s.offerService.Create(&template.Seller, nil, request); responseError != nil {
+			mlog.Log("Failed to publish offer of template " + template.Uuid + ": " + responseError.Error.Error())
+		}
+	}
+
+	return nil
+}
+
+func (s *offerTemplateService) findOwnTemplate(user *models.User, uuid string) (*models.OfferTemplate, *merr.ResponseError) {
+	template, err := s.templateRepo.FindByUuid(uuid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, merr.NewResponseError(http.StatusNotFound, ErrOfferTemplateNotFound)
+		}
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if !template.IsOwner(user) {
+		return nil, merr.NewResponseError(http.StatusNotFound, ErrOfferTemplateNotFound)
+	}
+
+	return template, nil
+}
+
+func applyOfferTemplateRequest(template *models.OfferTemplate, energyType *models.EnergyType, request *requests.SaveOfferTemplate) {
+	template.Name = request.Name
+	template.PricePerMwh = request.PricePerMwh
+	template.QuantityMwh = request.QuantityMwh
+	template.Description = request.Description
+	template.EnergyTypeId = energyType.ID
+	template.EnergyType = *energyType
+	template.PublishDay = request.PublishDay
+	template.PeriodOffsetMonths = 1
+
+	if request.PeriodOffsetMonths != 0 {
+		template.PeriodOffsetMonths = request.PeriodOffsetMonths
+	}
+
+	if !template.IsPaused() {
+		scheduleOfferTemplate(template)
+	}
+}
+
+// scheduleOfferTemplate sets the next publish day of an active series.
+func scheduleOfferTemplate(template *models.OfferTemplate) {
+	template.NextPublishAt = nil
+
+	if template.IsRecurring() {
+		var nextPublishAt time.Time = template.NextPublishAfter(utils.NowInLocal())
+		template.NextPublishAt = &nextPublishAt
+	}
+}
+
+func makeCreateOfferFromTemplate(template *models.OfferTemplate, periodStart string, periodEnd string) *requests.CreateOffer {
+	return &requests.CreateOffer{
+		PricePerMwh: template.PricePerMwh,
+		QuantityMwh: template.QuantityMwh,
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+		Description: template.Description,
+		EnergyType:  template.EnergyType.Type,
+	}
+}
+
+func makeOfferTemplateResourceFromModel(template *models.OfferTemplate) *resources.OfferTemplate {
+	var response *resources.OfferTemplate = &resources.OfferTemplate{
+		Uuid:               template.Uuid,
+		Name:               template.Name,
+		PricePerMwh:        template.PricePerMwh,
+		QuantityMwh:        template.QuantityMwh,
+		Description:        template.Description,
+		EnergyType:         template.EnergyType.Type,
+		PublishDay:         template.PublishDay,
+		PeriodOffsetMonths: template.PeriodOffsetMonths,
+		Status:             template.Status,
+		SellerUuid:         template.Seller.Uuid,
+		CreatedAt:          utils.TruncateDateToLocal(template.CreatedAt).Format(time.RFC3339),
+	}
+
+	if template.NextPublishAt != nil {
+		var nextPublishAt string = utils.TruncateDateToLocal(*template.NextPublishAt).Format(time.RFC3339)
+		response.NextPublishAt = &nextPublishAt
+	}
+
+	return response
+}
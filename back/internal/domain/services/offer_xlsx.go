@@ -0,0 +1,119 @@
+package services
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const offerXlsxSheet string = "Offers"
+
+// offerXlsxDateColumns hold dates, which spreadsheets store as serial numbers
+// when they are typed as dates rather than text
+var offerXlsxDateColumns = []string{"period_start", "period_end"}
+
+// readXlsxRecords reads the first sheet of the workbook, with the raw value
+// of each cell rather than its display format.
+func readXlsxRecords(file io.Reader) ([][]string, error) {
+	workbook, err := excelize.OpenReader(file, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, ErrInvalidImportFile
+	}
+	defer workbook.Close()
+
+	sheets := workbook.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, ErrInvalidImportFile
+	}
+
+	records, err := workbook.GetRows(sheets[0], excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, ErrInvalidImportFile
+	}
+
+	if len(records) > 0 {
+		convertXlsxDates(records, workbook.WorkBook.WorkbookPr != nil && workbook.WorkBook.WorkbookPr.Date1904)
+	}
+
+	return records, nil
+}
+
+// convertXlsxDates turns the serial numbers of the date columns into dates
+// in the layout Import expects.
+func convertXlsxDates(records [][]string, date1904 bool) {
+	for i, column := range records[0] {
+		if !containsFold(offerXlsxDateColumns, column) {
+			continue
+		}
+
+		for _, record := range records[1:] {
+			if i >= len(record) {
+				continue
+			}
+
+			serial, err := strconv.ParseFloat(strings.TrimSpace(record[i]), 64)
+			if err != nil {
+				continue
+			}
+
+			if date, err := excelize.ExcelDateToTime(serial, date1904); err == nil {
+				record[i] = date.Format(time.DateOnly)
+			}
+		}
+	}
+}
+
+// offerXlsxNumberColumns are exported as numeric cells
+var offerXlsxNumberColumns = []string{"price_per_mwh", "quantity_mwh", "remaining_quantity_mwh"}
+
+// writeXlsxRecords writes the records to a single sheet, the number columns
+// as numeric cells and everything else, dates included, as text.
+func writeXlsxRecords(records [][]string) ([]byte, error) {
+	workbook := excelize.NewFile()
+	defer workbook.Close()
+
+	if err := workbook.SetSheetName(workbook.GetSheetName(0), offerXlsxSheet); err != nil {
+		return nil, err
+	}
+
+	for i, record := range records {
+		var row []any = make([]any, len(record))
+		for j, value := range record {
+			row[j] = value
+			if i == 0 || !containsFold(offerXlsxNumberColumns, records[0][j]) {
+				continue
+			}
+			if number, err := strconv.ParseFloat(value, 64); err == nil {
+				row[j] = number
+			}
+		}
+
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = workbook.SetSheetRow(offerXlsxSheet, cell, &row); err != nil {
+			return nil, err
+		}
+	}
+
+	buffer, err := workbook.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, strings.TrimSpace(value)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,444 @@
+package services
+
+import (
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/repository"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/resources"
+	"ecoply/internal/domain/utils"
+	"ecoply/internal/matching"
+	"errors"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OrderBookService interface {
+	PlaceBid(user *models.User, request *requests.PlaceBid) (*resources.PlacedBid, *merr.ResponseError)
+	CancelBid(user *models.User, uuid string) *merr.ResponseError
+	Bids(user *models.User, request *requests.ListBids) (*utils.PaginationWrapper[*resources.Bid], *merr.ResponseError)
+	Depth(request *requests.OrderBookDepth) (*resources.OrderBookDepth, *merr.ResponseError)
+	UpdateExpiredBids() error
+}
+
+type orderBookService struct {
+	bidRepo         repository.BidRepository
+	offerRepo       repository.OfferRepository
+	submarketRepo   repository.SubmarketRepository
+	energyTypeRepo  repository.EnergyTypeRepository
+	purchaseService *purchaseService
+	db              *gorm.DB
+}
+
+func NewOrderBookService(db *gorm.DB) OrderBookService {
+	return &orderBookService{
+		bidRepo:         repository.NewBidRepository(db),
+		offerRepo:       repository.NewOfferRepository(db),
+		submarketRepo:   repository.NewSubmarketRepository(db),
+		energyTypeRepo:  repository.NewEnergyRepository(db),
+		purchaseService: newPurchaseService(db),
+		db:              db,
+	}
+}
+
+// PlaceBid rests a limit order on the book after matching it against the
+// offers it crosses. The purchases it was filled with are returned along
+// with the bid.
+func (s *orderBookService) PlaceBid(user *models.User, request *requests.PlaceBid) (*resources.PlacedBid, *merr.ResponseError) {
+	var fills []*models.Purchase
+
+	if !user.CanTrade() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	if !user.IsEmailVerified() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrEmailNotVerified)
+	}
+
+	energyType, err := s.energyTypeRepo.GetByType(request.EnergyType)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidEnergyType)
+	} else if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	submarket, err := s.submarketRepo.FindByName(request.Submarket)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidSubmarket)
+	} else if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if err = validateCreatePeriodFromRequest(request.PeriodStart, request.PeriodEnd); err != nil {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, err)
+	}
+
+	parsedStartPeriod, _ := parseDate(request.PeriodStart)
+	parsedEndPeriod, _ := parseDate(request.PeriodEnd)
+
+	var bid *models.Bid = &models.Bid{
+		Uuid:                 NewUuidV7String(),
+		LimitPricePerMwh:     request.LimitPricePerMwh,
+		QuantityMwh:          request.QuantityMwh,
+		RemainingQuantityMwh: request.QuantityMwh,
+		PeriodStart:          parsedStartPeriod,
+		PeriodEnd:            parsedEndPeriod,
+		Status:               models.BidStatusOpen,
+		PaymentMethod:        request.PaymentMethod,
+		EnergyTypeId:         energyType.ID,
+		SubmarketId:          submarket.ID,
+		BuyerId:              user.ID,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.bidRepo.WithTransaction(tx).Create(bid); err != nil {
+			return err
+		}
+
+		bid.Buyer = *user
+
+		var err error
+		fills, err = matchBid(tx, bid)
+		return err
+	})
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	dispatchOrderBookFills(s.purchaseService, fills)
+
+	bid.EnergyType = *energyType
+	bid.Submarket = *submarket
+
+	var response *resources.PlacedBid = &resources.PlacedBid{
+		Bid:       *makeBidResourceFromModel(bid),
+		Purchases: make([]*resources.Purchase, 0, len(fills)),
+	}
+	for _, purchase := range fills {
+		response.Purchases = append(response.Purchases, makePurchaseResourceFromModel(purchase))
+	}
+
+	return response, nil
+}
+
+// CancelBid takes what is left of the bid off the book, its fills stand.
+func (s *orderBookService) CancelBid(user *models.User, uuid string) *merr.ResponseError {
+	var responseError *merr.ResponseError
+
+	if !user.CanTrade() {
+		return merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var bidRepo = s.bidRepo.WithTransaction(tx)
+
+		bid, err := bidRepo.FindByUuidForUpdate(uuid)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				responseError = merr.NewResponseError(http.StatusNotFound, ErrBidNotFound)
+			}
+			return err
+		}
+
+		if !bid.IsOwner(user) {
+			responseError = merr.NewResponseError(http.StatusForbidden, ErrUserIsNotTheBidOwner)
+			return ErrUserIsNotTheBidOwner
+		}
+
+		if !bid.IsOpen() {
+			responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrBidIsNotOpen)
+			return ErrBidIsNotOpen
+		}
+
+		bid.Status = models.BidStatusCancelled
+		return bidRepo.Update(bid)
+	})
+
+	if responseError != nil {
+		return responseError
+	}
+
+	if err != nil {
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return nil
+}
+
+func (s *orderBookService) Bids(user *models.User, request *requests.ListBids) (*utils.PaginationWrapper[*resources.Bid], *merr.ResponseError) {
+	list, err := s.bidRepo.ListFromAgent(user.AgentId, request)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	var response utils.PaginationWrapper[*resources.Bid]
+
+	response.Page = list.Page
+	response.PageSize = list.PageSize
+	response.HasNext = list.HasNext
+	response.HasPrev = list.HasPrev
+	response.Data = make([]*resources.Bid, 0, len(list.Data))
+
+	for _, bid := range list.Data {
+		response.Data = append(response.Data, makeBidResourceFromModel(bid))
+	}
+
+	return &response, nil
+}
+
+// Depth aggregates the open bids and offers of a book by price.
+func (s *orderBookService) Depth(request *requests.OrderBookDepth) (*resources.OrderBookDepth, *merr.ResponseError) {
+	energyType, err := s.energyTypeRepo.GetByType(request.EnergyType)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidEnergyType)
+	} else if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	submarket, err := s.submarketRepo.FindByName(request.Submarket)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidSubmarket)
+	} else if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	periodStart, err := parseDate(request.PeriodStart)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidPeriod)
+	}
+
+	periodEnd, err := parseDate(request.PeriodEnd)
+	if err != nil || periodStart.After(periodEnd) {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidPeriod)
+	}
+
+	var key repository.OrderBookKey = repository.OrderBookKey{
+		SubmarketId:  submarket.ID,
+		EnergyTypeId: energyType.ID,
+		PeriodStart:  periodStart,
+		PeriodEnd:    periodEnd,
+	}
+
+	bids, err := s.bidRepo.Depth(key)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	asks, err := s.offerRepo.Depth(key)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return &resources.OrderBookDepth{
+		Submarket:   submarket.Name,
+		EnergyType:  energyType.Type,
+		PeriodStart: periodStart.Format(time.DateOnly),
+		PeriodEnd:   periodEnd.Format(time.DateOnly),
+		Bids:        makePriceLevelResources(bids),
+		Asks:        makePriceLevelResources(asks),
+	}, nil
+}
+
+func (s *orderBookService) UpdateExpiredBids() error {
+	return s.bidRepo.UpdateExpiredBids()
+}
+
+// dispatchOrderBookFills starts the payment of each fill once the matching
+// transaction is committed.
+func dispatchOrderBookFills(purchaseService *purchaseService, fills []*models.Purchase) {
+	for _, purchase := range fills {
+		purchaseService.dispatchPurchasePaymentProcessor(&purchase.Buyer, purchase)
+	}
+}
+
+func orderBookKeyOfBid(bid *models.Bid) repository.OrderBookKey {
+	return repository.OrderBookKey{
+		SubmarketId:  bid.SubmarketId,
+		EnergyTypeId: bid.EnergyTypeId,
+		PeriodStart:  bid.PeriodStart,
+		PeriodEnd:    bid.PeriodEnd,
+	}
+}
+
+func orderBookKeyOfOffer(offer *models.Offer) repository.OrderBookKey {
+	return repository.OrderBookKey{
+		SubmarketId:  offer.SubmarketId,
+		EnergyTypeId: offer.EnergyTypeId,
+		PeriodStart:  offer.PeriodStart,
+		PeriodEnd:    offer.PeriodEnd,
+	}
+}
+
+// matchBid fills the bid from the offers it crosses, the Buyer must be
+// loaded. It runs inside the transaction that placed the bid and returns
+// the purchases created, with their relations loaded.
+func matchBid(tx *gorm.DB, bid *models.Bid) ([]*models.Purchase, error) {
+	var key repository.OrderBookKey = orderBookKeyOfBid(bid)
+
+	if err := repository.LockOrderBook(tx, key); err != nil {
+		return nil, err
+	}
+
+	offers, err := repository.NewOfferRepository(tx).ListCrossingForUpdate(key, bid.LimitPricePerMwh, bid.Buyer.AgentId)
+	if err != nil {
+		return nil, err
+	}
+
+	var book *matching.Book = matching.NewBook()
+	for _, offer := range offers {
+		book.Add(makeAskOrder(offer))
+	}
+
+	return applyFills(tx, book.Submit(makeBidOrder(bid)), []*models.Bid{bid}, offers)
+}
+
+// matchOffer sells the offer to the bids it crosses, the Seller and the
+// PriceTiers must be loaded. Tiered offers stay off the book. It runs inside
+// the transaction that created or changed the offer and returns the
+// purchases created, with their relations loaded.
+func matchOffer(tx *gorm.DB, offer *models.Offer) ([]*models.Purchase, error) {
+	if offer.IsAuction() || offer.IsRestricted() || offer.HasLotRules() || offer.HasPriceTiers() || offer.RemainingQuantityMwh <= 0 || !offer.IsOnSale() {
+		return nil, nil
+	}
+
+	var key repository.OrderBookKey = orderBookKeyOfOffer(offer)
+
+	if err := repository.LockOrderBook(tx, key); err != nil {
+		return nil, err
+	}
+
+	bids, err := repository.NewBidRepository(tx).ListCrossingForUpdate(key, offer.PricePerMwh, offer.Seller.AgentId)
+	if err != nil {
+		return nil, err
+	}
+
+	var book *matching.Book = matching.NewBook()
+	for _, bid := range bids {
+		book.Add(makeBidOrder(bid))
+	}
+
+	return applyFills(tx, book.Submit(makeAskOrder(offer)), bids, []*models.Offer{offer})
+}
+
+// applyFills turns each fill into a waiting purchase of the bid's buyer,
+// taking its quantity out of the bid and the offer.
+func applyFills(tx *gorm.DB, fills []matching.Fill, bids []*models.Bid, offers []*models.Offer) ([]*models.Purchase, error) {
+	var bidRepo = repository.NewBidRepository(tx)
+	var offerRepo = repository.NewOfferRepository(tx)
+	var purchaseRepo = repository.NewPurchaseRepository(tx)
+
+	var bidsByUuid map[string]*models.Bid = make(map[string]*models.Bid, len(bids))
+	for _, bid := range bids {
+		bidsByUuid[bid.Uuid] = bid
+	}
+
+	var offersByUuid map[string]*models.Offer = make(map[string]*models.Offer, len(offers))
+	for _, offer := range offers {
+		offersByUuid[offer.Uuid] = offer
+	}
+
+	var purchases []*models.Purchase = make([]*models.Purchase, 0, len(fills))
+
+	for _, fill := range fills {
+		var bid *models.Bid = bidsByUuid[fill.BidId]
+		var offer *models.Offer = offersByUuid[fill.AskId]
+		var quantity float64 = matching.QuantityFromUnits(fill.Quantity)
+
+		bid.RemainingQuantityMwh = matching.QuantityFromUnits(matching.QuantityToUnits(bid.RemainingQuantityMwh) - fill.Quantity)
+		if bid.RemainingQuantityMwh == 0 {
+			bid.Status = models.BidStatusFilled
+		}
+
+		if err := bidRepo.Update(bid); err != nil {
+			return nil, err
+		}
+
+		if err := offer.Sell(quantity); err != nil {
+			return nil, err
+		}
+
+		if err := offerRepo.Update(offer); err != nil {
+			return nil, err
+		}
+
+		var purchase *models.Purchase = &models.Purchase{
+			Uuid:          NewUuidV7String(),
+			OfferId:       offer.ID,
+			BidId:         &bid.ID,
+			PricePerMwh:   matching.PriceFromUnits(fill.Price),
+			PaymentMethod: bid.PaymentMethod,
+			Status:        models.PurchaseStatusWaiting,
+			BuyerId:       bid.BuyerId,
+			QuantityMwh:   quantity,
+		}
+
+		if err := purchaseRepo.Create(purchase); err != nil {
+			return nil, err
+		}
+
+		if err := allocatePurchaseDeliveries(tx, purchase); err != nil {
+			return nil, err
+		}
+
+		if err := preloadPurchaseResource(tx, purchase); err != nil {
+			return nil, err
+		}
+
+		purchases = append(purchases, purchase)
+	}
+
+	return purchases, nil
+}
+
+func makeBidOrder(bid *models.Bid) *matching.Order {
+	return &matching.Order{
+		Id:       bid.Uuid,
+		Side:     matching.Bid,
+		Owner:    bid.Buyer.AgentId,
+		Price:    matching.PriceToUnits(bid.LimitPricePerMwh),
+		Quantity: matching.QuantityToUnits(bid.RemainingQuantityMwh),
+		Sequence: uint64(bid.ID),
+	}
+}
+
+func makeAskOrder(offer *models.Offer) *matching.Order {
+	return &matching.Order{
+		Id:       offer.Uuid,
+		Side:     matching.Ask,
+		Owner:    offer.Seller.AgentId,
+		Price:    matching.PriceToUnits(offer.PricePerMwh),
+		Quantity: matching.QuantityToUnits(offer.RemainingQuantityMwh),
+		Sequence: uint64(offer.ID),
+	}
+}
+
+func makePriceLevelResources(levels []*repository.PriceLevel) []*resources.PriceLevel {
+	response := make([]*resources.PriceLevel, 0, len(levels))
+	for _, level := range levels {
+		response = append(response, &resources.PriceLevel{
+			PricePerMwh: level.PricePerMwh,
+			QuantityMwh: level.QuantityMwh,
+			Orders:      level.Orders,
+		})
+	}
+	return response
+}
+
+func makeBidResourceFromModel(bid *models.Bid) *resources.Bid {
+	return &resources.Bid{
+		Uuid:                 bid.Uuid,
+		LimitPricePerMwh:     bid.LimitPricePerMwh,
+		QuantityMwh:          bid.QuantityMwh,
+		RemainingQuantityMwh: bid.RemainingQuantityMwh,
+		PeriodStart:          bid.PeriodStart.Format(time.DateOnly),
+		PeriodEnd:            bid.PeriodEnd.Format(time.DateOnly),
+		Status:               bid.Status,
+		PaymentMethod:        bid.PaymentMethod,
+		EnergyType:           bid.EnergyType.Type,
+		Submarket:            bid.Submarket.Name,
+		BuyerUuid:            bid.Buyer.Uuid,
+		CreatedAt:            utils.TruncateDateToLocal(bid.CreatedAt).Format(time.RFC3339),
+	}
+}
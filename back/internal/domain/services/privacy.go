@@ -0,0 +1,275 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"ecoply/internal/config"
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/repository"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/resources"
+	"ecoply/internal/domain/utils"
+	"ecoply/internal/mlog"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PrivacyService answers the data subject requests of the LGPD: a copy of
+// everything the platform holds about the user and the deletion of the
+// account.
+type PrivacyService interface {
+	Export(user *models.User) ([]byte, *merr.ResponseError)
+	DeleteAccount(user *models.User, request *requests.DeleteAccount) *merr.ResponseError
+}
+
+type privacyService struct {
+	userRepo         repository.UserRepository
+	offerRepo        repository.OfferRepository
+	rfqRepo          repository.RfqRepository
+	bidRepo          repository.BidRepository
+	templateRepo     repository.OfferTemplateRepository
+	purchaseRepo     repository.PurchaseRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	userTokenRepo    repository.UserTokenRepository
+	apiKeyRepo       repository.ApiKeyRepository
+	twoFactorRepo    repository.TwoFactorRepository
+	auditEventRepo   repository.AuditEventRepository
+	twoFactorService TwoFactorService
+	contractService  ContractService
+	hasher           PasswordHasher
+	db               *gorm.DB
+}
+
+func NewPrivacyService(cfg *config.Config, db *gorm.DB) PrivacyService {
+	return &privacyService{
+		userRepo:         repository.NewUserRepository(db),
+		offerRepo:        repository.NewOfferRepository(db),
+		rfqRepo:          repository.NewRfqRepository(db),
+		bidRepo:          repository.NewBidRepository(db),
+		templateRepo:     repository.NewOfferTemplateRepository(db),
+		purchaseRepo:     repository.NewPurchaseRepository(db),
+		refreshTokenRepo: repository.NewRefreshTokenRepository(db),
+		userTokenRepo:    repository.NewUserTokenRepository(db),
+		apiKeyRepo:       repository.NewApiKeyRepository(db),
+		twoFactorRepo:    repository.NewTwoFactorRepository(db),
+		auditEventRepo:   repository.NewAuditEventRepository(db),
+		twoFactorService: NewTwoFactorService(cfg, db),
+		contractService:  NewContractService(db),
+		hasher:           NewPasswordHasher(cfg),
+		db:               db,
+	}
+}
+
+// Export builds a zip archive with one JSON file per kind of record. Offers,
+// purchases and sales are the ones made by the user, not by the whole agent.
+func (s *privacyService) Export(user *models.User) ([]byte, *merr.ResponseError) {
+	me, responseError := makeMeResource(s.db, user)
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	twoFactorEnabled, responseError := s.twoFactorService.IsEnabled(user)
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	offers, err := s.offerRepo.GetBySellerId(user.ID)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	purchases, err := s.purchaseRepo.AllFromBuyer(user.ID)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	sales, err := s.purchaseRepo.AllFromSeller(user.ID)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	var contracts []*resources.Contract = make([]*resources.Contract, 0)
+	for _, purchase := range append(purchases, sales...) {
+		if !purchase.IsCompleted() {
+			continue
+		}
+
+		contract, responseError := s.contractService.GetForAdmin(purchase.Uuid)
+		if responseError != nil {
+			return nil, responseError
+		}
+		contracts = append(contracts, contract)
+	}
+
+	var files = []struct {
+		name    string
+		content any
+	}{
+		{"profile.json", &resources.ExportProfile{
+			Me:               *me,
+			Uuid:             user.Uuid,
+			TwoFactorEnabled: twoFactorEnabled,
+			CreatedAt:        utils.TruncateDateToLocal(user.CreatedAt).Format(time.RFC3339),
+		}},
+		{"offers.json", mapSlice(offers, makeOfferResourceFromModel)},
+		{"purchases.json", mapSlice(purchases, makePurchaseResourceFromModel)},
+		{"sales.json", mapSlice(sales, makePurchaseResourceFromModel)},
+		{"contracts.json", contracts},
+	}
+
+	var buffer bytes.Buffer
+	var archive *zip.Writer = zip.NewWriter(&buffer)
+
+	for _, file := range files {
+		if err = writeJsonToZip(archive, file.name, file.content); err != nil {
+			mlog.Log("Failed to write export file: " + err.Error())
+			return nil, merr.NewResponseError(http.StatusInternalServerError, ErrFailedToExportData)
+		}
+	}
+
+	if err = archive.Close(); err != nil {
+		mlog.Log("Failed to close export archive: " + err.Error())
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrFailedToExportData)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// DeleteAccount anonymizes the user instead of removing the row. Purchases
+// and contracts must stay available to the counterparty and for fiscal
+// purposes, so only the personal fields are scrubbed and every way of
+// authenticating as the user is removed.
+func (s *privacyService) DeleteAccount(user *models.User, request *requests.DeleteAccount) *merr.ResponseError {
+	matches, err := s.hasher.Verify(request.Password, user.Password)
+	if err != nil {
+		mlog.Log("Failed to verify password: " + err.Error())
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if !matches {
+		return merr.NewResponseError(http.StatusUnprocessableEntity, ErrIncorrectPassword)
+	}
+
+	if user.IsAgentOwner() {
+		if responseError := s.checkOtherOwners(user); responseError != nil {
+			return responseError
+		}
+	}
+
+	password, err := NewOpaqueToken()
+	if err != nil {
+		mlog.Log("Failed to generate password: " + err.Error())
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	passwordHash, err := s.hasher.Hash(password)
+	if err != nil {
+		mlog.Log("Failed to hash password: " + err.Error())
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var email string = fmt.Sprintf("deleted-%s@deleted.invalid", user.Uuid)
+
+		if err := s.userRepo.WithTransaction(tx).Anonymize(user, email, passwordHash); err != nil {
+			return err
+		}
+
+		if err := s.refreshTokenRepo.WithTransaction(tx).RevokeAllFromUser(user.ID); err != nil {
+			return err
+		}
+
+		if err := s.userTokenRepo.WithTransaction(tx).DeleteFromUser(user.ID); err != nil {
+			return err
+		}
+
+		if err := s.apiKeyRepo.WithTransaction(tx).RevokeAllFromUser(user.ID); err != nil {
+			return err
+		}
+
+		twoFactor, err := s.twoFactorRepo.WithTransaction(tx).FindByUserId(user.ID)
+		if err == nil {
+			if err = s.twoFactorRepo.WithTransaction(tx).Delete(twoFactor); err != nil {
+				return err
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err := s.rfqRepo.WithTransaction(tx).CancelOpenFromBuyer(user.ID); err != nil {
+			return err
+		}
+
+		if err := s.bidRepo.WithTransaction(tx).CancelOpenFromBuyer(user.ID); err != nil {
+			return err
+		}
+
+		if err := s.templateRepo.WithTransaction(tx).PauseActiveFromSeller(user.ID); err != nil {
+			return err
+		}
+
+		if err := s.auditEventRepo.WithTransaction(tx).AnonymizeFromUser(user); err != nil {
+			return err
+		}
+
+		return s.offerRepo.WithTransaction(tx).ExpireActiveFromSeller(user.ID)
+	})
+	if err != nil {
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	forgetCachedUser(user.Uuid)
+	forgetCachedSessionsFromUser(user.ID)
+	forgetCachedApiKeysFromUser(user.ID)
+
+	return nil
+}
+
+// checkOtherOwners keeps an agent with members from losing its last owner.
+// A user alone in the agent can always leave.
+func (s *privacyService) checkOtherOwners(user *models.User) *merr.ResponseError {
+	members, err := s.userRepo.ListByAgentId(user.AgentId)
+	if err != nil {
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if len(members) <= 1 {
+		return nil
+	}
+
+	owners, err := s.userRepo.CountByAgentIdAndRole(user.AgentId, models.UserRoleOwner)
+	if err != nil {
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if owners <= 1 {
+		return merr.NewResponseError(http.StatusUnprocessableEntity, ErrLastOwnerCannotLeave)
+	}
+
+	return nil
+}
+
+func writeJsonToZip(archive *zip.Writer, name string, content any) error {
+	writer, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	var encoder *json.Encoder = json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(content)
+}
+
+func mapSlice[T any, R any](items []T, fn func(T) R) []R {
+	var result []R = make([]R, len(items))
+	for i, item := range items {
+		result[i] = fn(item)
+	}
+	return result
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"ecoply/internal/config"
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/repository"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/resources"
+	"ecoply/internal/mail"
+	"ecoply/internal/mlog"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+type ProfileService interface {
+	Update(user *models.User, request *requests.UpdateProfile) (*resources.Me, *merr.ResponseError)
+	ChangePassword(user *models.User, request *requests.ChangePassword) *merr.ResponseError
+	UpdateAddress(user *models.User, request *requests.Address) (*resources.Me, *merr.ResponseError)
+}
+
+type profileService struct {
+	userRepo         repository.UserRepository
+	agentRepo        repository.AgentRepository
+	addressRepo      repository.AddressRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	accountService   AccountService
+	hasher           PasswordHasher
+	mailer           mail.Mailer
+	appName          string
+	db               *gorm.DB
+}
+
+func NewProfileService(cfg *config.Config, db *gorm.DB) ProfileService {
+	return &profileService{
+		userRepo:         repository.NewUserRepository(db),
+		agentRepo:        repository.NewAgentRepository(db),
+		addressRepo:      repository.NewAddressRepository(db),
+		refreshTokenRepo: repository.NewRefreshTokenRepository(db),
+		accountService:   NewAccountService(cfg, db),
+		hasher:           NewPasswordHasher(cfg),
+		mailer:           mail.New(cfg),
+		appName:          cfg.AppName,
+		db:               db,
+	}
+}
+
+// Update changes the user's name and email and the company name. A new email
+// starts unverified and gets a verification link, the previous address is
+// told about the change.
+func (s *profileService) Update(user *models.User, request *requests.UpdateProfile) (*resources.Me, *merr.ResponseError) {
+	var previousEmail string = user.Email
+	var emailChanged bool = request.Email != nil && *request.Email != user.Email
+	var responseError *merr.ResponseError
+
+	if request.CompanyName != nil && !user.IsAgentOwner() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	if emailChanged {
+		if request.CurrentPassword == "" {
+			return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrCurrentPasswordNeeded)
+		}
+
+		if responseError = s.checkPassword(user, request.CurrentPassword); responseError != nil {
+			return nil, responseError
+		}
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var userRepo = s.userRepo.WithTransaction(tx)
+
+		if request.Name != nil {
+			if err := userRepo.UpdateName(user, *request.Name); err != nil {
+				return err
+			}
+		}
+
+		if emailChanged {
+			if err := userRepo.UpdateEmail(user, *request.Email); err != nil {
+				if errors.Is(err, gorm.ErrDuplicatedKey) {
+					responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrUserEmailAlreadyExists)
+				}
+				return err
+			}
+		}
+
+		if request.CompanyName != nil {
+			var agent *models.Agent = &models.Agent{Model: gorm.Model{ID: user.AgentId}}
+			if err := s.agentRepo.WithTransaction(tx).UpdateCompanyName(agent, *request.CompanyName); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	forgetCachedUser(user.Uuid)
+
+	if emailChanged {
+		if responseError = s.accountService.SendEmailVerification(user); responseError != nil {
+			mlog.Log("Failed to send email verification: " + responseError.Message)
+		}
+
+		dispatchEmail(s.mailer, &mail.Message{
+			To:      previousEmail,
+			Subject: fmt.Sprintf("%s - your email was changed", s.appName),
+			Body: fmt.Sprintf(
+				"Hello %s,\n\nThe email of your account was changed to %s. If it wasn't you, contact us right away.\n",
+				user.Name, user.Email,
+			),
+		})
+	}
+
+	return makeMeResource(s.db, user)
+}
+
+// ChangePassword logs out every session, as ResetPassword does.
+func (s *profileService) ChangePassword(user *models.User, request *requests.ChangePassword) *merr.ResponseError {
+	if responseError := s.checkPassword(user, request.CurrentPassword); responseError != nil {
+		return responseError
+	}
+
+	if request.Password == request.CurrentPassword {
+		return merr.NewResponseError(http.StatusUnprocessableEntity, ErrSamePassword)
+	}
+
+	passwordHash, err := s.hasher.Hash(request.Password)
+	if err != nil {
+		mlog.Log("Failed to hash password: " + err.Error())
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.userRepo.WithTransaction(tx).UpdatePassword(user, passwordHash); err != nil {
+			return err
+		}
+
+		return s.refreshTokenRepo.WithTransaction(tx).RevokeAllFromUser(user.ID)
+	})
+	if err != nil {
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	forgetCachedUser(user.Uuid)
+	forgetCachedSessionsFromUser(user.ID)
+
+	dispatchEmail(s.mailer, &mail.Message{
+		To:      user.Email,
+		Subject: fmt.Sprintf("%s - your password was changed", s.appName),
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nThe password of your account was changed. If it wasn't you, reset it right away.\n",
+			user.Name,
+		),
+	})
+
+	return nil
+}
+
+// UpdateAddress replaces the company address. The address is normalized
+// into the state/city/neighborhood/street tables by AddressRepository.Create,
+// the previous row is kept untouched.
+func (s *profileService) UpdateAddress(user *models.User, request *requests.Address) (*resources.Me, *merr.ResponseError) {
+	if !user.IsAgentOwner() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		address, err := s.addressRepo.WithTransaction(tx).Create(repository.AddressCreateParams{
+			Cep:           request.Cep,
+			Number:        request.Number,
+			Complement:    request.Complement,
+			Street:        request.Street,
+			Neighborhood:  request.Neighborhood,
+			City:          request.City,
+			State:         request.State,
+			StateInitials: request.StateInitials,
+		})
+		if err != nil {
+			return err
+		}
+
+		var agent *models.Agent = &models.Agent{Model: gorm.Model{ID: user.AgentId}}
+		return s.agentRepo.WithTransaction(tx).UpdateAddress(agent, address)
+	})
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return makeMeResource(s.db, user)
+}
+
+func (s *profileService) checkPassword(user *models.User, password string) *merr.ResponseError {
+	matches, err := s.hasher.Verify(password, user.Password)
+	if err != nil {
+		mlog.Log("Failed to verify password: " + err.Error())
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if !matches {
+		return merr.NewResponseError(http.StatusUnprocessableEntity, ErrIncorrectPassword)
+	}
+
+	return nil
+}
@@ -19,7 +19,7 @@ type PurchaseService interface {
 	Create(request *requests.CreatePurchase, offerUuid string, user *models.User) (*resources.Purchase, *merr.ResponseError)
 	ListPurchases(request *requests.ListPurchase, user *models.User) (*utils.PaginationWrapper[*resources.Purchase], *merr.ResponseError)
 	ListSold(request *requests.ListSold, user *models.User) (*utils.PaginationWrapper[*resources.Purchase], *merr.ResponseError)
-	Cancel(pruchaseUuid string, user *models.User) *merr.ResponseError
+	Cancel(pruchaseUuid string, user *models.User, client *requests.ClientInfo) *merr.ResponseError
 	FindByUuid(user *models.User, uuid string) (*resources.Purchase, *merr.ResponseError)
 }
 
@@ -30,6 +30,12 @@ type purchaseService struct {
 }
 
 func NewPurchaseService(db *gorm.DB) PurchaseService {
+	return newPurchaseService(db)
+}
+
+// newPurchaseService is used by services that create purchases themselves
+// and need to start their payment.
+func newPurchaseService(db *gorm.DB) *purchaseService {
 	return &purchaseService{
 		db:           db,
 		purchaseRepo: repository.NewPurchaseRepository(db),
@@ -46,6 +52,10 @@ func (s *purchaseService) Create(
 	var offer *models.Offer
 	var purchase *models.Purchase
 
+	if !user.CanTrade() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
 	var err error = s.db.Transaction(func(tx *gorm.DB) error {
 		var err error
 
@@ -55,27 +65,40 @@ func (s *purchaseService) Create(
 			return err
 		}
 
+		if errResponse = checkOfferVisibility(s.offerRepo.WithTransaction(tx), offer, user); errResponse != nil {
+			return errResponse.Error
+		}
+
+		if request.TakeAll {
+			request.QuantityMwh = offer.RemainingQuantityMwh
+		}
+
 		if offer.RemainingQuantityMwh < request.QuantityMwh {
 			errResponse = merr.NewResponseError(http.StatusUnprocessableEntity, ErrInsufficientOfferQuantity)
 			return err
 		}
 
-		if offer.SellerId == user.ID {
+		if !offer.IsValidLot(request.QuantityMwh) {
+			errResponse = merr.NewResponseError(http.StatusUnprocessableEntity, ErrQuantityNotValidLot)
+			return ErrQuantityNotValidLot
+		}
+
+		if offer.IsOwner(user) {
 			errResponse = merr.NewResponseError(http.StatusForbidden, ErrCannotPurchaseOwnOffer)
 			return err
 		}
 
-		if offer.IsFulfilled() || offer.IsExpired() {
-			errResponse = merr.NewResponseError(http.StatusUnprocessableEntity, ErrOfferHasEnded)
-			return err
+		if offer.IsAuction() {
+			errResponse = merr.NewResponseError(http.StatusUnprocessableEntity, ErrOfferIsAuction)
+			return ErrOfferIsAuction
 		}
 
-		offer.RemainingQuantityMwh -= request.QuantityMwh
+		if errResponse = checkOfferIsOnSale(offer); errResponse != nil {
+			return errResponse.Error
+		}
 
-		if offer.IsFresh() {
-			offer.Status = models.OfferStatusOpen
-		} else if offer.RemainingQuantityMwh == 0 {
-			offer.Status = models.OfferStatusFulfilled
+		if err = offer.Sell(request.QuantityMwh); err != nil {
+			return err
 		}
 
 		if err = s.offerRepo.WithTransaction(tx).Update(offer); err != nil {
@@ -85,7 +108,7 @@ func (s *purchaseService) Create(
 		purchase = &models.Purchase{
 			Uuid:          NewUuidV7String(),
 			OfferId:       offer.ID,
-			PricePerMwh:   offer.PricePerMwh,
+			PricePerMwh:   offer.PriceFor(request.QuantityMwh),
 			PaymentMethod: request.PaymentMethod,
 			Status:        models.PurchaseStatusWaiting,
 			BuyerId:       user.ID,
@@ -96,14 +119,11 @@ func (s *purchaseService) Create(
 			return err
 		}
 
-		if err := tx.
-			Preload("Buyer").
-			Preload("Offer", func(db *gorm.DB) *gorm.DB {
-				return db.Select("id, uuid, seller_id")
-			}).
-			Preload("Offer.Seller", func(db *gorm.DB) *gorm.DB {
-				return db.Select("id, uuid, name")
-			}).Find(purchase).Error; err != nil {
+		if err = allocatePurchaseDeliveries(tx, purchase); err != nil {
+			return err
+		}
+
+		if err := preloadPurchaseResource(tx, purchase); err != nil {
 			return ErrInternal
 		}
 
@@ -132,7 +152,11 @@ func (s *purchaseService) ListPurchases(
 	var list *utils.PaginationWrapper[*models.Purchase]
 	var err error
 
-	list, err = s.purchaseRepo.ListPurchases(uint64(user.ID), request)
+	if !user.CanViewFinancials() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	list, err = s.purchaseRepo.ListPurchases(uint64(user.AgentId), request)
 	if err != nil {
 		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
 	}
@@ -159,7 +183,11 @@ func (s *purchaseService) ListSold(
 	var list *utils.PaginationWrapper[*models.Purchase]
 	var err error
 
-	list, err = s.purchaseRepo.ListSold(uint64(user.ID), request)
+	if !user.CanViewFinancials() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	list, err = s.purchaseRepo.ListSold(uint64(user.AgentId), request)
 	if err != nil {
 		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
 	}
@@ -179,6 +207,18 @@ func (s *purchaseService) ListSold(
 	return &response, nil
 }
 
+// preloadPurchaseResource loads what makePurchaseResourceFromModel needs.
+func preloadPurchaseResource(db *gorm.DB, purchase *models.Purchase) error {
+	return db.
+		Preload("Buyer").
+		Preload("Offer", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, uuid, seller_id")
+		}).
+		Preload("Offer.Seller", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, uuid, name")
+		}).Find(purchase).Error
+}
+
 func makePurchaseResourceFromModel(purchase *models.Purchase) *resources.Purchase {
 	var createdAt time.Time = utils.TruncateDateToLocal(purchase.CreatedAt)
 
@@ -196,11 +236,15 @@ func makePurchaseResourceFromModel(purchase *models.Purchase) *resources.Purchas
 	}
 }
 
-func (s *purchaseService) Cancel(purchaseUuid string, user *models.User) *merr.ResponseError {
+func (s *purchaseService) Cancel(purchaseUuid string, user *models.User, client *requests.ClientInfo) *merr.ResponseError {
 	var purchase *models.Purchase
 	var err error
 	var responseErr *merr.ResponseError
 
+	if !user.CanTrade() {
+		return merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
 	s.db.Transaction(func(tx *gorm.DB) error {
 		purchase, err = s.purchaseRepo.WithTransaction(tx).FindByUuid(purchaseUuid)
 		if err != nil {
@@ -222,14 +266,9 @@ func (s *purchaseService) Cancel(purchaseUuid string, user *models.User) *merr.R
 			return ErrPurchaseCannotBeCancelled
 		}
 
-		purchase.Status = models.PurchaseStatusCanceled
-		if err := s.purchaseRepo.Update(purchase); err != nil {
-			responseErr = merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
-			return ErrInternal
-		}
+		var before map[string]string = map[string]string{"status": purchase.Status}
 
-		offer, err := s.offerRepo.GetById(purchase.OfferId)
-		if err != nil {
+		if err := cancelPurchase(tx, purchase); err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				responseErr = merr.NewResponseError(http.StatusNotFound, ErrOfferNotFound)
 				return ErrPurchaseNotFound
@@ -238,12 +277,15 @@ func (s *purchaseService) Cancel(purchaseUuid string, user *models.User) *merr.R
 			return ErrInternal
 		}
 
-		offer.RemainingQuantityMwh += purchase.QuantityMwh
-		if offer.IsFulfilled() {
-			offer.Status = models.OfferStatusOpen
-		}
-
-		if err := s.offerRepo.Update(offer); err != nil {
+		err := recordAuditEvent(tx, client, auditEntry{
+			Event:      models.AuditEventPurchaseCancelled,
+			Actor:      user,
+			TargetType: models.AuditTargetPurchase,
+			TargetUuid: purchase.Uuid,
+			Before:     before,
+			After:      map[string]string{"status": purchase.Status},
+		})
+		if err != nil {
 			responseErr = merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
 			return ErrInternal
 		}
@@ -258,6 +300,44 @@ func (s *purchaseService) Cancel(purchaseUuid string, user *models.User) *merr.R
 	return nil
 }
 
+// cancelPurchase marks the purchase as canceled and gives its quantity back
+// to the offer, reopening it when it was fulfilled. The offer of an accepted
+// rfq quote is not for sale, the rfq is reopened instead, and an auction
+// whose winner cancels is not run again, it expires.
+func cancelPurchase(tx *gorm.DB, purchase *models.Purchase) error {
+	var offerRepo = repository.NewOfferRepository(tx)
+
+	purchase.Status = models.PurchaseStatusCanceled
+	if err := repository.NewPurchaseRepository(tx).Update(purchase); err != nil {
+		return err
+	}
+
+	offer, err := offerRepo.GetById(purchase.OfferId)
+	if err != nil {
+		return err
+	}
+
+	quote, err := repository.NewRfqQuoteRepository(tx).FindByOfferId(offer.ID)
+	if err == nil {
+		return reopenRfq(tx, quote, offer)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if err = offer.Restock(purchase.QuantityMwh); err != nil {
+		return err
+	}
+
+	// An auction already expired or taken down stays so
+	if offer.IsAuction() && offer.CanTransitionTo(models.OfferStatusExpired) {
+		if err = offer.TransitionTo(models.OfferStatusExpired); err != nil {
+			return err
+		}
+	}
+
+	return offerRepo.Update(offer)
+}
+
 func isPurchaseCancelable(purchase *models.Purchase) bool {
 	var createdAt time.Time = utils.TruncateDateToLocal(purchase.CreatedAt)
 	var maxCancelDate = createdAt.Add(time.Hour * 2)
@@ -294,7 +374,7 @@ func (s *purchaseService) dispatchPurchasePaymentProcessor(user *models.User, pu
 		})
 
 		if err != nil {
-			s.Cancel(purchaaseUuid, user)
+			s.Cancel(purchaaseUuid, user, nil)
 			mlog.Log("Failed to proccess purchase payment: " + err.Error())
 		}
 	}(s, purchase.Uuid)
@@ -313,6 +393,10 @@ func (s *purchaseService) FindByUuid(user *models.User, uuid string) (*resources
 		return nil, merr.NewResponseError(http.StatusForbidden, ErrUserIsNotThePurchaseOwner)
 	}
 
+	if !user.CanViewFinancials() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
 	resource := makePurchaseResourceFromModel(purchase)
 
 	return resource, nil
@@ -0,0 +1,514 @@
+package services
+
+import (
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/repository"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/resources"
+	"ecoply/internal/domain/utils"
+	"errors"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RfqService interface {
+	Create(user *models.User, request *requests.CreateRfq) (*resources.Rfq, *merr.ResponseError)
+	List(request *requests.ListRfqs, user *models.User) (*utils.PaginationWrapper[*resources.Rfq], *merr.ResponseError)
+	FromUser(request *requests.ListOwnRfqs, user *models.User) (*utils.PaginationWrapper[*resources.Rfq], *merr.ResponseError)
+	GetByUuid(user *models.User, uuid string) (*resources.Rfq, *merr.ResponseError)
+	Cancel(user *models.User, uuid string) *merr.ResponseError
+	Quote(user *models.User, rfqUuid string, request *requests.CreateRfqQuote) (*resources.RfqQuote, *merr.ResponseError)
+	Quotes(user *models.User, rfqUuid string) ([]*resources.RfqQuote, *merr.ResponseError)
+	Accept(user *models.User, client *requests.ClientInfo, rfqUuid string, quoteUuid string, request *requests.AcceptRfqQuote) (*resources.Purchase, *merr.ResponseError)
+	UpdateExpiredRfqs() error
+}
+
+type rfqService struct {
+	rfqRepo         repository.RfqRepository
+	quoteRepo       repository.RfqQuoteRepository
+	offerRepo       repository.OfferRepository
+	purchaseRepo    repository.PurchaseRepository
+	submarketRepo   repository.SubmarketRepository
+	energyTypeRepo  repository.EnergyTypeRepository
+	purchaseService *purchaseService
+	db              *gorm.DB
+}
+
+func NewRfqService(db *gorm.DB) RfqService {
+	return &rfqService{
+		rfqRepo:         repository.NewRfqRepository(db),
+		quoteRepo:       repository.NewRfqQuoteRepository(db),
+		offerRepo:       repository.NewOfferRepository(db),
+		purchaseRepo:    repository.NewPurchaseRepository(db),
+		submarketRepo:   repository.NewSubmarketRepository(db),
+		energyTypeRepo:  repository.NewEnergyRepository(db),
+		purchaseService: newPurchaseService(db),
+		db:              db,
+	}
+}
+
+func (s *rfqService) Create(user *models.User, request *requests.CreateRfq) (*resources.Rfq, *merr.ResponseError) {
+	if !user.CanTrade() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	energyType, err := s.energyTypeRepo.GetByType(request.EnergyType)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidEnergyType)
+	} else if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	submarket, err := s.submarketRepo.FindByName(request.Submarket)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidSubmarket)
+	} else if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if err = validateCreatePeriodFromRequest(request.PeriodStart, request.PeriodEnd); err != nil {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, err)
+	}
+
+	parsedStartPeriod, _ := parseDate(request.PeriodStart)
+	parsedEndPeriod, _ := parseDate(request.PeriodEnd)
+
+	var rfq *models.Rfq = &models.Rfq{
+		Uuid:           NewUuidV7String(),
+		QuantityMwh:    request.QuantityMwh,
+		MaxPricePerMwh: request.MaxPricePerMwh,
+		Description:    request.Description,
+		PeriodStart:    parsedStartPeriod,
+		PeriodEnd:      parsedEndPeriod,
+		Status:         models.RfqStatusOpen,
+		EnergyTypeId:   energyType.ID,
+		SubmarketId:    submarket.ID,
+		BuyerId:        user.ID,
+	}
+
+	if err = s.rfqRepo.Create(rfq); err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	rfq.EnergyType = *energyType
+	rfq.Submarket = *submarket
+	rfq.Buyer = *user
+
+	return makeRfqResourceFromModel(rfq), nil
+}
+
+func (s *rfqService) List(request *requests.ListRfqs, user *models.User) (*utils.PaginationWrapper[*resources.Rfq], *merr.ResponseError) {
+	list, err := s.rfqRepo.ListOpen(request, user)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return makeRfqPage(list), nil
+}
+
+func (s *rfqService) FromUser(request *requests.ListOwnRfqs, user *models.User) (*utils.PaginationWrapper[*resources.Rfq], *merr.ResponseError) {
+	list, err := s.rfqRepo.ListFromAgent(user.AgentId, request)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return makeRfqPage(list), nil
+}
+
+// GetByUuid shows open rfqs to anyone, the others only to the buyer's
+// company and to the suppliers that quoted them.
+func (s *rfqService) GetByUuid(user *models.User, uuid string) (*resources.Rfq, *merr.ResponseError) {
+	rfq, err := s.rfqRepo.FindByUuid(uuid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, merr.NewResponseError(http.StatusNotFound, ErrRfqNotFound)
+		}
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if !rfq.IsOpen() && !rfq.IsOwner(user) {
+		quotes, err := s.quoteRepo.ListFromRfqAndAgent(rfq.ID, user.AgentId)
+		if err != nil {
+			return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+		}
+
+		if len(quotes) == 0 {
+			return nil, merr.NewResponseError(http.StatusNotFound, ErrRfqNotFound)
+		}
+	}
+
+	return makeRfqResourceFromModel(rfq), nil
+}
+
+// Cancel closes the rfq, its pending quotes are rejected.
+func (s *rfqService) Cancel(user *models.User, uuid string) *merr.ResponseError {
+	var responseError *merr.ResponseError
+
+	if !user.CanTrade() {
+		return merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		rfq, responseErr := s.findOpenRfqForUpdate(tx, user, uuid)
+		if responseErr != nil {
+			responseError = responseErr
+			return responseErr.Error
+		}
+
+		if err := s.quoteRepo.WithTransaction(tx).RejectPendingFromRfq(rfq.ID); err != nil {
+			return err
+		}
+
+		rfq.Status = models.RfqStatusCancelled
+		return s.rfqRepo.WithTransaction(tx).Update(rfq)
+	})
+
+	if responseError != nil {
+		return responseError
+	}
+
+	if err != nil {
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return nil
+}
+
+// Quote submits a supplier's price for the whole quantity of the rfq. A
+// company may only have one pending quote per rfq.
+func (s *rfqService) Quote(user *models.User, rfqUuid string, request *requests.CreateRfqQuote) (*resources.RfqQuote, *merr.ResponseError) {
+	var responseError *merr.ResponseError
+	var quote *models.RfqQuote
+
+	if !user.CanTrade() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	if !user.IsEmailVerified() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrEmailNotVerified)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var quoteRepo = s.quoteRepo.WithTransaction(tx)
+
+		rfq, err := s.rfqRepo.WithTransaction(tx).FindByUuidForUpdate(rfqUuid)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				responseError = merr.NewResponseError(http.StatusNotFound, ErrRfqNotFound)
+			}
+			return err
+		}
+
+		if rfq.IsOwner(user) {
+			responseError = merr.NewResponseError(http.StatusForbidden, ErrCannotQuoteOwnRfq)
+			return ErrCannotQuoteOwnRfq
+		}
+
+		if !rfq.IsOpen() || rfq.IsExpired() {
+			responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrRfqIsNotOpen)
+			return ErrRfqIsNotOpen
+		}
+
+		if request.PricePerMwh > rfq.MaxPricePerMwh {
+			responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrQuoteAboveMaxPrice)
+			return ErrQuoteAboveMaxPrice
+		}
+
+		pending, err := quoteRepo.HasPendingFromAgent(rfq.ID, user.AgentId)
+		if err != nil {
+			return err
+		}
+
+		if pending {
+			responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrQuoteAlreadySubmitted)
+			return ErrQuoteAlreadySubmitted
+		}
+
+		quote = &models.RfqQuote{
+			Uuid:        NewUuidV7String(),
+			PricePerMwh: request.PricePerMwh,
+			Message:     request.Message,
+			Status:      models.RfqQuoteStatusPending,
+			RfqId:       rfq.ID,
+			SupplierId:  user.ID,
+		}
+
+		return quoteRepo.Create(quote)
+	})
+
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	quote.Supplier = *user
+
+	return makeRfqQuoteResourceFromModel(quote), nil
+}
+
+// Quotes lists every quote to the buyer's company, cheapest first, and only
+// their own quotes to suppliers.
+func (s *rfqService) Quotes(user *models.User, rfqUuid string) ([]*resources.RfqQuote, *merr.ResponseError) {
+	rfq, err := s.rfqRepo.FindByUuid(rfqUuid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, merr.NewResponseError(http.StatusNotFound, ErrRfqNotFound)
+		}
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	var quotes []*models.RfqQuote
+
+	if rfq.IsOwner(user) {
+		quotes, err = s.quoteRepo.ListFromRfq(rfq.ID)
+	} else {
+		quotes, err = s.quoteRepo.ListFromRfqAndAgent(rfq.ID, user.AgentId)
+	}
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	response := make([]*resources.RfqQuote, 0, len(quotes))
+	for _, quote := range quotes {
+		response = append(response, makeRfqQuoteResourceFromModel(quote))
+	}
+
+	return response, nil
+}
+
+// Accept awards the rfq to the quote. In a single transaction it creates
+// the supplier's offer, already fulfilled, the buyer's purchase at the quoted
+// price and rejects the other quotes.
+func (s *rfqService) Accept(
+	user *models.User,
+	client *requests.ClientInfo,
+	rfqUuid string,
+	quoteUuid string,
+	request *requests.AcceptRfqQuote,
+) (*resources.Purchase, *merr.ResponseError) {
+	var responseError *merr.ResponseError
+	var purchase *models.Purchase
+
+	if !user.CanTrade() {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrInsufficientRole)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var quoteRepo = s.quoteRepo.WithTransaction(tx)
+
+		rfq, responseErr := s.findOpenRfqForUpdate(tx, user, rfqUuid)
+		if responseErr != nil {
+			responseError = responseErr
+			return responseErr.Error
+		}
+
+		quote, err := quoteRepo.FindByUuid(rfq.ID, quoteUuid)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				responseError = merr.NewResponseError(http.StatusNotFound, ErrRfqQuoteNotFound)
+			}
+			return err
+		}
+
+		if !quote.IsPending() || quote.Supplier.IsSuspended() || quote.Supplier.IsAnonymized() {
+			responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrRfqQuoteIsNotPending)
+			return ErrRfqQuoteIsNotPending
+		}
+
+		offer, err := s.offerRepo.WithTransaction(tx).Create(&models.Offer{
+			Uuid:                 NewUuidV7String(),
+			PricePerMwh:          quote.PricePerMwh,
+			InitialQuantityMwh:   rfq.QuantityMwh,
+			RemainingQuantityMwh: 0,
+			Description:          rfq.Description,
+			PeriodStart:          rfq.PeriodStart,
+			PeriodEnd:            rfq.PeriodEnd,
+			Status:               models.OfferStatusFulfilled,
+			EnergyTypeId:         rfq.EnergyTypeId,
+			SubmarketId:          rfq.SubmarketId,
+			SellerId:             quote.SupplierId,
+		})
+		if err != nil {
+			return err
+		}
+
+		purchase = &models.Purchase{
+			Uuid:          NewUuidV7String(),
+			OfferId:       offer.ID,
+			PricePerMwh:   quote.PricePerMwh,
+			PaymentMethod: request.PaymentMethod,
+			Status:        models.PurchaseStatusWaiting,
+			BuyerId:       user.ID,
+			QuantityMwh:   rfq.QuantityMwh,
+		}
+
+		if err = s.purchaseRepo.WithTransaction(tx).Create(purchase); err != nil {
+			return err
+		}
+
+		if err = allocatePurchaseDeliveries(tx, purchase); err != nil {
+			return err
+		}
+
+		if err = quoteRepo.RejectPendingFromRfq(rfq.ID); err != nil {
+			return err
+		}
+
+		quote.Status = models.RfqQuoteStatusAccepted
+		quote.OfferId = &offer.ID
+		if err = quoteRepo.Update(quote); err != nil {
+			return err
+		}
+
+		rfq.Status = models.RfqStatusAwarded
+		rfq.PurchaseId = &purchase.ID
+		if err = s.rfqRepo.WithTransaction(tx).Update(rfq); err != nil {
+			return err
+		}
+
+		err = recordAuditEvent(tx, client, auditEntry{
+			Event:      models.AuditEventRfqQuoteAccepted,
+			Actor:      user,
+			TargetType: models.AuditTargetRfq,
+			TargetUuid: rfq.Uuid,
+			After: map[string]any{
+				"quote_uuid":    quote.Uuid,
+				"purchase_uuid": purchase.Uuid,
+				"price_per_mwh": quote.PricePerMwh,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		return preloadPurchaseResource(tx, purchase)
+	})
+
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	s.purchaseService.dispatchPurchasePaymentProcessor(user, purchase)
+
+	return makePurchaseResourceFromModel(purchase), nil
+}
+
+func (s *rfqService) UpdateExpiredRfqs() error {
+	return s.rfqRepo.UpdateExpiredRfqs()
+}
+
+func (s *rfqService) findOpenRfqForUpdate(tx *gorm.DB, user *models.User, uuid string) (*models.Rfq, *merr.ResponseError) {
+	rfq, err := s.rfqRepo.WithTransaction(tx).FindByUuidForUpdate(uuid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, merr.NewResponseError(http.StatusNotFound, ErrRfqNotFound)
+		}
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if !rfq.IsOwner(user) {
+		return nil, merr.NewResponseError(http.StatusForbidden, ErrUserIsNotTheRfqOwner)
+	}
+
+	if !rfq.IsOpen() || rfq.IsExpired() {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrRfqIsNotOpen)
+	}
+
+	return rfq, nil
+}
+
+// reopenRfq undoes the award of a quote whose purchase was cancelled. Its
+// offer is expired rather than reopened, the quote was only meant for this
+// buyer.
+func reopenRfq(tx *gorm.DB, quote *models.RfqQuote, offer *models.Offer) error {
+	var rfqRepo = repository.NewRfqRepository(tx)
+
+	// An offer already expired or taken down stays so
+	if offer.CanTransitionTo(models.OfferStatusExpired) {
+		if err := offer.TransitionTo(models.OfferStatusExpired); err != nil {
+			return err
+		}
+	}
+
+	if err := repository.NewOfferRepository(tx).Update(offer); err != nil {
+		return err
+	}
+
+	quote.Status = models.RfqQuoteStatusCancelled
+	if err := repository.NewRfqQuoteRepository(tx).Update(quote); err != nil {
+		return err
+	}
+
+	rfq, err := rfqRepo.FindById(quote.RfqId)
+	if err != nil {
+		return err
+	}
+
+	rfq.Status = models.RfqStatusOpen
+	if rfq.IsExpired() {
+		rfq.Status = models.RfqStatusExpired
+	}
+	rfq.PurchaseId = nil
+
+	return rfqRepo.Update(rfq)
+}
+
+func makeRfqPage(list *utils.PaginationWrapper[*models.Rfq]) *utils.PaginationWrapper[*resources.Rfq] {
+	var response utils.PaginationWrapper[*resources.Rfq]
+
+	response.Page = list.Page
+	response.PageSize = list.PageSize
+	response.HasNext = list.HasNext
+	response.HasPrev = list.HasPrev
+	response.Data = make([]*resources.Rfq, 0, len(list.Data))
+
+	for _, rfq := range list.Data {
+		response.Data = append(response.Data, makeRfqResourceFromModel(rfq))
+	}
+
+	return &response
+}
+
+func makeRfqResourceFromModel(rfq *models.Rfq) *resources.Rfq {
+	var resource *resources.Rfq = &resources.Rfq{
+		Uuid:           rfq.Uuid,
+		QuantityMwh:    rfq.QuantityMwh,
+		MaxPricePerMwh: rfq.MaxPricePerMwh,
+		Description:    rfq.Description,
+		PeriodStart:    rfq.PeriodStart.Format(time.DateOnly),
+		PeriodEnd:      rfq.PeriodEnd.Format(time.DateOnly),
+		Status:         rfq.Status,
+		EnergyType:     rfq.EnergyType.Type,
+		Submarket:      rfq.Submarket.Name,
+		BuyerUuid:      rfq.Buyer.Uuid,
+		CreatedAt:      utils.TruncateDateToLocal(rfq.CreatedAt).Format(time.RFC3339),
+	}
+
+	if rfq.Purchase != nil {
+		resource.PurchaseUuid = &rfq.Purchase.Uuid
+	}
+
+	return resource
+}
+
+func makeRfqQuoteResourceFromModel(quote *models.RfqQuote) *resources.RfqQuote {
+	return &resources.RfqQuote{
+		Uuid:         quote.Uuid,
+		PricePerMwh:  quote.PricePerMwh,
+		Message:      quote.Message,
+		Status:       quote.Status,
+		SupplierUuid: quote.Supplier.Uuid,
+		SupplierName: quote.Supplier.Name,
+		CreatedAt:    utils.TruncateDateToLocal(quote.CreatedAt).Format(time.RFC3339),
+	}
+}
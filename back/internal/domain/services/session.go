@@ -0,0 +1,148 @@
+package services
+
+import (
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/repository"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/resources"
+	"ecoply/internal/domain/utils"
+	"errors"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Last seen is saved at most once per interval to avoid a write per request
+const sessionLastSeenInterval = time.Minute
+
+// SessionService lists the devices a user is logged in from and lets them
+// be cut off. Sessions are started and extended by the AuthService when it
+// issues tokens.
+type SessionService interface {
+	List(user *models.User, currentSessionId string) ([]*resources.Session, *merr.ResponseError)
+	Revoke(user *models.User, client *requests.ClientInfo, sessionUuid string) *merr.ResponseError
+	Authenticate(user *models.User, client *requests.ClientInfo, sessionId string) *merr.ResponseError
+}
+
+type sessionService struct {
+	sessionRepo      repository.SessionRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	db               *gorm.DB
+}
+
+func NewSessionService(db *gorm.DB) SessionService {
+	return &sessionService{
+		sessionRepo:      repository.NewSessionRepository(db),
+		refreshTokenRepo: repository.NewRefreshTokenRepository(db),
+		db:               db,
+	}
+}
+
+func (s *sessionService) List(user *models.User, currentSessionId string) ([]*resources.Session, *merr.ResponseError) {
+	sessions, err := s.sessionRepo.ListActiveFromUser(user.ID)
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	response := make([]*resources.Session, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, &resources.Session{
+			Uuid:       session.Uuid,
+			Ip:         session.Ip,
+			UserAgent:  session.UserAgent,
+			Current:    session.Uuid == currentSessionId,
+			LastSeenAt: utils.TruncateDateToLocal(session.LastSeenAt).Format(time.RFC3339),
+			CreatedAt:  utils.TruncateDateToLocal(session.CreatedAt).Format(time.RFC3339),
+		})
+	}
+
+	return response, nil
+}
+
+// Revoke logs the device out: its refresh tokens can no longer be used and
+// its access tokens are refused by JwtAuthMiddleware from now on.
+func (s *sessionService) Revoke(user *models.User, client *requests.ClientInfo, sessionUuid string) *merr.ResponseError {
+	session, err := s.findFromUser(user, sessionUuid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return merr.NewResponseError(http.StatusNotFound, ErrSessionNotFound)
+		}
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if session.RevokedAt != nil {
+		return nil
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.refreshTokenRepo.WithTransaction(tx).RevokeFamily(session.Uuid); err != nil {
+			return err
+		}
+
+		return recordAuditEvent(tx, client, auditEntry{
+			Event:      models.AuditEventSessionRevoked,
+			Actor:      user,
+			TargetType: models.AuditTargetSession,
+			TargetUuid: session.Uuid,
+		})
+	})
+	if err != nil {
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	forgetCachedSession(session.Uuid)
+	return nil
+}
+
+// Authenticate checks that the session of an access token is still active
+// and records the activity of the device. The session is read from
+// cachedSessions until its activity is due to be recorded again.
+func (s *sessionService) Authenticate(user *models.User, client *requests.ClientInfo, sessionId string) *merr.ResponseError {
+	var now time.Time = utils.NowInLocal()
+
+	if cached, ok := cachedSessions.get(sessionId); ok && cached.UserId == user.ID && cached.IsActive() &&
+		now.Sub(cached.LastSeenAt) < sessionLastSeenInterval {
+		return nil
+	}
+
+	session, err := s.findFromUser(user, sessionId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return merr.NewResponseError(http.StatusUnauthorized, ErrSessionRevoked)
+		}
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if !session.IsActive() {
+		return merr.NewResponseError(http.StatusUnauthorized, ErrSessionRevoked)
+	}
+
+	if now.Sub(session.LastSeenAt) >= sessionLastSeenInterval {
+		session.LastSeenAt = now
+		if client != nil {
+			session.Ip = client.Ip
+			session.UserAgent = client.UserAgent
+		}
+
+		// Failing to record the activity must not block the request
+		_ = s.sessionRepo.Touch(session)
+	}
+
+	cachedSessions.put(session.Uuid, session)
+	return nil
+}
+
+func (s *sessionService) findFromUser(user *models.User, sessionUuid string) (*models.Session, error) {
+	session, err := s.sessionRepo.FindByUuid(sessionUuid)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.UserId != user.ID {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return session, nil
+}
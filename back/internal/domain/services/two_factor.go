@@ -0,0 +1,371 @@
+package services
+
+import (
+	"crypto/rand"
+	"ecoply/internal/config"
+	"ecoply/internal/domain/merr"
+	"ecoply/internal/domain/models"
+	"ecoply/internal/domain/repository"
+	"ecoply/internal/domain/requests"
+	"ecoply/internal/domain/resources"
+	"ecoply/internal/domain/utils"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	recoveryCodesCount    = 10
+	recoveryCodeAlphabet  = "abcdefghjkmnpqrstuvwxyz23456789"
+	recoveryCodeGroupSize = 5
+)
+
+type TwoFactorService interface {
+	Status(user *models.User) (*resources.TwoFactorStatus, *merr.ResponseError)
+	BeginEnrollment(user *models.User) (*resources.TwoFactorEnrollment, *merr.ResponseError)
+	ConfirmEnrollment(user *models.User, request *requests.TwoFactorCode) (*resources.TwoFactorRecoveryCodes, *merr.ResponseError)
+	Disable(user *models.User, request *requests.TwoFactorCode) *merr.ResponseError
+	RegenerateRecoveryCodes(user *models.User, request *requests.TwoFactorCode) (*resources.TwoFactorRecoveryCodes, *merr.ResponseError)
+
+	IsEnabled(user *models.User) (bool, *merr.ResponseError)
+	IsRequired(user *models.User) bool
+	Verify(user *models.User, code string) (bool, *merr.ResponseError)
+}
+
+type twoFactorService struct {
+	twoFactorRepo repository.TwoFactorRepository
+	userTypeRepo  repository.UserTypeRepository
+	requiredFor   []string
+	issuer        string
+	db            *gorm.DB
+}
+
+func NewTwoFactorService(cfg *config.Config, db *gorm.DB) TwoFactorService {
+	return &twoFactorService{
+		twoFactorRepo: repository.NewTwoFactorRepository(db),
+		userTypeRepo:  repository.NewUserTypeRepository(db),
+		requiredFor:   cfg.TwoFactorRequiredFor,
+		issuer:        cfg.AppName,
+		db:            db,
+	}
+}
+
+func (s *twoFactorService) Status(user *models.User) (*resources.TwoFactorStatus, *merr.ResponseError) {
+	var status resources.TwoFactorStatus = resources.TwoFactorStatus{
+		Required: s.IsRequired(user),
+	}
+
+	twoFactor, err := s.twoFactorRepo.FindByUserId(user.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &status, nil
+		}
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if !twoFactor.IsEnabled() {
+		return &status, nil
+	}
+
+	var remaining int64
+	if err = s.db.Model(&models.TwoFactorRecoveryCode{}).
+		Where("two_factor_id = ? AND used_at IS NULL", twoFactor.ID).
+		Count(&remaining).Error; err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	status.Enabled = true
+	status.RecoveryCodesRemaining = int(remaining)
+
+	return &status, nil
+}
+
+// BeginEnrollment creates a new secret, replacing any unconfirmed one. The
+// account stays unprotected until ConfirmEnrollment receives a valid code.
+func (s *twoFactorService) BeginEnrollment(user *models.User) (*resources.TwoFactorEnrollment, *merr.ResponseError) {
+	var responseError *merr.ResponseError
+	var secret string
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var twoFactorRepo = s.twoFactorRepo.WithTransaction(tx)
+		var err error
+
+		twoFactor, err := twoFactorRepo.FindByUserIdForUpdate(user.ID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if twoFactor == nil {
+			twoFactor = &models.TwoFactor{UserId: user.ID}
+		}
+
+		if twoFactor.IsEnabled() {
+			responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrTwoFactorAlreadyEnabled)
+			return ErrTwoFactorAlreadyEnabled
+		}
+
+		if secret, err = NewTotpSecret(); err != nil {
+			return err
+		}
+
+		twoFactor.Secret = secret
+		twoFactor.LastUsedStep = 0
+
+		return twoFactorRepo.Save(twoFactor)
+	})
+
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return &resources.TwoFactorEnrollment{
+		Secret:     secret,
+		OtpauthUri: TotpUri(s.issuer, user.Email, secret),
+	}, nil
+}
+
+func (s *twoFactorService) ConfirmEnrollment(user *models.User, request *requests.TwoFactorCode) (*resources.TwoFactorRecoveryCodes, *merr.ResponseError) {
+	var responseError *merr.ResponseError
+	var codes []string
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var twoFactorRepo = s.twoFactorRepo.WithTransaction(tx)
+
+		twoFactor, err := twoFactorRepo.FindByUserIdForUpdate(user.ID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrTwoFactorNotEnrolling)
+			}
+			return err
+		}
+
+		if twoFactor.IsEnabled() {
+			responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrTwoFactorAlreadyEnabled)
+			return ErrTwoFactorAlreadyEnabled
+		}
+
+		step, ok := ValidateTotp(twoFactor.Secret, request.Code, utils.NowInLocal(), twoFactor.LastUsedStep)
+		if !ok {
+			responseError = merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidTwoFactorCode)
+			return ErrInvalidTwoFactorCode
+		}
+
+		var now = utils.NowInLocal()
+		twoFactor.EnabledAt = &now
+		twoFactor.LastUsedStep = step
+
+		if err = twoFactorRepo.Save(twoFactor); err != nil {
+			return err
+		}
+
+		codes, err = s.replaceRecoveryCodes(twoFactorRepo, twoFactor)
+		return err
+	})
+
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return &resources.TwoFactorRecoveryCodes{RecoveryCodes: codes}, nil
+}
+
+func (s *twoFactorService) Disable(user *models.User, request *requests.TwoFactorCode) *merr.ResponseError {
+	if s.IsRequired(user) {
+		return merr.NewResponseError(http.StatusForbidden, ErrTwoFactorMandatory)
+	}
+
+	var responseError *merr.ResponseError
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var twoFactorRepo = s.twoFactorRepo.WithTransaction(tx)
+
+		twoFactor, responseErr := s.verifyLocked(twoFactorRepo, user, request.Code)
+		if responseErr != nil {
+			responseError = responseErr
+			return responseErr.Error
+		}
+
+		return twoFactorRepo.Delete(twoFactor)
+	})
+
+	if responseError != nil {
+		return responseError
+	}
+
+	if err != nil {
+		return merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return nil
+}
+
+func (s *twoFactorService) RegenerateRecoveryCodes(user *models.User, request *requests.TwoFactorCode) (*resources.TwoFactorRecoveryCodes, *merr.ResponseError) {
+	var responseError *merr.ResponseError
+	var codes []string
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var twoFactorRepo = s.twoFactorRepo.WithTransaction(tx)
+		var err error
+
+		twoFactor, responseErr := s.verifyLocked(twoFactorRepo, user, request.Code)
+		if responseErr != nil {
+			responseError = responseErr
+			return responseErr.Error
+		}
+
+		codes, err = s.replaceRecoveryCodes(twoFactorRepo, twoFactor)
+		return err
+	})
+
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return &resources.TwoFactorRecoveryCodes{RecoveryCodes: codes}, nil
+}
+
+func (s *twoFactorService) IsEnabled(user *models.User) (bool, *merr.ResponseError) {
+	twoFactor, err := s.twoFactorRepo.FindByUserId(user.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	return twoFactor.IsEnabled(), nil
+}
+
+func (s *twoFactorService) IsRequired(user *models.User) bool {
+	if len(s.requiredFor) == 0 {
+		return false
+	}
+
+	userType, err := s.userTypeRepo.FindById(user.UserTypeId)
+	if err != nil {
+		return false
+	}
+
+	return slices.Contains(s.requiredFor, userType.Type)
+}
+
+// Verify accepts either a TOTP code or an unused recovery code, consuming it.
+func (s *twoFactorService) Verify(user *models.User, code string) (bool, *merr.ResponseError) {
+	var responseError *merr.ResponseError
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		_, responseErr := s.verifyLocked(s.twoFactorRepo.WithTransaction(tx), user, code)
+		if responseErr != nil {
+			responseError = responseErr
+		}
+		return nil
+	})
+
+	if err != nil {
+		return false, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if responseError != nil {
+		if errors.Is(responseError.Error, ErrInvalidTwoFactorCode) {
+			return false, nil
+		}
+		return false, responseError
+	}
+
+	return true, nil
+}
+
+// verifyLocked must run inside a transaction, the row lock serializes
+// concurrent attempts so a TOTP step or recovery code is only used once.
+func (s *twoFactorService) verifyLocked(twoFactorRepo repository.TwoFactorRepository, user *models.User, code string) (*models.TwoFactor, *merr.ResponseError) {
+	twoFactor, err := twoFactorRepo.FindByUserIdForUpdate(user.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrTwoFactorNotEnabled)
+		}
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if !twoFactor.IsEnabled() {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrTwoFactorNotEnabled)
+	}
+
+	code = strings.TrimSpace(code)
+
+	if step, ok := ValidateTotp(twoFactor.Secret, code, utils.NowInLocal(), twoFactor.LastUsedStep); ok {
+		twoFactor.LastUsedStep = step
+		if err = twoFactorRepo.Save(twoFactor); err != nil {
+			return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+		}
+		return twoFactor, nil
+	}
+
+	used, err := twoFactorRepo.UseRecoveryCode(twoFactor, Hash256String(normalizeRecoveryCode(code)))
+	if err != nil {
+		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
+	}
+
+	if !used {
+		return nil, merr.NewResponseError(http.StatusUnprocessableEntity, ErrInvalidTwoFactorCode)
+	}
+
+	return twoFactor, nil
+}
+
+func (s *twoFactorService) replaceRecoveryCodes(twoFactorRepo repository.TwoFactorRepository, twoFactor *models.TwoFactor) ([]string, error) {
+	var codes []string = make([]string, 0, recoveryCodesCount)
+	var hashes []string = make([]string, 0, recoveryCodesCount)
+
+	for range recoveryCodesCount {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, Hash256String(normalizeRecoveryCode(code)))
+	}
+
+	if err := twoFactorRepo.ReplaceRecoveryCodes(twoFactor, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// newRecoveryCode returns a code like "k7m2p-x9qhr", avoiding characters that
+// are easy to mistake for each other.
+func newRecoveryCode() (string, error) {
+	var random []byte = make([]byte, recoveryCodeGroupSize*2)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	for i, b := range random {
+		if i == recoveryCodeGroupSize {
+			builder.WriteByte('-')
+		}
+		builder.WriteByte(recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)])
+	}
+
+	return builder.String(), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(code, "-", ""))
+}
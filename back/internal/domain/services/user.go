@@ -25,7 +25,13 @@ func NewUserService(db *gorm.DB) UserService {
 	}
 }
 
+// FindByUuid is served from cachedUsers when it can, it is called on every
+// authenticated request.
 func (s *userService) FindByUuid(uuid string) (*models.User, *merr.ResponseError) {
+	if user, ok := cachedUsers.get(uuid); ok {
+		return user, nil
+	}
+
 	user, err := s.userRepo.FindByUuid(uuid)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -33,5 +39,7 @@ func (s *userService) FindByUuid(uuid string) (*models.User, *merr.ResponseError
 		}
 		return nil, merr.NewResponseError(http.StatusInternalServerError, ErrInternal)
 	}
+
+	cachedUsers.put(user.Uuid, user)
 	return user, nil
 }
@@ -9,6 +9,7 @@ import (
 
 type UserTypeService interface {
 	UserIsSupplier(user *models.User) bool
+	UserIsAdmin(user *models.User) bool
 }
 
 type userTypeService struct {
@@ -29,3 +30,12 @@ func (s *userTypeService) UserIsSupplier(user *models.User) bool {
 
 	return userType.Type == models.UserTypeSupplier
 }
+
+func (s *userTypeService) UserIsAdmin(user *models.User) bool {
+	userType, err := s.userTypeRepo.FindById(user.UserTypeId)
+	if err != nil {
+		return false
+	}
+
+	return userType.Type == models.UserTypeAdmin
+}
@@ -10,6 +10,60 @@ import (
 
 func RunBackgroundTasks(s *server.ServerContext) {
 	updateOfferStatusToExpired(s.Services.OfferService)
+	publishScheduledOffers(s.Services.OfferService)
+	closeEndedAuctions(s.Services.AuctionService)
+	updateNegotiationStatusToExpired(s.Services.NegotiationService)
+	updateRfqStatusToExpired(s.Services.RfqService)
+	updateBidStatusToExpired(s.Services.OrderBookService)
+	publishDueTemplateOffers(s.Services.OfferTemplateService)
+}
+
+func publishDueTemplateOffers(service services.OfferTemplateService) {
+	var ctx context.Context = context.Background()
+
+	background.StartPeriodicTask(ctx, time.Duration(time.Second*30), func() error {
+		return service.PublishDueOffers()
+	})
+}
+
+func updateRfqStatusToExpired(service services.RfqService) {
+	var ctx context.Context = context.Background()
+
+	background.StartPeriodicTask(ctx, time.Duration(time.Second*30), func() error {
+		return service.UpdateExpiredRfqs()
+	})
+}
+
+func updateBidStatusToExpired(service services.OrderBookService) {
+	var ctx context.Context = context.Background()
+
+	background.StartPeriodicTask(ctx, time.Duration(time.Second*30), func() error {
+		return service.UpdateExpiredBids()
+	})
+}
+
+func updateNegotiationStatusToExpired(service services.NegotiationService) {
+	var ctx context.Context = context.Background()
+
+	background.StartPeriodicTask(ctx, time.Duration(time.Second*30), func() error {
+		return service.UpdateExpiredNegotiations()
+	})
+}
+
+func closeEndedAuctions(service services.AuctionService) {
+	var ctx context.Context = context.Background()
+
+	background.StartPeriodicTask(ctx, time.Duration(time.Second*30), func() error {
+		return service.CloseEndedAuctions()
+	})
+}
+
+func publishScheduledOffers(service services.OfferService) {
+	var ctx context.Context = context.Background()
+
+	background.StartPeriodicTask(ctx, time.Duration(time.Second*30), func() error {
+		return service.PublishScheduledOffers()
+	})
 }
 
 func updateOfferStatusToExpired(service services.OfferService) {
@@ -0,0 +1,14 @@
+package mail
+
+import "errors"
+
+var (
+	ErrUnknownDriver     = makeError("unknown mail driver")
+	ErrFailedToSendEmail = makeError("failed to send email")
+)
+
+func makeError(message string) error {
+	const prefix string = "Mail -> "
+
+	return errors.New(prefix + message)
+}
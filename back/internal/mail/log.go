@@ -0,0 +1,41 @@
+package mail
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// logMailer appends every message to a file instead of delivering it, so
+// links sent by email can be followed during local development.
+type logMailer struct {
+	from string
+	path string
+	mu   sync.Mutex
+}
+
+func NewLogMailer(from string, path string) Mailer {
+	return &logMailer{from: from, path: path}
+}
+
+func (m *logMailer) Send(message *Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(m.path), 0766); err != nil {
+		return fmt.Errorf("%w: %v", ErrFailedToSendEmail, err)
+	}
+
+	file, err := os.OpenFile(m.path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrFailedToSendEmail, err)
+	}
+	defer file.Close()
+
+	if _, err = fmt.Fprintf(file, "%s\r\n----------\r\n", render(m.from, message)); err != nil {
+		return fmt.Errorf("%w: %v", ErrFailedToSendEmail, err)
+	}
+
+	return nil
+}
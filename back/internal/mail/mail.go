@@ -0,0 +1,63 @@
+package mail
+
+import (
+	"ecoply/internal/config"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	DriverSmtp = "smtp"
+	DriverLog  = "log"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(message *Message) error
+}
+
+func New(cfg *config.Config) Mailer {
+	switch cfg.MailDriver {
+	case DriverSmtp:
+		return NewSmtpMailer(SmtpConfig{
+			Host:     cfg.SmtpHost,
+			Port:     cfg.SmtpPort,
+			Username: cfg.SmtpUsername,
+			Password: cfg.SmtpPassword,
+			From:     cfg.MailFrom,
+		})
+	case DriverLog:
+		return NewLogMailer(cfg.MailFrom, "log/mail.log")
+	default:
+		log.Fatalf("%v: %s\n", ErrUnknownDriver, cfg.MailDriver)
+		return nil
+	}
+}
+
+func render(from string, message *Message) []byte {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "From: %s\r\n", headerValue(from))
+	fmt.Fprintf(&builder, "To: %s\r\n", headerValue(message.To))
+	fmt.Fprintf(&builder, "Subject: %s\r\n", headerValue(message.Subject))
+	fmt.Fprintf(&builder, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	builder.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	builder.WriteString("\r\n")
+	builder.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+
+	return []byte(builder.String())
+}
+
+// headerValue drops line breaks, values such as subjects carry user input
+// that could otherwise add headers of its own.
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
@@ -0,0 +1,45 @@
+package mail
+
+import (
+	"fmt"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"strconv"
+)
+
+type SmtpConfig struct {
+	Host     string
+	Port     uint16
+	Username string
+	Password string
+	From     string
+}
+
+type smtpMailer struct {
+	cfg SmtpConfig
+}
+
+func NewSmtpMailer(cfg SmtpConfig) Mailer {
+	return &smtpMailer{cfg: cfg}
+}
+
+func (m *smtpMailer) Send(message *Message) error {
+	var address string = net.JoinHostPort(m.cfg.Host, strconv.FormatUint(uint64(m.cfg.Port), 10))
+	var auth smtp.Auth
+
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+
+	from, err := netmail.ParseAddress(m.cfg.From)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrFailedToSendEmail, err)
+	}
+
+	if err = smtp.SendMail(address, auth, from.Address, []string{message.To}, render(m.cfg.From, message)); err != nil {
+		return fmt.Errorf("%w: %v", ErrFailedToSendEmail, err)
+	}
+
+	return nil
+}
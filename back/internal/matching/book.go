@@ -0,0 +1,170 @@
+// Package matching is a limit order book with price-time priority. It knows
+// nothing about the database: callers load the resting orders, submit the
+// incoming one and persist the fills, which makes a run deterministic for a
+// given set of orders.
+package matching
+
+import (
+	"math"
+	"sort"
+)
+
+type Side int
+
+const (
+	Bid Side = iota
+	Ask
+)
+
+// Prices are in cents per MWh and quantities in thousandths of a MWh, the
+// precision of the database columns, so fills are exact.
+type Order struct {
+	Id    string
+	Side  Side
+	Owner uint // Orders of the same owner never match each other
+
+	Price    int64
+	Quantity int64
+
+	// Time priority, lower comes first
+	Sequence uint64
+}
+
+type Fill struct {
+	BidId    string
+	AskId    string
+	Price    int64
+	Quantity int64
+}
+
+type Book struct {
+	bids []*Order
+	asks []*Order
+}
+
+func NewBook() *Book {
+	return &Book{}
+}
+
+// Add rests the order on the book without matching it.
+func (b *Book) Add(order *Order) {
+	if order.Quantity <= 0 {
+		return
+	}
+
+	if order.Side == Bid {
+		b.bids = insertOrder(b.bids, order, bidBefore)
+	} else {
+		b.asks = insertOrder(b.asks, order, askBefore)
+	}
+}
+
+// Submit matches the incoming order against the other side of the book,
+// best price first and, within a price, oldest first. Trades happen at the
+// price of the resting order. What is left of the incoming order rests on
+// the book.
+func (b *Book) Submit(order *Order) []Fill {
+	var fills []Fill
+	var resting *[]*Order = &b.asks
+	if order.Side == Ask {
+		resting = &b.bids
+	}
+
+	var remaining []*Order = make([]*Order, 0, len(*resting))
+
+	for _, other := range *resting {
+		if order.Quantity == 0 || !crosses(order, other) || order.Owner == other.Owner {
+			remaining = append(remaining, other)
+			continue
+		}
+
+		var quantity int64 = min(order.Quantity, other.Quantity)
+
+		var fill Fill = Fill{Price: other.Price, Quantity: quantity}
+		if order.Side == Bid {
+			fill.BidId, fill.AskId = order.Id, other.Id
+		} else {
+			fill.BidId, fill.AskId = other.Id, order.Id
+		}
+		fills = append(fills, fill)
+
+		order.Quantity -= quantity
+		other.Quantity -= quantity
+
+		if other.Quantity > 0 {
+			remaining = append(remaining, other)
+		}
+	}
+
+	*resting = remaining
+	b.Add(order)
+
+	return fills
+}
+
+// Bids returns the resting bids, best first.
+func (b *Book) Bids() []Order {
+	return copyOrders(b.bids)
+}
+
+// Asks returns the resting asks, best first.
+func (b *Book) Asks() []Order {
+	return copyOrders(b.asks)
+}
+
+func crosses(incoming *Order, resting *Order) bool {
+	if incoming.Side == Bid {
+		return incoming.Price >= resting.Price
+	}
+	return incoming.Price <= resting.Price
+}
+
+func bidBefore(a *Order, b *Order) bool {
+	if a.Price != b.Price {
+		return a.Price > b.Price
+	}
+	return a.Sequence < b.Sequence
+}
+
+func askBefore(a *Order, b *Order) bool {
+	if a.Price != b.Price {
+		return a.Price < b.Price
+	}
+	return a.Sequence < b.Sequence
+}
+
+func insertOrder(orders []*Order, order *Order, before func(a *Order, b *Order) bool) []*Order {
+	var i int = sort.Search(len(orders), func(i int) bool {
+		return before(order, orders[i])
+	})
+
+	orders = append(orders, nil)
+	copy(orders[i+1:], orders[i:])
+	orders[i] = order
+
+	return orders
+}
+
+func copyOrders(orders []*Order) []Order {
+	var result []Order = make([]Order, len(orders))
+	for i, order := range orders {
+		result[i] = *order
+	}
+	return result
+}
+
+func PriceToUnits(pricePerMwh float64) int64 {
+	return int64(math.Round(pricePerMwh * 100))
+}
+
+func PriceFromUnits(units int64) float64 {
+	return float64(units) / 100
+}
+
+func QuantityToUnits(quantityMwh float64) int64 {
+	return int64(math.Round(quantityMwh * 1000))
+}
+
+func QuantityFromUnits(units int64) float64 {
+	return float64(units) / 1000
+}
@@ -0,0 +1,163 @@
+package matching
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBookSubmit(t *testing.T) {
+	tests := []struct {
+		name     string
+		resting  []*Order
+		incoming *Order
+		fills    []Fill
+		bids     []Order
+		asks     []Order
+	}{
+		{
+			name: "equal prices fill in sequence order",
+			resting: []*Order{
+				{Id: "ask-late", Side: Ask, Owner: 1, Price: 10000, Quantity: 5000, Sequence: 2},
+				{Id: "ask-early", Side: Ask, Owner: 2, Price: 10000, Quantity: 5000, Sequence: 1},
+			},
+			incoming: &Order{Id: "bid", Side: Bid, Owner: 3, Price: 10000, Quantity: 7000, Sequence: 3},
+			fills: []Fill{
+				{BidId: "bid", AskId: "ask-early", Price: 10000, Quantity: 5000},
+				{BidId: "bid", AskId: "ask-late", Price: 10000, Quantity: 2000},
+			},
+			bids: []Order{},
+			asks: []Order{
+				{Id: "ask-late", Side: Ask, Owner: 1, Price: 10000, Quantity: 3000, Sequence: 2},
+			},
+		},
+		{
+			name: "better price fills before older order",
+			resting: []*Order{
+				{Id: "bid-old", Side: Bid, Owner: 1, Price: 9000, Quantity: 1000, Sequence: 1},
+				{Id: "bid-best", Side: Bid, Owner: 2, Price: 9500, Quantity: 1000, Sequence: 2},
+			},
+			incoming: &Order{Id: "ask", Side: Ask, Owner: 3, Price: 9000, Quantity: 1500, Sequence: 3},
+			fills: []Fill{
+				{BidId: "bid-best", AskId: "ask", Price: 9500, Quantity: 1000},
+				{BidId: "bid-old", AskId: "ask", Price: 9000, Quantity: 500},
+			},
+			bids: []Order{
+				{Id: "bid-old", Side: Bid, Owner: 1, Price: 9000, Quantity: 500, Sequence: 1},
+			},
+			asks: []Order{},
+		},
+		{
+			name: "partial fill rests the remainder of the incoming order",
+			resting: []*Order{
+				{Id: "ask", Side: Ask, Owner: 1, Price: 10000, Quantity: 2000, Sequence: 1},
+			},
+			incoming: &Order{Id: "bid", Side: Bid, Owner: 2, Price: 11000, Quantity: 5000, Sequence: 2},
+			fills: []Fill{
+				{BidId: "bid", AskId: "ask", Price: 10000, Quantity: 2000},
+			},
+			bids: []Order{
+				{Id: "bid", Side: Bid, Owner: 2, Price: 11000, Quantity: 3000, Sequence: 2},
+			},
+			asks: []Order{},
+		},
+		{
+			name: "orders of the same owner are skipped",
+			resting: []*Order{
+				{Id: "ask-own", Side: Ask, Owner: 1, Price: 9000, Quantity: 1000, Sequence: 1},
+				{Id: "ask-other", Side: Ask, Owner: 2, Price: 10000, Quantity: 1000, Sequence: 2},
+			},
+			incoming: &Order{Id: "bid", Side: Bid, Owner: 1, Price: 10000, Quantity: 1000, Sequence: 3},
+			fills: []Fill{
+				{BidId: "bid", AskId: "ask-other", Price: 10000, Quantity: 1000},
+			},
+			bids: []Order{},
+			asks: []Order{
+				{Id: "ask-own", Side: Ask, Owner: 1, Price: 9000, Quantity: 1000, Sequence: 1},
+			},
+		},
+		{
+			name: "orders that don't cross rest on the book",
+			resting: []*Order{
+				{Id: "ask", Side: Ask, Owner: 1, Price: 12000, Quantity: 1000, Sequence: 1},
+			},
+			incoming: &Order{Id: "bid", Side: Bid, Owner: 2, Price: 11000, Quantity: 1000, Sequence: 2},
+			fills:    nil,
+			bids: []Order{
+				{Id: "bid", Side: Bid, Owner: 2, Price: 11000, Quantity: 1000, Sequence: 2},
+			},
+			asks: []Order{
+				{Id: "ask", Side: Ask, Owner: 1, Price: 12000, Quantity: 1000, Sequence: 1},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var book *Book = NewBook()
+			for _, order := range test.resting {
+				book.Add(order)
+			}
+
+			fills := book.Submit(test.incoming)
+
+			if !reflect.DeepEqual(fills, test.fills) {
+				t.Errorf("fills = %+v, want %+v", fills, test.fills)
+			}
+			if bids := book.Bids(); !reflect.DeepEqual(bids, test.bids) {
+				t.Errorf("bids = %+v, want %+v", bids, test.bids)
+			}
+			if asks := book.Asks(); !reflect.DeepEqual(asks, test.asks) {
+				t.Errorf("asks = %+v, want %+v", asks, test.asks)
+			}
+		})
+	}
+}
+
+func TestBookAddSkipsEmptyOrders(t *testing.T) {
+	var book *Book = NewBook()
+	book.Add(&Order{Id: "bid", Side: Bid, Price: 10000, Quantity: 0})
+
+	if bids := book.Bids(); len(bids) != 0 {
+		t.Errorf("bids = %+v, want none", bids)
+	}
+}
+
+func TestQuantityUnits(t *testing.T) {
+	tests := []struct {
+		quantityMwh float64
+		units       int64
+	}{
+		{quantityMwh: 1, units: 1000},
+		{quantityMwh: 0.1 + 0.2, units: 300},
+		{quantityMwh: 2.0005, units: 2001},
+		{quantityMwh: 2.0004, units: 2000},
+		{quantityMwh: 123.456, units: 123456},
+	}
+
+	for _, test := range tests {
+		if units := QuantityToUnits(test.quantityMwh); units != test.units {
+			t.Errorf("QuantityToUnits(%v) = %d, want %d", test.quantityMwh, units, test.units)
+		}
+	}
+
+	if quantity := QuantityFromUnits(QuantityToUnits(123.456)); quantity != 123.456 {
+		t.Errorf("QuantityFromUnits round trip = %v, want 123.456", quantity)
+	}
+}
+
+func TestPriceUnits(t *testing.T) {
+	tests := []struct {
+		pricePerMwh float64
+		units       int64
+	}{
+		{pricePerMwh: 100, units: 10000},
+		{pricePerMwh: 150.555, units: 15056},
+		{pricePerMwh: 0.1 + 0.2, units: 30},
+	}
+
+	for _, test := range tests {
+		if units := PriceToUnits(test.pricePerMwh); units != test.units {
+			t.Errorf("PriceToUnits(%v) = %d, want %d", test.pricePerMwh, units, test.units)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"ecoply/internal/domain/handlers"
 	"ecoply/internal/domain/middlewares"
+	"ecoply/internal/domain/models"
 	"ecoply/internal/domain/services"
 	"time"
 
@@ -15,7 +16,7 @@ import (
 const htmlPath = "web/static"
 
 func registerRoutes(router *gin.Engine, s *ServerContext) {
-	var jwtService = services.NewJwtService(s.Cfg)
+	var jwtService services.JwtService = s.Services.JwtService
 	var authHandlers handlers.AuthHandlers = s.Handlers.AuthHandlers
 	var offerHandlers handlers.OfferHandlers = s.Handlers.OfferHandlers
 	var cnpjHandlers handlers.CnpjHandlers = s.Handlers.CnpjHandlers
@@ -24,6 +25,24 @@ func registerRoutes(router *gin.Engine, s *ServerContext) {
 	var purchaseHandlers handlers.PurchaseHandlers = s.Handlers.PurchaseHandlers
 	var contractHandlers handlers.ContractHandlers = s.Handlers.ContractHandlers
 	var analyticsHandlers handlers.AnalyticsHandlers = s.Handlers.AnalyticsHandlers
+	var jwksHandlers handlers.JwksHandlers = s.Handlers.JwksHandlers
+	var accountHandlers handlers.AccountHandlers = s.Handlers.AccountHandlers
+	var twoFactorHandlers handlers.TwoFactorHandlers = s.Handlers.TwoFactorHandlers
+	var twoFactorService services.TwoFactorService = s.Services.TwoFactorService
+	var agentHandlers handlers.AgentHandlers = s.Handlers.AgentHandlers
+	var adminHandlers handlers.AdminHandlers = s.Handlers.AdminHandlers
+	var profileHandlers handlers.ProfileHandlers = s.Handlers.ProfileHandlers
+	var privacyHandlers handlers.PrivacyHandlers = s.Handlers.PrivacyHandlers
+	var apiKeyHandlers handlers.ApiKeyHandlers = s.Handlers.ApiKeyHandlers
+	var apiKeyService services.ApiKeyService = s.Services.ApiKeyService
+	var auditHandlers handlers.AuditHandlers = s.Handlers.AuditHandlers
+	var sessionHandlers handlers.SessionHandlers = s.Handlers.SessionHandlers
+	var sessionService services.SessionService = s.Services.SessionService
+	var rfqHandlers handlers.RfqHandlers = s.Handlers.RfqHandlers
+	var orderBookHandlers handlers.OrderBookHandlers = s.Handlers.OrderBookHandlers
+	var auctionHandlers handlers.AuctionHandlers = s.Handlers.AuctionHandlers
+	var negotiationHandlers handlers.NegotiationHandlers = s.Handlers.NegotiationHandlers
+	var offerTemplateHandlers handlers.OfferTemplateHandlers = s.Handlers.OfferTemplateHandlers
 
 	router.LoadHTMLGlob(htmlPath + "/index.html")
 
@@ -34,6 +53,7 @@ func registerRoutes(router *gin.Engine, s *ServerContext) {
 
 	router.GET("/", rootHandler)
 	router.GET("/health", healthHandler)
+	router.GET("/.well-known/jwks.json", jwksHandlers.Get)
 	router.NoRoute(notFoundHandler)
 
 	api := router.Group("api")
@@ -43,11 +63,27 @@ func registerRoutes(router *gin.Engine, s *ServerContext) {
 		auth := v1.Group("auth")
 		{
 			auth.POST("login", authHandlers.Login)
+			auth.POST("login/2fa", authHandlers.LoginTwoFactor)
 			auth.POST("signup", authHandlers.SignUp)
-			auth.POST("refresh-token", middlewares.JwtAuthMiddleware(
+			auth.POST("invitations/accept", authHandlers.AcceptInvitation)
+			auth.POST("refresh-token", authHandlers.RefreshToken)
+			auth.POST("logout", authHandlers.Logout)
+			auth.POST("logout-all", middlewares.JwtAuthMiddleware(
 				s.Services.UserService,
 				jwtService,
-			), authHandlers.RefreshToken)
+				sessionService,
+			), authHandlers.LogoutAll)
+
+			password := auth.Group("password")
+			{
+				password.POST("forgot", accountHandlers.ForgotPassword)
+				password.POST("reset", accountHandlers.ResetPassword)
+			}
+
+			email := auth.Group("email")
+			{
+				email.POST("verify", accountHandlers.VerifyEmail)
+			}
 
 			available := auth.Group("available")
 			{
@@ -72,21 +108,38 @@ func registerRoutes(router *gin.Engine, s *ServerContext) {
 			brasilapi.GET("cep/:cep", brasilApiHandlers.GetCepData)
 		}
 
-		offer := v1.Group("offers", middlewares.JwtAuthMiddleware(
+		offer := v1.Group("offers", middlewares.ApiKeyMiddleware(apiKeyService), middlewares.JwtAuthMiddleware(
 			s.Services.UserService,
 			jwtService,
-		))
+			sessionService,
+		), middlewares.TwoFactorEnrollmentMiddleware(twoFactorService))
 		{
-			offer.GET(":uuid", offerHandlers.FindByUuid)
-			offer.GET("", offerHandlers.List)
-			offer.POST("", middlewares.SupplierMiddleware(s.Services.UserTypeService), offerHandlers.Create)
-			offer.PUT(":uuid", middlewares.SupplierMiddleware(s.Services.UserTypeService), offerHandlers.Update)
-			offer.DELETE(":uuid", middlewares.SupplierMiddleware(s.Services.UserTypeService), offerHandlers.Delete)
+			offer.GET(":uuid", middlewares.RequireScope(models.ApiKeyScopeOffersRead), offerHandlers.FindByUuid)
+			offer.GET(":uuid/quote", middlewares.RequireScope(models.ApiKeyScopeOffersRead), offerHandlers.Quote)
+			offer.GET("", middlewares.RequireScope(models.ApiKeyScopeOffersRead), offerHandlers.List)
+			offer.POST("", middlewares.RequireScope(models.ApiKeyScopeOffersWrite), middlewares.RequirePermission(models.PermissionOfferCreate), offerHandlers.Create)
+			offer.POST("import", middlewares.RequireScope(models.ApiKeyScopeOffersWrite), middlewares.RequirePermission(models.PermissionOfferCreate), offerHandlers.Import)
+			offer.PUT(":uuid", middlewares.RequireScope(models.ApiKeyScopeOffersWrite), middlewares.RequirePermission(models.PermissionOfferUpdate), offerHandlers.Update)
+			offer.DELETE(":uuid", middlewares.RequireScope(models.ApiKeyScopeOffersWrite), middlewares.RequirePermission(models.PermissionOfferDelete), offerHandlers.Delete)
+			offer.POST(":uuid/pause", middlewares.RequireScope(models.ApiKeyScopeOffersWrite), middlewares.RequirePermission(models.PermissionOfferUpdate), offerHandlers.Pause)
+			offer.POST(":uuid/resume", middlewares.RequireScope(models.ApiKeyScopeOffersWrite), middlewares.RequirePermission(models.PermissionOfferUpdate), offerHandlers.Resume)
 
 			purchase := offer.Group(":uuid/purchases")
 			{
-				purchase.GET("", middlewares.SupplierMiddleware(s.Services.UserTypeService), offerHandlers.Purchases)
-				purchase.POST("", purchaseHandlers.Create)
+				purchase.GET("", middlewares.RequireScope(models.ApiKeyScopeSalesRead), middlewares.RequirePermission(models.PermissionSaleRead), offerHandlers.Purchases)
+				purchase.POST("", middlewares.RequireScope(models.ApiKeyScopePurchasesWrite), purchaseHandlers.Create)
+			}
+
+			bids := offer.Group(":uuid/bids")
+			{
+				bids.GET("", middlewares.RequireScope(models.ApiKeyScopeOffersRead), auctionHandlers.Bids)
+				bids.POST("", middlewares.RequireScope(models.ApiKeyScopePurchasesWrite), auctionHandlers.Bid)
+			}
+
+			offerNegotiations := offer.Group(":uuid/negotiations")
+			{
+				offerNegotiations.GET("", middlewares.RequireScope(models.ApiKeyScopeOffersRead), negotiationHandlers.List)
+				offerNegotiations.POST("", middlewares.RequireScope(models.ApiKeyScopePurchasesWrite), negotiationHandlers.Open)
 			}
 
 			checkout := offer.Group(":uuid/checkout")
@@ -95,33 +148,142 @@ func registerRoutes(router *gin.Engine, s *ServerContext) {
 			}
 		}
 
-		purchases := v1.Group("purchases", middlewares.JwtAuthMiddleware(
+		offerTemplates := v1.Group("offer-templates", middlewares.ApiKeyMiddleware(apiKeyService), middlewares.JwtAuthMiddleware(
 			s.Services.UserService,
 			jwtService,
-		))
+			sessionService,
+		), middlewares.TwoFactorEnrollmentMiddleware(twoFactorService), middlewares.RequirePermission(models.PermissionOfferCreate))
 		{
-			purchases.GET("", purchaseHandlers.ListPurchases)
-			purchases.GET(":uuid", purchaseHandlers.FindByUuid)
-			purchases.POST(":uuid/cancel", purchaseHandlers.Cancel)
-			purchases.GET(":uuid/contract", contractHandlers.Get)
+			offerTemplates.GET("", middlewares.RequireScope(models.ApiKeyScopeOffersRead), offerTemplateHandlers.List)
+			offerTemplates.POST("", middlewares.RequireScope(models.ApiKeyScopeOffersWrite), offerTemplateHandlers.Create)
+			offerTemplates.GET(":uuid", middlewares.RequireScope(models.ApiKeyScopeOffersRead), offerTemplateHandlers.FindByUuid)
+			offerTemplates.PUT(":uuid", middlewares.RequireScope(models.ApiKeyScopeOffersWrite), offerTemplateHandlers.Update)
+			offerTemplates.DELETE(":uuid", middlewares.RequireScope(models.ApiKeyScopeOffersWrite), offerTemplateHandlers.Delete)
+			offerTemplates.GET(":uuid/preview", middlewares.RequireScope(models.ApiKeyScopeOffersRead), offerTemplateHandlers.Preview)
+			offerTemplates.POST(":uuid/offers", middlewares.RequireScope(models.ApiKeyScopeOffersWrite), offerTemplateHandlers.Publish)
+			offerTemplates.POST(":uuid/pause", middlewares.RequireScope(models.ApiKeyScopeOffersWrite), offerTemplateHandlers.Pause)
+			offerTemplates.POST(":uuid/resume", middlewares.RequireScope(models.ApiKeyScopeOffersWrite), offerTemplateHandlers.Resume)
 		}
 
-		sales := v1.Group("sales", middlewares.JwtAuthMiddleware(
+		purchases := v1.Group("purchases", middlewares.ApiKeyMiddleware(apiKeyService), middlewares.JwtAuthMiddleware(
 			s.Services.UserService,
 			jwtService,
-		), middlewares.SupplierMiddleware(s.Services.UserTypeService))
+			sessionService,
+		), middlewares.TwoFactorEnrollmentMiddleware(twoFactorService))
 		{
-			sales.GET("", purchaseHandlers.ListSales)
+			purchases.GET("", middlewares.RequireScope(models.ApiKeyScopePurchasesRead), purchaseHandlers.ListPurchases)
+			purchases.GET(":uuid", middlewares.RequireScope(models.ApiKeyScopePurchasesRead), purchaseHandlers.FindByUuid)
+			purchases.POST(":uuid/cancel", middlewares.RequireScope(models.ApiKeyScopePurchasesWrite), purchaseHandlers.Cancel)
+			purchases.GET(":uuid/contract", middlewares.RequireScope(models.ApiKeyScopePurchasesRead), contractHandlers.Get)
+		}
+
+		sales := v1.Group("sales", middlewares.ApiKeyMiddleware(apiKeyService), middlewares.JwtAuthMiddleware(
+			s.Services.UserService,
+			jwtService,
+			sessionService,
+		), middlewares.TwoFactorEnrollmentMiddleware(twoFactorService), middlewares.RequirePermission(models.PermissionSaleRead))
+		{
+			sales.GET("", middlewares.RequireScope(models.ApiKeyScopeSalesRead), purchaseHandlers.ListSales)
+		}
+
+		rfqs := v1.Group("rfqs", middlewares.JwtAuthMiddleware(
+			s.Services.UserService,
+			jwtService,
+			sessionService,
+		), middlewares.TwoFactorEnrollmentMiddleware(twoFactorService))
+		{
+			rfqs.GET("", middlewares.RequirePermission(models.PermissionRfqQuote), rfqHandlers.List)
+			rfqs.POST("", rfqHandlers.Create)
+			rfqs.GET(":uuid", rfqHandlers.FindByUuid)
+			rfqs.POST(":uuid/cancel", rfqHandlers.Cancel)
+			rfqs.GET(":uuid/quotes", rfqHandlers.Quotes)
+			rfqs.POST(":uuid/quotes", middlewares.RequirePermission(models.PermissionRfqQuote), rfqHandlers.Quote)
+			rfqs.POST(":uuid/quotes/:quote_uuid/accept", rfqHandlers.Accept)
+		}
+
+		negotiations := v1.Group("negotiations", middlewares.JwtAuthMiddleware(
+			s.Services.UserService,
+			jwtService,
+			sessionService,
+		), middlewares.TwoFactorEnrollmentMiddleware(twoFactorService))
+		{
+			negotiations.GET(":uuid", negotiationHandlers.FindByUuid)
+			negotiations.POST(":uuid/counter", negotiationHandlers.Counter)
+			negotiations.POST(":uuid/accept", negotiationHandlers.Accept)
+			negotiations.POST(":uuid/reject", negotiationHandlers.Reject)
+			negotiations.POST(":uuid/cancel", negotiationHandlers.Cancel)
+		}
+
+		orderBook := v1.Group("order-book", middlewares.JwtAuthMiddleware(
+			s.Services.UserService,
+			jwtService,
+			sessionService,
+		), middlewares.TwoFactorEnrollmentMiddleware(twoFactorService))
+		{
+			orderBook.GET("", orderBookHandlers.Depth)
+			orderBook.POST("bids", orderBookHandlers.PlaceBid)
+			orderBook.DELETE("bids/:uuid", orderBookHandlers.CancelBid)
 		}
 
 		me := v1.Group("me").Use(middlewares.JwtAuthMiddleware(
 			s.Services.UserService,
 			jwtService,
+			sessionService,
 		))
 		{
 			me.GET("", authHandlers.Me)
-			me.GET("offers", middlewares.SupplierMiddleware(s.Services.UserTypeService), offerHandlers.FromUser)
+			me.PATCH("", profileHandlers.Update)
+			me.PUT("password", profileHandlers.ChangePassword)
+			me.PUT("address", profileHandlers.UpdateAddress)
+			me.GET("export", privacyHandlers.Export)
+			me.DELETE("", privacyHandlers.DeleteAccount)
+			me.GET("offers", middlewares.RequirePermission(models.PermissionOfferReadOwn), offerHandlers.FromUser)
+			me.GET("offers/export", middlewares.RequirePermission(models.PermissionOfferReadOwn), offerHandlers.Export)
+			me.GET("rfqs", rfqHandlers.FromUser)
+			me.GET("bids", orderBookHandlers.Bids)
 			me.GET("analytics", analyticsHandlers.User)
+			me.GET("activity", auditHandlers.Activity)
+			me.POST("email/verification", accountHandlers.ResendEmailVerification)
+
+			me.GET("2fa", twoFactorHandlers.Status)
+			me.POST("2fa", twoFactorHandlers.BeginEnrollment)
+			me.POST("2fa/confirm", twoFactorHandlers.ConfirmEnrollment)
+			me.DELETE("2fa", twoFactorHandlers.Disable)
+			me.POST("2fa/recovery-codes", twoFactorHandlers.RegenerateRecoveryCodes)
+
+			me.GET("agent/members", agentHandlers.Members)
+			me.PUT("agent/members/:uuid/role", agentHandlers.UpdateMemberRole)
+			me.GET("agent/invitations", agentHandlers.Invitations)
+			me.POST("agent/invitations", agentHandlers.Invite)
+			me.DELETE("agent/invitations/:uuid", agentHandlers.RevokeInvitation)
+
+			me.GET("api-keys", apiKeyHandlers.List)
+			me.POST("api-keys", apiKeyHandlers.Create)
+			me.DELETE("api-keys/:uuid", apiKeyHandlers.Revoke)
+
+			me.GET("sessions", sessionHandlers.List)
+			me.DELETE("sessions/:id", sessionHandlers.Revoke)
+		}
+
+		admin := v1.Group("admin", middlewares.JwtAuthMiddleware(
+			s.Services.UserService,
+			jwtService,
+			sessionService,
+		), middlewares.TwoFactorEnrollmentMiddleware(twoFactorService), middlewares.RequirePermission(models.PermissionAdminAccess))
+		{
+			admin.GET("users", adminHandlers.ListUsers)
+			admin.POST("users/:uuid/suspend", adminHandlers.SuspendUser)
+			admin.POST("users/:uuid/unsuspend", adminHandlers.UnsuspendUser)
+			admin.POST("users/:uuid/unlock", adminHandlers.UnlockUser)
+			admin.GET("agents", adminHandlers.ListAgents)
+			admin.POST("offers/:uuid/expire", adminHandlers.ExpireOffer)
+			admin.POST("offers/:uuid/take-down", adminHandlers.TakeDownOffer)
+			admin.GET("purchases/:uuid", adminHandlers.GetPurchase)
+			admin.GET("purchases/:uuid/contract", adminHandlers.GetContract)
+			admin.POST("purchases/:uuid/complete", adminHandlers.CompletePurchase)
+			admin.POST("purchases/:uuid/cancel", adminHandlers.CancelPurchase)
+			admin.GET("actions", adminHandlers.ListActions)
+			admin.GET("audit-events", auditHandlers.List)
 		}
 
 		analytics := v1.Group("analytics")
@@ -5,6 +5,7 @@ import (
 	"ecoply/internal/domain/handlers"
 	"ecoply/internal/domain/services"
 	"ecoply/internal/mlog"
+	"log"
 	"net"
 	"strconv"
 
@@ -27,6 +28,21 @@ type ServerServices struct {
 	services.AnalyticsService
 	services.CceeService
 	services.BrasilApiService
+	services.JwtService
+	services.AccountService
+	services.TwoFactorService
+	services.AgentService
+	services.AdminService
+	services.ProfileService
+	services.PrivacyService
+	services.ApiKeyService
+	services.AuditService
+	services.SessionService
+	services.RfqService
+	services.OrderBookService
+	services.AuctionService
+	services.NegotiationService
+	services.OfferTemplateService
 }
 
 type ServerHandlers struct {
@@ -38,6 +54,21 @@ type ServerHandlers struct {
 	handlers.AnalyticsHandlers
 	handlers.CceeHandlers
 	handlers.BrasilApiHandlers
+	handlers.JwksHandlers
+	handlers.AccountHandlers
+	handlers.TwoFactorHandlers
+	handlers.AgentHandlers
+	handlers.AdminHandlers
+	handlers.ProfileHandlers
+	handlers.PrivacyHandlers
+	handlers.ApiKeyHandlers
+	handlers.AuditHandlers
+	handlers.SessionHandlers
+	handlers.RfqHandlers
+	handlers.OrderBookHandlers
+	handlers.AuctionHandlers
+	handlers.NegotiationHandlers
+	handlers.OfferTemplateHandlers
 }
 
 type ServerContext struct {
@@ -49,6 +80,10 @@ type ServerContext struct {
 func New(s *ServerContext) *Server {
 	var engine *gin.Engine = gin.Default()
 
+	if err := engine.SetTrustedProxies(s.Cfg.ServerTrustedProxies); err != nil {
+		log.Fatalf("Invalid trusted proxies: %v", err)
+	}
+
 	registerRoutes(engine, s)
 
 	mlog.LogGinRoutes(engine)